@@ -26,10 +26,24 @@ type Config struct {
 	UpgradeVotingShortHistoryMinShift int
 	Data                              *DataConfig
 	TreeSnapshotDir                   string
+	TreeSnapshots                     TreeSnapshotsConfig
+	Plugins                           PluginsConfig
 	VoteCounting                      VoteCountingConfig
-	CheckBalances                     bool
 	WasmInfoUrl                       string
+	LocalCompiler                     *LocalCompilerConfig
+	ContractAbi                       ContractAbiConfig
 	DisableDelegationHistory          bool // TODO temporary flag
+	Health                            HealthConfig
+	Stream                            StreamConfig
+	Webhooks                          WebhooksConfig
+	CatchUp                           CatchUpConfig
+	Finality                          FinalityConfig
+	Audit                             AuditConfig
+	Jobs                              JobsConfig
+	Admin                             AdminConfig
+	BalanceHistory                    BalanceHistoryConfig
+	// ShutdownTimeoutSec is the max time to complete indexing of the current block and save cached data on shutdown
+	ShutdownTimeoutSec int
 }
 
 type Api struct {
@@ -40,11 +54,154 @@ type Api struct {
 type PerformanceMonitorConfig struct {
 	Enabled     bool
 	BlocksToLog int
+	// Type is either "log" (default) or "metrics"
+	Type string
+	// MetricsPort is a separate port to serve /metrics on, 0 means /api/metrics on the api server
+	MetricsPort int
+}
+
+type HealthConfig struct {
+	// MaxSyncLag is the max difference between node head height and last indexed height for the indexer to be ready
+	MaxSyncLag uint64
+	// MaxBlockAgeSec is the max time since the last processed block for the indexer to be ready
+	MaxBlockAgeSec int
+}
+
+type StreamConfig struct {
+	Enabled bool
+	// BufferSize is the max number of messages queued for a subscriber before it is disconnected as too slow
+	BufferSize int
+	// MaxReplayBlocks limits how far back subscribers can resume using fromHeight, 0 means no limit
+	MaxReplayBlocks uint64
+}
+
+type WebhooksConfig struct {
+	Enabled bool
+	// BatchSize is the max number of events to fan out and deliveries to send at a time
+	BatchSize int
+	// MaxAttempts is the number of failed delivery attempts after which the event is moved to dead letters
+	MaxAttempts int
+	// RetryIntervalSec is the delay before the first retry, it is doubled for each next one up to MaxRetryIntervalSec
+	RetryIntervalSec    int
+	MaxRetryIntervalSec int
+	RequestTimeoutSec   int
+	// DeliveredRetentionHours is how long delivered events are kept to send reverted events if their blocks are reset
+	DeliveredRetentionHours int
+}
+
+type FinalityConfig struct {
+	// Confirmations is the number of blocks on top of a block after which the block is considered final
+	Confirmations uint64
+}
+
+type AuditConfig struct {
+	Enabled bool
+	// IntervalBlocks is the number of blocks between comparisons of the db data with the node state
+	IntervalBlocks uint64
+	// Repair enables restoring balances, delegations and pool sizes from the node state if they are inconsistent
+	Repair bool
+	// MaxTokenBalances is the max number of randomly chosen token balances checked per audit, 0 disables the check
+	MaxTokenBalances int
+}
+
+type AdminConfig struct {
+	Enabled bool
+	// Port is a separate port to serve the admin api on, it should not be exposed publicly
+	Port int
+	// Token is expected in the Authorization header of admin requests as "Bearer <Token>"
+	Token string
+}
+
+type BalanceHistoryConfig struct {
+	// CheckpointIntervalBlocks is the number of blocks between saving balances of updated addresses, checkpoints limit
+	// the number of balance updates scanned to find a balance at a height, 0 disables checkpoints
+	CheckpointIntervalBlocks uint64
+}
+
+type TreeSnapshotsConfig struct {
+	// KeepEpochs is the number of latest epoch snapshots kept in TreeSnapshotDir, 0 means all snapshots are kept
+	KeepEpochs int
+	// CacheSizeMb is the max approximate memory size of epoch states loaded from snapshots to serve api requests
+	CacheSizeMb int
+	// ExtractDir enables extracting loaded snapshots to LevelDB databases in the dir to reopen them without parsing the
+	// snapshot files and to keep states on disk instead of memory
+	ExtractDir string
+}
+
+// LocalCompilerConfig enables building submitted contract sources locally instead of the service at WasmInfoUrl
+type LocalCompilerConfig struct {
+	// Command is the compiler executable with args run in the dir the source archive is extracted to
+	Command []string
+	// Output is the path of the built wasm file relative to the source dir
+	Output string
+	// VersionCommand prints the compiler version recorded with builds
+	VersionCommand []string
+	TimeoutSec     int
+	// TempDir holds dirs of running builds, the system temp dir is used if it is empty
+	TempDir         string
+	MaxSourceSizeMb int
+	// MaxLogSizeKb limits the recorded tail of the compiler output
+	MaxLogSizeKb int
+}
+
+type ContractAbiConfig struct {
+	// RequireVerified allows uploading ABIs of contracts with verified source code only
+	RequireVerified bool
+}
+
+type PluginsConfig struct {
+	LargeTransfers LargeTransfersPluginConfig
+	Manifests      ManifestsPluginConfig
+}
+
+type LargeTransfersPluginConfig struct {
+	Enabled bool
+	// MinAmount is the min number of coins transferred by a tx to be saved to the large_transfers table
+	MinAmount float64
+}
+
+type ManifestsPluginConfig struct {
+	// Dir contains yaml or json manifests declaring contract events to decode into tables, empty value disables manifests
+	Dir string
+}
+
+type JobsConfig struct {
+	// PollIntervalSec is the delay between checks for due jobs if the queue is drained
+	PollIntervalSec      int
+	FlipSize             JobTypeConfig
+	ContractVerification JobTypeConfig
+	// WasmIntrospection parses code of wasm contracts deployed before the introspection was introduced
+	WasmIntrospection JobTypeConfig
+}
+
+type JobTypeConfig struct {
+	// Concurrency is the max number of jobs of the type processed at a time
+	Concurrency int
+	// MaxAttempts is the number of failed attempts after which the job is moved to dead letters
+	MaxAttempts int
+	// RetryIntervalSec is the delay before the first retry, it is doubled for each next one up to MaxRetryIntervalSec
+	RetryIntervalSec    int
+	MaxRetryIntervalSec int
+	// LeaseSec is the max time to process the job before it can be claimed again
+	LeaseSec int
+}
+
+type CatchUpConfig struct {
+	// Workers is the number of blocks converted concurrently while catching up with the node, 0 disables catch-up mode
+	Workers int
+	// MaxBlocksInFlight is the max number of blocks converted or waiting to be saved
+	MaxBlocksInFlight int
+	// MinBlockAgeSec is the min age of incoming blocks to be indexed in catch-up mode, younger blocks are indexed one by one
+	MinBlockAgeSec int
+	// SaveBatchSize is the max number of blocks converted in catch-up mode saved in a single db transaction
+	SaveBatchSize int
 }
 
 type PostgresConfig struct {
-	ConnStr    string
-	ScriptsDir string
+	ConnStr            string
+	ScriptsDir         string
+	MigrationsDir      string
+	MigrationsBaseline string
 }
 
 type RuntimeMigrationConfig struct {
@@ -89,7 +246,8 @@ func newDefaultConfig() *Config {
 	return &Config{
 		NodeConfigFile: filepath.Join("conf", "node.json"),
 		Postgres: PostgresConfig{
-			ScriptsDir: filepath.Join("resources", "scripts", "indexer"),
+			ScriptsDir:    filepath.Join("resources", "scripts", "indexer"),
+			MigrationsDir: filepath.Join("resources", "scripts", "migration"),
 		},
 		Verbosity:     3,
 		NodeVerbosity: 0,
@@ -102,6 +260,73 @@ func newDefaultConfig() *Config {
 			Port:        8080,
 			LogFileSize: 100 * 1024,
 		},
+		Health: HealthConfig{
+			MaxSyncLag:     5,
+			MaxBlockAgeSec: 300,
+		},
+		Stream: StreamConfig{
+			BufferSize:      1000,
+			MaxReplayBlocks: 10000,
+		},
+		Webhooks: WebhooksConfig{
+			BatchSize:               100,
+			MaxAttempts:             10,
+			RetryIntervalSec:        10,
+			MaxRetryIntervalSec:     3600,
+			RequestTimeoutSec:       10,
+			DeliveredRetentionHours: 24,
+		},
+		CatchUp: CatchUpConfig{
+			MaxBlocksInFlight: 32,
+			MinBlockAgeSec:    600,
+			SaveBatchSize:     16,
+		},
+		Finality: FinalityConfig{
+			Confirmations: 10,
+		},
+		Audit: AuditConfig{
+			IntervalBlocks:   1000,
+			MaxTokenBalances: 100,
+		},
+		Jobs: JobsConfig{
+			PollIntervalSec: 5,
+			FlipSize: JobTypeConfig{
+				Concurrency:         10,
+				MaxAttempts:         10,
+				RetryIntervalSec:    60,
+				MaxRetryIntervalSec: 3600,
+				LeaseSec:            600,
+			},
+			ContractVerification: JobTypeConfig{
+				Concurrency:         2,
+				MaxAttempts:         5,
+				RetryIntervalSec:    10,
+				MaxRetryIntervalSec: 600,
+				LeaseSec:            600,
+			},
+			WasmIntrospection: JobTypeConfig{
+				Concurrency:         2,
+				MaxAttempts:         5,
+				RetryIntervalSec:    10,
+				MaxRetryIntervalSec: 600,
+				LeaseSec:            60,
+			},
+		},
+		Admin: AdminConfig{
+			Port: 8081,
+		},
+		BalanceHistory: BalanceHistoryConfig{
+			CheckpointIntervalBlocks: 10000,
+		},
+		TreeSnapshots: TreeSnapshotsConfig{
+			CacheSizeMb: 1024,
+		},
+		Plugins: PluginsConfig{
+			LargeTransfers: LargeTransfersPluginConfig{
+				MinAmount: 100000,
+			},
+		},
+		ShutdownTimeoutSec:                30,
 		CommitteeRewardBlocksCount:        1000,
 		UpgradeVotingShortHistoryItems:    400,
 		UpgradeVotingShortHistoryMinShift: 5,
@@ -0,0 +1,205 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	types2 "github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+)
+
+// deployMethod is the method name of tx receipts of wasm contract deploys
+const deployMethod = "deploy"
+
+// ABI describes args of methods and events of a wasm contract to decode its calls, it is uploaded as json:
+//
+//	{
+//	  "methods": [
+//	    {"name": "transfer", "args": [{"name": "to", "type": "address"}, {"name": "amount", "type": "bigint"}]},
+//	    {"name": "balanceOf", "args": [{"name": "owner", "type": "address"}], "returns": "bigint"}
+//	  ],
+//	  "events": [
+//	    {"name": "transfer", "args": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"},
+//	      {"name": "amount", "type": "bigint"}]}
+//	  ]
+//	}
+//
+// Args of deploy txs are described by the method "deploy".
+type ABI struct {
+	Methods []*Method `json:"methods,omitempty"`
+	Events  []*Event  `json:"events,omitempty"`
+}
+
+type Method struct {
+	Name string `json:"name"`
+	Args []*Arg `json:"args,omitempty"`
+	// Returns is the type of the action result, the result is not decoded if it is empty
+	Returns ArgType `json:"returns,omitempty"`
+}
+
+type Event struct {
+	Name string `json:"name"`
+	Args []*Arg `json:"args,omitempty"`
+}
+
+type Arg struct {
+	Name string  `json:"name"`
+	Type ArgType `json:"type"`
+}
+
+// Call holds raw data of the contract call to decode, the result and events are empty for mem pool txs
+type Call struct {
+	Method string
+	Args   [][]byte
+	Result []byte
+	Events []*types.TxEvent
+}
+
+// Parse reads the ABI json, unknown fields are not allowed to catch typos
+func Parse(data []byte) (*ABI, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	abi := &ABI{}
+	if err := decoder.Decode(abi); err != nil {
+		return nil, err
+	}
+	if err := abi.validate(); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
+func (a *ABI) validate() error {
+	if len(a.Methods) == 0 && len(a.Events) == 0 {
+		return errors.New("no methods and events")
+	}
+	methods := make(map[string]struct{}, len(a.Methods))
+	for _, method := range a.Methods {
+		if len(method.Name) == 0 {
+			return errors.New("empty method name")
+		}
+		if _, ok := methods[method.Name]; ok {
+			return errors.Errorf("duplicated method %v", method.Name)
+		}
+		methods[method.Name] = struct{}{}
+		if err := validateArgs(method.Args); err != nil {
+			return errors.Wrapf(err, "invalid method %v", method.Name)
+		}
+		if len(method.Returns) > 0 && !method.Returns.Valid() {
+			return errors.Errorf("unknown type %q of method %v result", method.Returns, method.Name)
+		}
+	}
+	events := make(map[string]struct{}, len(a.Events))
+	for _, event := range a.Events {
+		if len(event.Name) == 0 {
+			return errors.New("empty event name")
+		}
+		if _, ok := events[event.Name]; ok {
+			return errors.Errorf("duplicated event %v", event.Name)
+		}
+		events[event.Name] = struct{}{}
+		if err := validateArgs(event.Args); err != nil {
+			return errors.Wrapf(err, "invalid event %v", event.Name)
+		}
+	}
+	return nil
+}
+
+func validateArgs(args []*Arg) error {
+	for _, arg := range args {
+		if len(arg.Name) == 0 {
+			return errors.New("empty arg name")
+		}
+		if !arg.Type.Valid() {
+			return errors.Errorf("unknown type %q of arg %v", arg.Type, arg.Name)
+		}
+	}
+	return nil
+}
+
+func (a *ABI) method(name string) *Method {
+	for _, method := range a.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+func (a *ABI) event(name string) *Event {
+	for _, event := range a.Events {
+		if event.Name == name {
+			return event
+		}
+	}
+	return nil
+}
+
+// CallArgs returns the method and args of the call or deploy contract tx payload
+func CallArgs(txType types.TxType, payload []byte) (string, [][]byte, bool) {
+	tx := &types.Transaction{Type: txType, Payload: payload}
+	switch txType {
+	case types.CallContractTx:
+		if attachment := attachments.ParseCallContractAttachment(tx); attachment != nil {
+			return attachment.Method, attachment.Args, true
+		}
+	case types.DeployContractTx:
+		if attachment := attachments.ParseDeployContractAttachment(tx); attachment != nil {
+			return deployMethod, attachment.Args, true
+		}
+	}
+	return "", nil, false
+}
+
+// decodeCall returns nil args if the method is not described by the ABI
+func (a *ABI) decodeCall(method string, args [][]byte, result []byte) ([]*types2.DecodedValue, *types2.DecodedValue) {
+	m := a.method(method)
+	if m == nil {
+		return nil, nil
+	}
+	var decodedResult *types2.DecodedValue
+	if len(m.Returns) > 0 && result != nil {
+		decodedResult = decodeValue("", m.Returns, result)
+	}
+	return decodeArgs(m.Args, args), decodedResult
+}
+
+// decodeEvent returns nil if the event is not described by the ABI
+func (a *ABI) decodeEvent(event *types.TxEvent) *types2.DecodedEvent {
+	e := a.event(event.EventName)
+	if e == nil {
+		return nil
+	}
+	return &types2.DecodedEvent{
+		Contract: event.Contract.Hex(),
+		Name:     event.EventName,
+		Args:     decodeArgs(e.Args, event.Data),
+	}
+}
+
+func decodeArgs(args []*Arg, data [][]byte) []*types2.DecodedValue {
+	res := make([]*types2.DecodedValue, len(args))
+	for i, arg := range args {
+		var argData []byte
+		if i < len(data) {
+			argData = data[i]
+		}
+		res[i] = decodeValue(arg.Name, arg.Type, argData)
+	}
+	return res
+}
+
+func decodeValue(name string, argType ArgType, data []byte) *types2.DecodedValue {
+	res := &types2.DecodedValue{
+		Name: name,
+		Type: string(argType),
+	}
+	value, err := argType.Decode(data)
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Value = value
+	}
+	return res
+}
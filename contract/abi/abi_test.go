@@ -0,0 +1,95 @@
+package abi
+
+import (
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	types2 "github.com/idena-network/idena-indexer/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testAbi = `{
+  "methods": [
+    {"name": "deploy", "args": [{"name": "name", "type": "string"}]},
+    {"name": "transfer", "args": [{"name": "to", "type": "address"}, {"name": "amount", "type": "bigint"}], "returns": "bool"}
+  ],
+  "events": [
+    {"name": "transfer", "args": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "amount", "type": "bigint"}]}
+  ]
+}`
+
+func Test_Parse(t *testing.T) {
+	abi, err := Parse([]byte(testAbi))
+	require.Nil(t, err)
+	require.Len(t, abi.Methods, 2)
+	require.Equal(t, ArgTypeBool, abi.Methods[1].Returns)
+	require.Len(t, abi.Events, 1)
+
+	for _, invalid := range []string{
+		`{}`,
+		`{"methods": [{"name": ""}]}`,
+		`{"methods": [{"name": "m"}, {"name": "m"}]}`,
+		`{"methods": [{"name": "m", "args": [{"name": "a", "type": "float"}]}]}`,
+		`{"methods": [{"name": "m", "returns": "float"}]}`,
+		`{"methods": [{"name": "m", "args": [{"type": "bool"}]}]}`,
+		`{"events": [{"name": "e"}, {"name": "e"}]}`,
+		`{"events": [{"name": "e", "args": [{"name": "a", "typ": "bool"}]}]}`,
+	} {
+		_, err := Parse([]byte(invalid))
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func Test_ABIDecode(t *testing.T) {
+	abi, err := Parse([]byte(testAbi))
+	require.Nil(t, err)
+	to := common.Address{0x1}
+
+	args, result := abi.decodeCall("transfer", [][]byte{to.Bytes(), {0x1, 0x0}}, []byte{0x1})
+	require.Equal(t, []*types2.DecodedValue{
+		{Name: "to", Type: "address", Value: to.Hex()},
+		{Name: "amount", Type: "bigint", Value: "256"},
+	}, args)
+	require.Equal(t, &types2.DecodedValue{Type: "bool", Value: true}, result)
+
+	args, result = abi.decodeCall("deploy", [][]byte{{0xff}}, nil)
+	require.Equal(t, []*types2.DecodedValue{{Name: "name", Type: "string", Error: "invalid string"}}, args)
+	require.Nil(t, result)
+
+	args, result = abi.decodeCall("burn", nil, nil)
+	require.Nil(t, args)
+	require.Nil(t, result)
+
+	contract := common.Address{0x2}
+	event := abi.decodeEvent(&types.TxEvent{Contract: contract, EventName: "transfer", Data: [][]byte{{0x3}, to.Bytes()}})
+	require.Equal(t, &types2.DecodedEvent{
+		Contract: contract.Hex(),
+		Name:     "transfer",
+		Args: []*types2.DecodedValue{
+			{Name: "from", Type: "address", Value: common.Address{0x3}.Hex()},
+			{Name: "to", Type: "address", Value: to.Hex()},
+			{Name: "amount", Type: "bigint"},
+		},
+	}, event)
+	require.Nil(t, abi.decodeEvent(&types.TxEvent{EventName: "approve"}))
+}
+
+func Test_CallArgs(t *testing.T) {
+	payload, err := attachments.CreateCallContractAttachment("transfer", []byte{0x1}, []byte{0x2}).ToBytes()
+	require.Nil(t, err)
+	method, args, ok := CallArgs(types.CallContractTx, payload)
+	require.True(t, ok)
+	require.Equal(t, "transfer", method)
+	require.Equal(t, [][]byte{{0x1}, {0x2}}, args)
+
+	payload, err = attachments.CreateDeployContractAttachment(common.Hash{}, []byte{0x1}, nil, []byte("name")).ToBytes()
+	require.Nil(t, err)
+	method, args, ok = CallArgs(types.DeployContractTx, payload)
+	require.True(t, ok)
+	require.Equal(t, "deploy", method)
+	require.Equal(t, [][]byte{[]byte("name")}, args)
+
+	_, _, ok = CallArgs(types.SendTx, payload)
+	require.False(t, ok)
+}
@@ -0,0 +1,104 @@
+package abi
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/verification"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+// SaveAbi saves the ABI of the wasm contract if it has none, requireVerified rejects ABIs of contracts without verified
+// source
+func (p *Postgres) SaveAbi(contractAddress common.Address, abi []byte, requireVerified bool) (usrErr, err error) {
+	const contractQuery = `SELECT c.contract_address_id, c.code IS NOT NULL, coalesce(cv.state = $2, false)
+FROM contracts c
+         JOIN addresses a ON a.id = c.contract_address_id
+         LEFT JOIN contract_verifications cv ON cv.contract_address_id = c.contract_address_id
+WHERE lower(a.address) = lower($1)`
+	const saveQuery = `INSERT INTO contract_abis (contract_address_id, "timestamp", abi)
+VALUES ($1, $2, $3)
+ON CONFLICT (contract_address_id) DO NOTHING`
+	var contractAddressId uint64
+	var wasm, verified bool
+	err = p.db.QueryRow(contractQuery, conversion.ConvertAddress(contractAddress), verification.StateVerified).
+		Scan(&contractAddressId, &wasm, &verified)
+	if err == sql.ErrNoRows {
+		return errors.Errorf("contract %v not found", contractAddress.Hex()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !wasm {
+		return errors.New("ABIs are supported for wasm contracts only"), nil
+	}
+	if requireVerified && !verified {
+		return errors.Errorf("contract %v is not verified", contractAddress.Hex()), nil
+	}
+	res, err := p.db.Exec(saveQuery, contractAddressId, time.Now().UTC().Unix(), abi)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if saved == 0 {
+		return errors.Errorf("contract %v already has ABI, it has to be deleted before uploading a new one",
+			contractAddress.Hex()), nil
+	}
+	return nil, nil
+}
+
+// DeleteAbi removes the ABI of the contract
+func (p *Postgres) DeleteAbi(contractAddress common.Address) (usrErr, err error) {
+	const query = `DELETE
+FROM contract_abis ca
+    USING addresses a
+WHERE a.id = ca.contract_address_id
+  AND lower(a.address) = lower($1)`
+	res, err := p.db.Exec(query, conversion.ConvertAddress(contractAddress))
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return errors.Errorf("contract %v has no ABI", contractAddress.Hex()), nil
+	}
+	return nil, nil
+}
+
+// Abi returns nil if there is no ABI of the contract
+func (p *Postgres) Abi(contractAddress common.Address) ([]byte, error) {
+	const query = `SELECT ca.abi
+FROM contract_abis ca
+         JOIN addresses a ON a.id = ca.contract_address_id
+WHERE lower(a.address) = lower($1)`
+	var res []byte
+	err := p.db.QueryRow(query, conversion.ConvertAddress(contractAddress)).Scan(&res)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return res, err
+}
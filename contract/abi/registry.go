@@ -0,0 +1,118 @@
+package abi
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	// MaxAbiSize limits uploaded ABIs
+	MaxAbiSize   = 64 << 10
+	cacheTimeout = time.Minute
+)
+
+// Registry keeps uploaded contract ABIs and decodes contract calls by them
+type Registry struct {
+	db              *Postgres
+	requireVerified bool
+	// cache holds parsed ABIs and nils for contracts without ABIs by contract addresses
+	cache *cache.Cache
+}
+
+// NewRegistry creates the registry, requireVerified allows uploading ABIs of verified contracts only
+func NewRegistry(db *Postgres, requireVerified bool) *Registry {
+	return &Registry{
+		db:              db,
+		requireVerified: requireVerified,
+		cache:           cache.New(cacheTimeout, cacheTimeout*2),
+	}
+}
+
+// Upload validates and saves the ABI, an existing ABI of the contract is never replaced and has to be deleted first
+func (r *Registry) Upload(contractAddress common.Address, data []byte) (usrErr, err error) {
+	if len(data) > MaxAbiSize {
+		return errors.Errorf("too big ABI, max size is %v bytes", MaxAbiSize), nil
+	}
+	abi, err := Parse(data)
+	if err != nil {
+		return errors.Wrap(err, "invalid ABI"), nil
+	}
+	normalized, err := json.Marshal(abi)
+	if err != nil {
+		return nil, err
+	}
+	if usrErr, err = r.db.SaveAbi(contractAddress, normalized, r.requireVerified); usrErr != nil || err != nil {
+		return usrErr, err
+	}
+	r.cache.Set(contractAddress.Hex(), abi, cache.DefaultExpiration)
+	return nil, nil
+}
+
+// Delete removes the ABI of the contract so that a new one can be uploaded
+func (r *Registry) Delete(contractAddress common.Address) (usrErr, err error) {
+	if usrErr, err = r.db.DeleteAbi(contractAddress); usrErr != nil || err != nil {
+		return usrErr, err
+	}
+	r.cache.Delete(contractAddress.Hex())
+	return nil, nil
+}
+
+// Get returns nil if there is no ABI of the contract
+func (r *Registry) Get(contractAddress common.Address) (*ABI, error) {
+	if cached, ok := r.cache.Get(contractAddress.Hex()); ok {
+		return cached.(*ABI), nil
+	}
+	data, err := r.db.Abi(contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	var abi *ABI
+	if data != nil {
+		if abi, err = Parse(data); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse saved ABI of contract %v", contractAddress.Hex())
+		}
+	}
+	r.cache.Set(contractAddress.Hex(), abi, cache.DefaultExpiration)
+	return abi, nil
+}
+
+// Decode returns nil if neither the call nor the events are described by uploaded ABIs
+func (r *Registry) Decode(contractAddress common.Address, call *Call) (*types.DecodedContractCall, error) {
+	res := &types.DecodedContractCall{
+		Method: call.Method,
+	}
+	decoded := false
+	abi, err := r.Get(contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	if abi != nil {
+		res.Args, res.Result = abi.decodeCall(call.Method, call.Args, call.Result)
+		decoded = res.Args != nil
+	}
+	abis := map[common.Address]*ABI{contractAddress: abi}
+	for _, event := range call.Events {
+		eventAbi, ok := abis[event.Contract]
+		if !ok {
+			if eventAbi, err = r.Get(event.Contract); err != nil {
+				return nil, err
+			}
+			abis[event.Contract] = eventAbi
+		}
+		if eventAbi == nil {
+			continue
+		}
+		if decodedEvent := eventAbi.decodeEvent(event); decodedEvent != nil {
+			res.Events = append(res.Events, decodedEvent)
+			decoded = true
+		}
+	}
+	if !decoded {
+		return nil, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package abi
+
+import (
+	"encoding/binary"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/pkg/errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ArgType defines how bytes of contract call args, results and event data are decoded
+type ArgType string
+
+const (
+	// ArgTypeAddress is up to 20 bytes extended with trailing zeros the same way as token event addresses
+	ArgTypeAddress ArgType = "address"
+	// ArgTypeString is utf-8 text without zero bytes
+	ArgTypeString ArgType = "string"
+	// ArgTypeBytes is kept as is
+	ArgTypeBytes ArgType = "bytes"
+	// ArgTypeBool is true if any byte is not zero
+	ArgTypeBool ArgType = "bool"
+	// ArgTypeUint64 is up to 8 bytes of little-endian unsigned integer as encoded by common.ToBytes
+	ArgTypeUint64 ArgType = "uint64"
+	// ArgTypeBigInt is big-endian unsigned integer as encoded by big.Int.Bytes
+	ArgTypeBigInt ArgType = "bigint"
+	// ArgTypeDna is big-endian unsigned integer of the smallest coin units converted to coins
+	ArgTypeDna ArgType = "dna"
+)
+
+var argTypes = map[ArgType]struct{}{
+	ArgTypeAddress: {},
+	ArgTypeString:  {},
+	ArgTypeBytes:   {},
+	ArgTypeBool:    {},
+	ArgTypeUint64:  {},
+	ArgTypeBigInt:  {},
+	ArgTypeDna:     {},
+}
+
+// Valid returns false for types unknown to the decoder
+func (t ArgType) Valid() bool {
+	_, ok := argTypes[t]
+	return ok
+}
+
+// Decode returns nil for missing data, values are strings except for bool, bytes (hexutil.Bytes) and dna
+// (decimal.Decimal)
+func (t ArgType) Decode(data []byte) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	switch t {
+	case ArgTypeAddress:
+		if len(data) > common.AddressLength {
+			return nil, errors.Errorf("too long address, %v bytes", len(data))
+		}
+		var address common.Address
+		copy(address[:], data)
+		return address.Hex(), nil
+	case ArgTypeString:
+		if !utf8.Valid(data) || strings.IndexByte(string(data), 0) >= 0 {
+			return nil, errors.New("invalid string")
+		}
+		return string(data), nil
+	case ArgTypeBytes:
+		return hexutil.Bytes(data), nil
+	case ArgTypeBool:
+		for _, b := range data {
+			if b != 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case ArgTypeUint64:
+		if len(data) > 8 {
+			return nil, errors.Errorf("too long uint64, %v bytes", len(data))
+		}
+		var value [8]byte
+		copy(value[:], data)
+		return strconv.FormatUint(binary.LittleEndian.Uint64(value[:]), 10), nil
+	case ArgTypeBigInt:
+		return new(big.Int).SetBytes(data).String(), nil
+	case ArgTypeDna:
+		return blockchain.ConvertToFloat(new(big.Int).SetBytes(data)), nil
+	default:
+		return nil, errors.Errorf("unknown type %v", t)
+	}
+}
@@ -0,0 +1,47 @@
+package abi
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ArgTypeDecode(t *testing.T) {
+	for _, c := range []struct {
+		argType  ArgType
+		data     []byte
+		expected interface{}
+		invalid  bool
+	}{
+		{argType: ArgTypeAddress, data: []byte{0x1, 0x2}, expected: common.Address{0x1, 0x2}.Hex()},
+		{argType: ArgTypeAddress, data: make([]byte, 21), invalid: true},
+		{argType: ArgTypeString, data: []byte("text"), expected: "text"},
+		{argType: ArgTypeString, data: []byte{'a', 0x0}, invalid: true},
+		{argType: ArgTypeString, data: []byte{0xff}, invalid: true},
+		{argType: ArgTypeBytes, data: []byte{}, expected: hexutil.Bytes{}},
+		{argType: ArgTypeBool, data: []byte{0x0, 0x1}, expected: true},
+		{argType: ArgTypeBool, data: []byte{}, expected: false},
+		{argType: ArgTypeUint64, data: common.ToBytes(uint64(1000)), expected: "1000"},
+		{argType: ArgTypeUint64, data: []byte{0x1, 0x1}, expected: "257"},
+		{argType: ArgTypeUint64, data: make([]byte, 9), invalid: true},
+		{argType: ArgTypeBigInt, data: []byte{0x1, 0x0}, expected: "256"},
+		{argType: ArgTypeBigInt, data: []byte{}, expected: "0"},
+		{argType: ArgTypeDna, data: common.DnaBase.Bytes(), expected: decimal.New(1, 0)},
+		{argType: ArgTypeDna, data: nil, expected: nil},
+	} {
+		value, err := c.argType.Decode(c.data)
+		if c.invalid {
+			require.NotNil(t, err, c.argType)
+			require.Nil(t, value)
+			continue
+		}
+		require.Nil(t, err, c.argType)
+		if d, ok := c.expected.(decimal.Decimal); ok {
+			require.True(t, d.Equal(value.(decimal.Decimal)))
+			continue
+		}
+		require.Equal(t, c.expected, value, c.argType)
+	}
+}
@@ -0,0 +1,18 @@
+package verification
+
+// Backend builds submitted contract sources, builds of different contracts may run concurrently
+type Backend interface {
+	// Build returns an error if the build cannot be run, e.g. the compiler is unavailable, so the verification is
+	// retried later, failures of the source build itself are described by the result
+	Build(source []byte) (*Build, error)
+}
+
+type Build struct {
+	// CodeHash is the sha256 hash of the built wasm code, it is nil if the build failed
+	CodeHash        []byte
+	CompilerVersion string
+	Flags           string
+	Log             string
+	// Error describes why the source failed to be built
+	Error string
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
 	"github.com/pkg/errors"
 	"time"
 )
@@ -18,8 +19,19 @@ const (
 
 type VerifierDb interface {
 	SavePendingVerification(contractAddress common.Address, data []byte, fileName string) (usrErr, err error)
-	GetPendingVerification() (*PendingVerification, error)
-	UpdateVerificationState(contractAddress common.Address, state State, data []byte, errorMessage *string) error
+	GetPendingVerification(contractAddress common.Address) (*PendingVerification, error)
+	// GetUnqueuedPendingVerifications returns addresses of pending verifications which have no queued jobs
+	GetUnqueuedPendingVerifications(jobType string) ([]common.Address, error)
+	// UpdateVerificationState saves the result of the verification, build is nil if the source has not been built
+	UpdateVerificationState(contractAddress common.Address, state State, data []byte, errorMessage *string, build *Build) error
+	// ResetVerificationState makes the submitted verification pending, it returns false if there is no verification
+	ResetVerificationState(contractAddress common.Address) (bool, error)
+	// ResetFailedVerifications makes all failed verifications pending and returns their addresses
+	ResetFailedVerifications() ([]common.Address, error)
+	// GetVerification returns nil if no source has been submitted for the contract
+	GetVerification(contractAddress common.Address) (*types.ContractVerification, error)
+	// GetVerifiedSource returns nil if the contract is not verified
+	GetVerifiedSource(contractAddress common.Address) (data []byte, fileName string, err error)
 }
 
 type PendingVerification struct {
@@ -63,13 +75,13 @@ func (vdb *VerifierPostgres) SavePendingVerification(contractAddress common.Addr
 	return nil, nil
 }
 
-func (vdb *VerifierPostgres) GetPendingVerification() (*PendingVerification, error) {
+func (vdb *VerifierPostgres) GetPendingVerification(contractAddress common.Address) (*PendingVerification, error) {
 	const query = `SELECT a.address, coalesce(c.code, ''::bytea), cv.data
 FROM contract_verifications cv
          LEFT JOIN addresses a ON a.id = cv.contract_address_id
          LEFT JOIN contracts c ON c.contract_address_id = cv.contract_address_id
-         WHERE cv.state=$1 LIMIT 1`
-	rows, err := vdb.db.Query(query, StatePending)
+         WHERE cv.state=$1 AND lower(a.address)=lower($2) LIMIT 1`
+	rows, err := vdb.db.Query(query, StatePending, conversion.ConvertAddress(contractAddress))
 	if err != nil {
 		return nil, err
 	}
@@ -88,15 +100,142 @@ FROM contract_verifications cv
 	}
 }
 
-func (vdb *VerifierPostgres) UpdateVerificationState(contractAddress common.Address, state State, data []byte, errorMessage *string) error {
-	const query = "call update_contract_verification_state($1, $2, $3, $4, $5);"
+func (vdb *VerifierPostgres) GetUnqueuedPendingVerifications(jobType string) ([]common.Address, error) {
+	const query = `SELECT a.address
+FROM contract_verifications cv
+         JOIN addresses a ON a.id = cv.contract_address_id
+WHERE cv.state = $1
+  AND NOT exists(SELECT 1
+                 FROM jobs j
+                 WHERE j."type" = $2
+                   AND lower(j.payload ->> 'address') = lower(a.address))`
+	rows, err := vdb.db.Query(query, StatePending, jobType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []common.Address
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		res = append(res, common.HexToAddress(address))
+	}
+	return res, rows.Err()
+}
+
+func (vdb *VerifierPostgres) UpdateVerificationState(contractAddress common.Address, state State, data []byte, errorMessage *string, build *Build) error {
+	const query = "call update_contract_verification_state($1, $2, $3, $4, $5, $6, $7, $8);"
 	timestamp := time.Now().UTC().Unix()
+	var compilerVersion, buildFlags, buildLog *string
+	if build != nil {
+		compilerVersion, buildFlags, buildLog = nullableString(build.CompilerVersion), nullableString(build.Flags),
+			nullableString(build.Log)
+	}
 	_, err := vdb.db.Exec(query,
 		conversion.ConvertAddress(contractAddress),
 		state,
 		timestamp,
 		data,
 		errorMessage,
+		compilerVersion,
+		buildFlags,
+		buildLog,
 	)
 	return err
 }
+
+func nullableString(v string) *string {
+	if len(v) == 0 {
+		return nil
+	}
+	return &v
+}
+
+func (vdb *VerifierPostgres) ResetVerificationState(contractAddress common.Address) (bool, error) {
+	const query = `UPDATE contract_verifications
+SET state            = $2,
+    state_timestamp  = $3,
+    error_message    = null,
+    compiler_version = null,
+    build_flags      = null,
+    build_log        = null
+WHERE contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))
+  AND "data" IS NOT NULL`
+	res, err := vdb.db.Exec(query, conversion.ConvertAddress(contractAddress), StatePending, time.Now().UTC().Unix())
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (vdb *VerifierPostgres) ResetFailedVerifications() ([]common.Address, error) {
+	const query = `UPDATE contract_verifications cv
+SET state            = $2,
+    state_timestamp  = $3,
+    error_message    = null,
+    compiler_version = null,
+    build_flags      = null,
+    build_log        = null
+FROM addresses a
+WHERE a.id = cv.contract_address_id
+  AND cv.state = $1
+  AND cv."data" IS NOT NULL
+RETURNING a.address`
+	rows, err := vdb.db.Query(query, StateFailed, StatePending, time.Now().UTC().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []common.Address
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		res = append(res, common.HexToAddress(address))
+	}
+	return res, rows.Err()
+}
+
+func (vdb *VerifierPostgres) GetVerification(contractAddress common.Address) (*types.ContractVerification, error) {
+	const query = `SELECT s.name,
+       cv.state_timestamp,
+       coalesce(cv.file_name, ''),
+       coalesce(cv.error_message, ''),
+       coalesce(cv.compiler_version, ''),
+       coalesce(cv.build_flags, ''),
+       coalesce(cv.build_log, '')
+FROM contract_verifications cv
+         JOIN dic_contract_verification_states s ON s.id = cv.state
+WHERE cv.contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))`
+	res := &types.ContractVerification{}
+	var stateTimestamp int64
+	err := vdb.db.QueryRow(query, conversion.ConvertAddress(contractAddress)).Scan(&res.State, &stateTimestamp,
+		&res.FileName, &res.ErrorMessage, &res.CompilerVersion, &res.BuildFlags, &res.BuildLog)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.StateTimestamp = time.Unix(stateTimestamp, 0).UTC()
+	return res, nil
+}
+
+func (vdb *VerifierPostgres) GetVerifiedSource(contractAddress common.Address) (data []byte, fileName string, err error) {
+	const query = `SELECT cv."data", coalesce(cv.file_name, '')
+FROM contract_verifications cv
+WHERE cv.contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))
+  AND cv.state = $2`
+	err = vdb.db.QueryRow(query, conversion.ConvertAddress(contractAddress), StateVerified).Scan(&data, &fileName)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	return data, fileName, err
+}
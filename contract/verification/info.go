@@ -2,28 +2,27 @@ package verification
 
 import (
 	"bytes"
-	"fmt"
 	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/pkg/errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
 )
 
-type WasmInfo interface {
-	Hash(data []byte) ([]byte, error)
-}
-
+// WasmInfoImpl is the backend sending sources to the remote service which builds them and returns the code hash
 type WasmInfoImpl struct {
 	url string
 }
 
-func NewWasmInfo(url string) WasmInfo {
+func NewWasmInfo(url string) Backend {
 	return &WasmInfoImpl{url: url}
 }
 
-func (wi *WasmInfoImpl) Hash(data []byte) ([]byte, error) {
-	httpReq, err := http.NewRequest("POST", wi.url, bytes.NewBuffer(data))
+// Build records the build failure only if the service rejects the source with 400 or 422 status, unavailability of
+// the service, other statuses and unexpected responses are returned as errors so the verification is retried later
+func (wi *WasmInfoImpl) Build(source []byte) (*Build, error) {
+	httpReq, err := http.NewRequest("POST", wi.url, bytes.NewBuffer(source))
 	if err != nil {
 		return nil, err
 	}
@@ -33,15 +32,20 @@ func (wi *WasmInfoImpl) Hash(data []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, defaultMaxLogSize))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read response, status code: %v", resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
+		return &Build{Error: "failed to compile", Log: string(respBody)}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("unable to send request, status code: %v", resp.StatusCode))
+		return nil, errors.Errorf("unable to send request, status code: %v", resp.StatusCode)
 	}
-	var respBody []byte
-	respBody, err = ioutil.ReadAll(resp.Body)
-	_ = resp.Body.Close()
+	hash, err := hexutil.Decode("0x" + strings.TrimSpace(string(respBody)))
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "invalid code hash in response")
 	}
-	hashHex := strings.TrimSpace("0x" + string(respBody))
-	return hexutil.Decode(hashHex)
+	return &Build{CodeHash: hash}, nil
 }
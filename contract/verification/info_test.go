@@ -0,0 +1,49 @@
+package verification
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WasmInfoImpl_Build(t *testing.T) {
+	var status int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	backend := NewWasmInfo(server.URL)
+
+	status, body = http.StatusOK, "0102\n"
+	build, err := backend.Build([]byte("source"))
+	require.Nil(t, err)
+	require.Empty(t, build.Error)
+	require.Equal(t, []byte{0x1, 0x2}, build.CodeHash)
+
+	// Rejected source
+	status, body = http.StatusBadRequest, "error: expected `;`"
+	build, err = backend.Build([]byte("source"))
+	require.Nil(t, err)
+	require.Nil(t, build.CodeHash)
+	require.Equal(t, "failed to compile", build.Error)
+	require.Equal(t, "error: expected `;`", build.Log)
+
+	// Service failures
+	status, body = http.StatusInternalServerError, "internal error"
+	_, err = backend.Build([]byte("source"))
+	require.NotNil(t, err)
+
+	status, body = http.StatusTooManyRequests, ""
+	_, err = backend.Build([]byte("source"))
+	require.NotNil(t, err)
+
+	status, body = http.StatusOK, "not hash"
+	_, err = backend.Build([]byte("source"))
+	require.NotNil(t, err)
+
+	_, err = NewWasmInfo("http://127.0.0.1:0").Build([]byte("source"))
+	require.NotNil(t, err)
+}
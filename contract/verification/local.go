@@ -0,0 +1,197 @@
+package verification
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBuildTimeout  = 5 * time.Minute
+	defaultMaxSourceSize = 50 << 20
+	defaultMaxLogSize    = 64 << 10
+	maxSourceFiles       = 10000
+	maxCodeSize          = 10 << 20
+	versionTimeout       = 10 * time.Second
+)
+
+type LocalConfig struct {
+	// Command is the compiler executable with args, it is run in the dir the source archive is extracted to
+	Command []string
+	// Output is the path of the built wasm file relative to the source dir
+	Output string
+	// VersionCommand prints the compiler version recorded with the build, the version is empty if it is not set
+	VersionCommand []string
+	Timeout        time.Duration
+	// TempDir holds dirs of running builds, the system temp dir is used if it is empty
+	TempDir string
+	// MaxSourceSize limits the total uncompressed size of the source files
+	MaxSourceSize int64
+	// MaxLogSize limits the recorded compiler output, the tail of the output is kept
+	MaxLogSize int
+}
+
+type localBackend struct {
+	conf LocalConfig
+}
+
+// NewLocalBackend creates the backend running the compiler command for each build in its own temp dir which is removed
+// once the build is completed, the command gets the minimal environment and is killed with its children on timeout
+func NewLocalBackend(conf LocalConfig) (Backend, error) {
+	if len(conf.Command) == 0 {
+		return nil, errors.New("compiler command is not set")
+	}
+	if len(conf.Output) == 0 || filepath.IsAbs(conf.Output) || !isLocalPath(conf.Output) {
+		return nil, errors.Errorf("output %q must be a path inside the source dir", conf.Output)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultBuildTimeout
+	}
+	if conf.MaxSourceSize <= 0 {
+		conf.MaxSourceSize = defaultMaxSourceSize
+	}
+	if conf.MaxLogSize <= 0 {
+		conf.MaxLogSize = defaultMaxLogSize
+	}
+	return &localBackend{conf: conf}, nil
+}
+
+func (b *localBackend) Build(source []byte) (*Build, error) {
+	dir, err := os.MkdirTemp(b.conf.TempDir, "contract-build-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create build dir")
+	}
+	defer os.RemoveAll(dir)
+	srcDir := filepath.Join(dir, "src")
+
+	res := &Build{
+		Flags: strings.Join(b.conf.Command[1:], " "),
+	}
+	if res.CompilerVersion, err = b.version(dir); err != nil {
+		return nil, errors.Wrap(err, "failed to get compiler version")
+	}
+	if err := extractSource(source, srcDir, b.conf.MaxSourceSize); err != nil {
+		res.Error = fmt.Sprintf("invalid source archive: %v", err)
+		return res, nil
+	}
+
+	output := newTailBuffer(b.conf.MaxLogSize)
+	timedOut, runErr := b.run(dir, srcDir, b.conf.Command, output, b.conf.Timeout)
+	res.Log = output.String()
+	if timedOut {
+		res.Error = fmt.Sprintf("build timed out after %v", b.conf.Timeout)
+		return res, nil
+	}
+	if runErr != nil {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			return nil, errors.Wrap(runErr, "failed to run compiler")
+		}
+		res.Error = fmt.Sprintf("failed to compile: %v", runErr)
+		return res, nil
+	}
+
+	code, err := readFile(filepath.Join(srcDir, b.conf.Output), maxCodeSize)
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to read built code %v: %v", b.conf.Output, err)
+		return res, nil
+	}
+	hash := sha256.Sum256(code)
+	res.CodeHash = hash[:]
+	return res, nil
+}
+
+func (b *localBackend) version(dir string) (string, error) {
+	if len(b.conf.VersionCommand) == 0 {
+		return "", nil
+	}
+	output := newTailBuffer(1 << 10)
+	timedOut, err := b.run(dir, dir, b.conf.VersionCommand, output, versionTimeout)
+	if timedOut {
+		return "", errors.New("timed out")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output.String()), nil
+}
+
+// run starts the command in its own process group with HOME and TMPDIR pointing to the build dir, all processes of
+// the group are killed once the command exits or times out
+func (b *localBackend) run(dir, workDir string, command []string, output io.Writer, timeout time.Duration) (
+	timedOut bool, err error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+		killProcessGroup(cmd)
+	case <-timer.C:
+		timedOut = true
+		killProcessGroup(cmd)
+		err = <-done
+	}
+	return timedOut, err
+}
+
+func readFile(path string, maxSize int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errors.Errorf("file exceeds %v bytes", maxSize)
+	}
+	return data, nil
+}
+
+// tailBuffer keeps the last max bytes written, it is not synchronized as exec calls Write from one goroutine at a time
+// if stdout and stderr are the same writer
+type tailBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) > t.max {
+		p = p[len(p)-t.max:]
+	}
+	t.buf.Write(p)
+	if extra := t.buf.Len() - t.max; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return n, nil
+}
+
+// String returns valid utf8 without zero bytes to be saved to postgres text
+func (t *tailBuffer) String() string {
+	return strings.ReplaceAll(strings.ToValidUTF8(t.buf.String(), "�"), "\x00", "")
+}
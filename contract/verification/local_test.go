@@ -0,0 +1,161 @@
+//go:build !windows
+// +build !windows
+
+package verification
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type archiveFile struct {
+	name string
+	mode os.FileMode
+	data string
+}
+
+func archive(t *testing.T, files ...archiveFile) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+		mode := file.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		header.SetMode(mode)
+		w, err := writer.CreateHeader(header)
+		require.Nil(t, err)
+		_, err = w.Write([]byte(file.data))
+		require.Nil(t, err)
+	}
+	require.Nil(t, writer.Close())
+	return buf.Bytes()
+}
+
+func newTestBackend(t *testing.T, command string, timeout time.Duration) Backend {
+	backend, err := NewLocalBackend(LocalConfig{
+		Command:        []string{"sh", "-c", command},
+		Output:         "out/contract.wasm",
+		VersionCommand: []string{"echo", "fake-compiler 1.0"},
+		Timeout:        timeout,
+		TempDir:        t.TempDir(),
+		MaxLogSize:     16,
+	})
+	require.Nil(t, err)
+	return backend
+}
+
+func Test_NewLocalBackend(t *testing.T) {
+	_, err := NewLocalBackend(LocalConfig{Output: "contract.wasm"})
+	require.NotNil(t, err)
+	for _, output := range []string{"", "/contract.wasm", "../contract.wasm", "out/../../contract.wasm", "."} {
+		_, err = NewLocalBackend(LocalConfig{Command: []string{"cc"}, Output: output})
+		require.NotNil(t, err, output)
+	}
+	_, err = NewLocalBackend(LocalConfig{Command: []string{"cc"}, Output: "out/../contract.wasm"})
+	require.Nil(t, err)
+}
+
+func Test_localBackend_Build(t *testing.T) {
+	source := archive(t,
+		archiveFile{name: "src/"},
+		archiveFile{name: "src/contract.wasm", data: "code"},
+	)
+	expectedHash := sha256.Sum256([]byte("code"))
+
+	build, err := newTestBackend(t, "mkdir out && cp src/contract.wasm out/ && echo done", time.Minute).Build(source)
+	require.Nil(t, err)
+	require.Empty(t, build.Error)
+	require.Equal(t, expectedHash[:], build.CodeHash)
+	require.Equal(t, "fake-compiler 1.0", build.CompilerVersion)
+	require.Equal(t, "-c mkdir out && cp src/contract.wasm out/ && echo done", build.Flags)
+	require.Equal(t, "done\n", build.Log)
+
+	// Failed build with the output tail kept
+	build, err = newTestBackend(t, "echo 0123456789abcdefghij >&2; exit 1", time.Minute).Build(source)
+	require.Nil(t, err)
+	require.Nil(t, build.CodeHash)
+	require.True(t, strings.HasPrefix(build.Error, "failed to compile"))
+	require.Equal(t, "6789abcdefghij\n", build.Log[len(build.Log)-15:])
+	require.Len(t, build.Log, 16)
+
+	// No output
+	build, err = newTestBackend(t, "true", time.Minute).Build(source)
+	require.Nil(t, err)
+	require.Nil(t, build.CodeHash)
+	require.True(t, strings.HasPrefix(build.Error, "failed to read built code"))
+
+	// Timeout
+	build, err = newTestBackend(t, "sleep 10", 100*time.Millisecond).Build(source)
+	require.Nil(t, err)
+	require.Nil(t, build.CodeHash)
+	require.True(t, strings.HasPrefix(build.Error, "build timed out"))
+
+	// Unavailable compiler
+	backend, err := NewLocalBackend(LocalConfig{Command: []string{"not-existing-compiler"}, Output: "contract.wasm",
+		TempDir: t.TempDir()})
+	require.Nil(t, err)
+	_, err = backend.Build(source)
+	require.NotNil(t, err)
+}
+
+func Test_localBackend_BuildInvalidSource(t *testing.T) {
+	backend := newTestBackend(t, "mkdir out && cp contract.wasm out/", time.Minute)
+	for _, source := range [][]byte{
+		[]byte("not zip"),
+		archive(t, archiveFile{name: "../contract.wasm", data: "code"}),
+		archive(t, archiveFile{name: "/contract.wasm", data: "code"}),
+		archive(t, archiveFile{name: "contract.wasm", mode: os.ModeSymlink | 0777, data: "/etc/passwd"}),
+		archive(t, archiveFile{name: "contract.wasm", data: "code"}, archiveFile{name: "contract.wasm", data: "code"}),
+	} {
+		build, err := backend.Build(source)
+		require.Nil(t, err)
+		require.Nil(t, build.CodeHash)
+		require.True(t, strings.HasPrefix(build.Error, "invalid source archive"), build.Error)
+	}
+
+	backend, err := NewLocalBackend(LocalConfig{Command: []string{"true"}, Output: "contract.wasm",
+		TempDir: t.TempDir(), MaxSourceSize: 10})
+	require.Nil(t, err)
+	build, err := backend.Build(archive(t,
+		archiveFile{name: "a", data: "012345"},
+		archiveFile{name: "b", data: "012345"},
+	))
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(build.Error, "invalid source archive"), build.Error)
+}
+
+func Test_SourceFiles(t *testing.T) {
+	source := archive(t,
+		archiveFile{name: "src/"},
+		archiveFile{name: "src/lib.rs", data: "fn main() {}"},
+		archiveFile{name: "Cargo.toml", data: "[package]"},
+	)
+	files, err := SourceFiles(source)
+	require.Nil(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "src/lib.rs", files[0].Name)
+	require.Equal(t, uint64(12), files[0].Size)
+	require.Equal(t, "Cargo.toml", files[1].Name)
+
+	data, err := SourceFile(source, "src/lib.rs", 100)
+	require.Nil(t, err)
+	require.Equal(t, []byte("fn main() {}"), data)
+
+	data, err = SourceFile(source, "src/", 100)
+	require.Nil(t, err)
+	require.Nil(t, data)
+
+	_, err = SourceFile(source, "src/lib.rs", 5)
+	require.NotNil(t, err)
+
+	_, err = SourceFiles([]byte("not zip"))
+	require.NotNil(t, err)
+}
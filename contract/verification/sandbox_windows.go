@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package verification
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// killProcessGroup kills the command process only, its children are not tracked on windows
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,137 @@
+package verification
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// isLocalPath reports whether the slash separated relative path stays inside the dir it is relative to
+func isLocalPath(name string) bool {
+	name = filepath.ToSlash(name)
+	if strings.HasPrefix(name, "/") {
+		return false
+	}
+	cleaned := path.Clean(name)
+	return cleaned != "." && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+// extractSource writes regular files and dirs of the zip archive to the dir, symlinks and paths leading outside the
+// dir are rejected, the total size is checked against the actual decompressed data, not the declared one
+func extractSource(source []byte, dir string, maxSize int64) error {
+	reader, err := zip.NewReader(bytes.NewReader(source), int64(len(source)))
+	if err != nil {
+		return err
+	}
+	if len(reader.File) > maxSourceFiles {
+		return errors.Errorf("too many files, max is %v", maxSourceFiles)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	remaining := maxSize
+	for _, file := range reader.File {
+		if !isLocalPath(file.Name) {
+			return errors.Errorf("invalid file path %v", file.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean(file.Name)))
+		mode := file.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			return errors.Errorf("unsupported file %v", file.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		written, err := extractFile(file, target, mode.Perm()&0100 != 0, remaining)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract %v", file.Name)
+		}
+		remaining -= written
+	}
+	return nil
+}
+
+func extractFile(file *zip.File, target string, executable bool, maxSize int64) (int64, error) {
+	perm := os.FileMode(0600)
+	if executable {
+		perm = 0700
+	}
+	src, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(dst, io.LimitReader(src, maxSize+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if written > maxSize {
+		return 0, errors.New("source size limit exceeded")
+	}
+	return written, nil
+}
+
+// SourceFiles lists regular files of the source archive
+func SourceFiles(source []byte) ([]*types.ContractSourceFile, error) {
+	reader, err := zip.NewReader(bytes.NewReader(source), int64(len(source)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read source archive")
+	}
+	res := make([]*types.ContractSourceFile, 0, len(reader.File))
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		res = append(res, &types.ContractSourceFile{
+			Name: file.Name,
+			Size: file.UncompressedSize64,
+		})
+	}
+	return res, nil
+}
+
+// SourceFile returns nil if there is no regular file with the name in the source archive
+func SourceFile(source []byte, name string, maxSize int64) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(source), int64(len(source)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read source archive")
+	}
+	for _, file := range reader.File {
+		if file.Name != name || !file.Mode().IsRegular() {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxSize {
+			return nil, errors.Errorf("file exceeds %v bytes", maxSize)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
@@ -3,49 +3,159 @@ package verification
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/hexutil"
+	"github.com/idena-network/idena-indexer/core/jobs"
+	"github.com/idena-network/idena-indexer/core/types"
 	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/monitoring"
 	"github.com/pkg/errors"
 	"time"
 )
 
 type Verifier interface {
 	Submit(contractAddress common.Address, code []byte, fileName string) (usrErr, err error)
+	// Reverify queues the submitted verification to run again
+	Reverify(contractAddress common.Address) (usrErr, err error)
+	// ReverifyFailed queues all failed verifications to run again, e.g. once the compiler is fixed, and returns their
+	// number
+	ReverifyFailed() (int, error)
+	// Verification returns nil if no source has been submitted for the contract
+	Verification(contractAddress common.Address) (*types.ContractVerification, error)
+	// SourceFiles lists files of the verified source
+	SourceFiles(contractAddress common.Address) (files []*types.ContractSourceFile, usrErr, err error)
+	// SourceFile returns the file of the verified source
+	SourceFile(contractAddress common.Address, name string) (data []byte, usrErr, err error)
+	// Source returns the verified source archive
+	Source(contractAddress common.Address) (data []byte, fileName string, usrErr, err error)
 }
 
+const (
+	JobTypeContractVerification = "contract-verification"
+
+	maxSourceFileSize = 10 << 20
+)
+
 type verifierImpl struct {
-	db     VerifierDb
-	info   WasmInfo
-	logger log.Logger
+	runner  *jobs.Runner
+	db      VerifierDb
+	backend Backend
+	logger  log.Logger
 }
 
-func NewVerifier(db VerifierDb, info WasmInfo, logger log.Logger) Verifier {
+type verificationJob struct {
+	Address common.Address `json:"address"`
+}
+
+// NewVerifier registers the contract verification job type and queues jobs for pending verifications submitted
+// before the job was introduced, verifications run concurrently up to the job type concurrency
+func NewVerifier(runner *jobs.Runner, conf jobs.TypeConfig, db VerifierDb, backend Backend, logger log.Logger) Verifier {
 	res := &verifierImpl{
-		db:     db,
-		info:   info,
-		logger: logger,
+		runner:  runner,
+		db:      db,
+		backend: backend,
+		logger:  logger,
+	}
+	runner.Register(JobTypeContractVerification, conf, res.processJob)
+	addresses, err := db.GetUnqueuedPendingVerifications(JobTypeContractVerification)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to get pending verifications: %v", err))
+	}
+	for _, address := range addresses {
+		res.enqueue(address)
 	}
-	go res.loop()
 	return res
 }
 
 func (v *verifierImpl) Submit(contractAddress common.Address, code []byte, fileName string) (usrErr, err error) {
-	return v.db.SavePendingVerification(contractAddress, code, fileName)
+	if usrErr, err = v.db.SavePendingVerification(contractAddress, code, fileName); usrErr != nil || err != nil {
+		return usrErr, err
+	}
+	v.enqueue(contractAddress)
+	return nil, nil
+}
+
+func (v *verifierImpl) Reverify(contractAddress common.Address) (usrErr, err error) {
+	found, err := v.db.ResetVerificationState(contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reset verification state")
+	}
+	if !found {
+		return errors.Errorf("contract %v verification not found", contractAddress.Hex()), nil
+	}
+	if err := v.runner.Enqueue(JobTypeContractVerification, &verificationJob{Address: contractAddress}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (v *verifierImpl) ReverifyFailed() (int, error) {
+	addresses, err := v.db.ResetFailedVerifications()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to reset failed verifications")
+	}
+	for _, address := range addresses {
+		v.enqueue(address)
+	}
+	return len(addresses), nil
+}
+
+func (v *verifierImpl) Verification(contractAddress common.Address) (*types.ContractVerification, error) {
+	return v.db.GetVerification(contractAddress)
+}
+
+func (v *verifierImpl) SourceFiles(contractAddress common.Address) (files []*types.ContractSourceFile, usrErr, err error) {
+	source, _, usrErr, err := v.Source(contractAddress)
+	if usrErr != nil || err != nil {
+		return nil, usrErr, err
+	}
+	files, err = SourceFiles(source)
+	return files, nil, err
 }
 
-func (v *verifierImpl) loop() {
-	for {
-		time.Sleep(time.Second * 5)
-		if err := v.verifyPendingContract(); err != nil {
-			v.logger.Warn(fmt.Sprintf("failed to verify contract: %v", err))
-		}
+func (v *verifierImpl) SourceFile(contractAddress common.Address, name string) (data []byte, usrErr, err error) {
+	source, _, usrErr, err := v.Source(contractAddress)
+	if usrErr != nil || err != nil {
+		return nil, usrErr, err
+	}
+	if data, err = SourceFile(source, name, maxSourceFileSize); err != nil {
+		return nil, nil, err
 	}
+	if data == nil {
+		return nil, errors.Errorf("file %v not found", name), nil
+	}
+	return data, nil, nil
 }
 
-func (v *verifierImpl) verifyPendingContract() error {
-	verification, err := v.db.GetPendingVerification()
+func (v *verifierImpl) Source(contractAddress common.Address) (data []byte, fileName string, usrErr, err error) {
+	data, fileName, err = v.db.GetVerifiedSource(contractAddress)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if data == nil {
+		return nil, "", errors.Errorf("contract %v is not verified", contractAddress.Hex()), nil
+	}
+	return data, fileName, nil, nil
+}
+
+func (v *verifierImpl) enqueue(contractAddress common.Address) {
+	if err := v.runner.Enqueue(JobTypeContractVerification, &verificationJob{Address: contractAddress}); err != nil {
+		v.logger.Error(fmt.Sprintf("failed to queue contract %v verification: %v", contractAddress.Hex(), err))
+	}
+}
+
+func (v *verifierImpl) processJob(payload json.RawMessage) error {
+	var job verificationJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return errors.Wrap(err, "failed to unmarshal verification job")
+	}
+	return v.verifyPendingContract(job.Address)
+}
+
+func (v *verifierImpl) verifyPendingContract(contractAddress common.Address) error {
+	verification, err := v.db.GetPendingVerification(contractAddress)
 	if err != nil {
 		return errors.Wrap(err, "failed to get pending verification")
 	}
@@ -57,17 +167,19 @@ func (v *verifierImpl) verifyPendingContract() error {
 	start := time.Now()
 	v.logger.Info(fmt.Sprintf("start verifying contract %v, contract src len: %v", verification.Address.Hex(), len(verification.Data)))
 
-	codeHash, err := v.getCodeHash(verification.Code)
+	codeHash := sha256.Sum256(verification.Code)
+
+	build, err := v.backend.Build(verification.Data)
 	if err != nil {
-		return errors.Wrap(err, "failed to get code hash")
+		return errors.Wrap(err, "failed to build contract")
 	}
 
-	dataHash, verificationErr := v.getDataInfo(verification.Data)
-	if verificationErr != nil {
-		v.logger.Warn(fmt.Sprintf("failed to verify contract: %v", err))
-		verificationErr = errors.New("failed to compile")
-	} else if bytes.Compare(codeHash, dataHash) != 0 {
-		v.logger.Warn(fmt.Sprintf("different hashes, actual: %v, provided: %v", hexutil.Encode(codeHash), hexutil.Encode(dataHash)))
+	var verificationErr error
+	if len(build.Error) > 0 {
+		v.logger.Warn(fmt.Sprintf("failed to build contract %v: %v", verification.Address.Hex(), build.Error))
+		verificationErr = errors.New(build.Error)
+	} else if !bytes.Equal(codeHash[:], build.CodeHash) {
+		v.logger.Warn(fmt.Sprintf("different hashes, actual: %v, provided: %v", hexutil.Encode(codeHash[:]), hexutil.Encode(build.CodeHash)))
 		verificationErr = errors.New("wrong compiled contract hash")
 	}
 
@@ -83,20 +195,16 @@ func (v *verifierImpl) verifyPendingContract() error {
 		errorMessage = &v
 	}
 
-	if err := v.db.UpdateVerificationState(verification.Address, state, verification.Data, errorMessage); err != nil {
+	if err := v.db.UpdateVerificationState(verification.Address, state, verification.Data, errorMessage, build); err != nil {
 		return errors.Wrap(err, "failed to update verification state")
 	}
+	if verified {
+		monitoring.ContractVerifications.WithLabelValues("verified").Inc()
+	} else {
+		monitoring.ContractVerifications.WithLabelValues("failed").Inc()
+	}
 
 	v.logger.Info(fmt.Sprintf("contract %v verification result: %v, err: %v", verification.Address.Hex(), verified, verificationErr), "d", time.Since(start))
 
 	return nil
 }
-
-func (v *verifierImpl) getDataInfo(data []byte) ([]byte, error) {
-	return v.info.Hash(data)
-}
-
-func (v *verifierImpl) getCodeHash(code []byte) ([]byte, error) {
-	hash := sha256.Sum256(code)
-	return hash[:], nil
-}
@@ -0,0 +1,63 @@
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/jobs"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+)
+
+const JobTypeIntrospection = "wasm-introspection"
+
+type introspectionJob struct {
+	Address common.Address `json:"address"`
+}
+
+type backfiller struct {
+	db     *Postgres
+	logger log.Logger
+}
+
+// RegisterBackfill registers the introspection job type and queues jobs for wasm contracts deployed before the code
+// introspection was introduced, contracts deployed later are introspected by the indexer
+func RegisterBackfill(runner *jobs.Runner, conf jobs.TypeConfig, db *Postgres, logger log.Logger) {
+	b := &backfiller{
+		db:     db,
+		logger: logger,
+	}
+	runner.Register(JobTypeIntrospection, conf, b.processJob)
+	addresses, err := db.GetUnqueuedContracts(JobTypeIntrospection)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to get contracts to introspect: %v", err))
+		return
+	}
+	if len(addresses) > 0 {
+		logger.Info(fmt.Sprintf("queueing introspection of %v contracts", len(addresses)))
+	}
+	for _, address := range addresses {
+		if err := runner.Enqueue(JobTypeIntrospection, &introspectionJob{Address: address}); err != nil {
+			logger.Error(fmt.Sprintf("failed to queue contract %v introspection: %v", address.Hex(), err))
+		}
+	}
+}
+
+func (b *backfiller) processJob(payload json.RawMessage) error {
+	var job introspectionJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return errors.Wrap(err, "failed to unmarshal introspection job")
+	}
+	code, err := b.db.Code(job.Address)
+	if err != nil {
+		return errors.Wrap(err, "failed to get contract code")
+	}
+	if code == nil {
+		return nil
+	}
+	info := Introspect(code)
+	if len(info.Error) > 0 {
+		b.logger.Warn(fmt.Sprintf("failed to parse contract %v code: %v", job.Address.Hex(), info.Error))
+	}
+	return errors.Wrap(b.db.SaveInfo(job.Address, info), "failed to save contract code info")
+}
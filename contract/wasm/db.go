@@ -0,0 +1,99 @@
+package wasm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"time"
+)
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+// GetUnqueuedContracts returns addresses of wasm contracts deployed before the code introspection was introduced
+// which have no queued jobs
+func (p *Postgres) GetUnqueuedContracts(jobType string) ([]common.Address, error) {
+	const query = `SELECT a.address
+FROM contracts c
+         JOIN addresses a ON a.id = c.contract_address_id
+WHERE c.code IS NOT NULL
+  AND NOT exists(SELECT 1 FROM contract_codes cc WHERE cc.contract_address_id = c.contract_address_id)
+  AND NOT exists(SELECT 1
+                 FROM jobs j
+                 WHERE j."type" = $1
+                   AND lower(j.payload ->> 'address') = lower(a.address))`
+	rows, err := p.db.Query(query, jobType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []common.Address
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		res = append(res, common.HexToAddress(address))
+	}
+	return res, rows.Err()
+}
+
+// Code returns nil if there is no wasm contract with the address
+func (p *Postgres) Code(contractAddress common.Address) ([]byte, error) {
+	const query = `SELECT c.code
+FROM contracts c
+         JOIN addresses a ON a.id = c.contract_address_id
+WHERE lower(a.address) = lower($1)
+  AND c.code IS NOT NULL`
+	var res []byte
+	err := p.db.QueryRow(query, conversion.ConvertAddress(contractAddress)).Scan(&res)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return res, err
+}
+
+// SaveInfo keeps the info saved by the indexer if there is one, nothing is saved if the contract has been reset
+func (p *Postgres) SaveInfo(contractAddress common.Address, info *Info) error {
+	const query = `INSERT INTO contract_codes (contract_address_id, code_hash, size, module, parse_error)
+SELECT c.contract_address_id, $2::text, $3::integer, $4::jsonb, limited_text($5::text, 200)
+FROM contracts c
+         JOIN addresses a ON a.id = c.contract_address_id
+WHERE lower(a.address) = lower($1)
+  AND c.code IS NOT NULL
+ON CONFLICT DO NOTHING`
+	var module, parseError interface{}
+	if info.Module != nil {
+		data, err := json.Marshal(info.Module)
+		if err != nil {
+			return err
+		}
+		module = string(data)
+	}
+	if len(info.Error) > 0 {
+		parseError = info.Error
+	}
+	_, err := p.db.Exec(query,
+		conversion.ConvertAddress(contractAddress),
+		conversion.ConvertHash(info.CodeHash),
+		info.Size,
+		module,
+		parseError,
+	)
+	return err
+}
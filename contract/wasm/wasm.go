@@ -0,0 +1,289 @@
+package wasm
+
+import (
+	"bytes"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"unicode/utf8"
+)
+
+const (
+	sectionCustom = 0
+	sectionImport = 2
+	sectionMemory = 5
+	sectionExport = 7
+	sectionCode   = 10
+
+	externalFunc   = 0
+	externalTable  = 1
+	externalMemory = 2
+	externalGlobal = 3
+	externalTag    = 4
+)
+
+var header = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// Info is the introspection result of the deployed code
+type Info struct {
+	CodeHash common.Hash
+	Size     int
+	// Module is nil if the code cannot be parsed, Error holds the reason then
+	Module *types.WasmModule
+	Error  string
+}
+
+// Introspect never fails, the code hash is calculated the same way as the node does it for deployed contracts
+func Introspect(code []byte) *Info {
+	res := &Info{
+		CodeHash: crypto.Hash(code),
+		Size:     len(code),
+	}
+	module, err := Parse(code)
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Module = module
+	}
+	return res
+}
+
+// Parse reads sections of the wasm binary describing the module interface, function bodies are not validated
+func Parse(code []byte) (*types.WasmModule, error) {
+	if !bytes.HasPrefix(code, header) {
+		return nil, errors.New("not a wasm binary of version 1")
+	}
+	res := &types.WasmModule{
+		Exports: []string{},
+		Imports: []*types.WasmImport{},
+	}
+	r := &reader{data: code, pos: len(header)}
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size of section %v", id)
+		}
+		data, err := r.bytes(size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid section %v", id)
+		}
+		section := &reader{data: data}
+		switch id {
+		case sectionCustom:
+			err = readCustomSection(section, res)
+		case sectionImport:
+			err = readImportSection(section, res)
+		case sectionMemory:
+			err = readMemorySection(section, res)
+		case sectionExport:
+			err = readExportSection(section, res)
+		case sectionCode:
+			res.CodeSize = size
+			res.Functions, err = section.u32()
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid section %v", id)
+		}
+	}
+	return res, nil
+}
+
+func readCustomSection(r *reader, module *types.WasmModule) error {
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	module.CustomSections = append(module.CustomSections, &types.WasmCustomSection{
+		Name: name,
+		Size: uint32(len(r.data) - r.pos),
+	})
+	return nil
+}
+
+func readImportSection(r *reader, module *types.WasmModule) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		moduleName, err := r.name()
+		if err != nil {
+			return err
+		}
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case externalFunc:
+			if _, err := r.u32(); err != nil {
+				return err
+			}
+			module.Imports = append(module.Imports, &types.WasmImport{Module: moduleName, Name: name})
+		case externalTable:
+			if _, err := r.byte(); err != nil {
+				return err
+			}
+			if _, err := r.limits(); err != nil {
+				return err
+			}
+		case externalMemory:
+			memory, err := r.limits()
+			if err != nil {
+				return err
+			}
+			memory.Imported = true
+			module.Memory = memory
+		case externalGlobal:
+			if _, err := r.bytes(2); err != nil {
+				return err
+			}
+		case externalTag:
+			if _, err := r.byte(); err != nil {
+				return err
+			}
+			if _, err := r.u32(); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("unknown import kind %v of %v.%v", kind, moduleName, name)
+		}
+	}
+	return nil
+}
+
+func readMemorySection(r *reader, module *types.WasmModule) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	// multiple memories are not supported by the node, the first one is reported
+	for i := uint32(0); i < count; i++ {
+		memory, err := r.limits()
+		if err != nil {
+			return err
+		}
+		if module.Memory == nil {
+			module.Memory = memory
+		}
+	}
+	return nil
+}
+
+func readExportSection(r *reader, module *types.WasmModule) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		if kind == externalFunc {
+			module.Exports = append(module.Exports, name)
+		}
+	}
+	return nil
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.done() {
+		return 0, errors.New("unexpected end")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n uint32) ([]byte, error) {
+	if uint64(n) > uint64(len(r.data)-r.pos) {
+		return nil, errors.New("unexpected end")
+	}
+	res := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return res, nil
+}
+
+// u32 reads unsigned LEB128
+func (r *reader) u32() (uint32, error) {
+	var res uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift == 28 && b > 0x0f {
+			return 0, errors.New("too big u32")
+		}
+		res |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return res, nil
+		}
+	}
+	return 0, errors.New("too long u32")
+}
+
+func (r *reader) name() (string, error) {
+	length, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	data, err := r.bytes(length)
+	if err != nil {
+		return "", err
+	}
+	// zero bytes are rejected as they cannot be saved to postgres text and jsonb
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", errors.New("invalid name")
+	}
+	return string(data), nil
+}
+
+func (r *reader) limits() (*types.WasmMemory, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	// bit 1 marks shared memories of the threads proposal, 64-bit memories are not supported
+	if flags > 0x03 {
+		return nil, errors.Errorf("unsupported limits flags %v", flags)
+	}
+	res := &types.WasmMemory{}
+	if res.Min, err = r.u32(); err != nil {
+		return nil, err
+	}
+	if flags&0x01 != 0 {
+		max, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		res.Max = &max
+	}
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package wasm
+
+import (
+	"bytes"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func section(id byte, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	return append([]byte{id, byte(len(data))}, data...)
+}
+
+func name(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func Test_Parse(t *testing.T) {
+	code := bytes.Join([][]byte{
+		header,
+		section(1, []byte{0x01, 0x60, 0x00, 0x00}),
+		section(sectionImport, []byte{0x02},
+			name("env"), name("set_storage"), []byte{externalFunc, 0x00},
+			name("env"), name("table"), []byte{externalTable, 0x70, 0x00, 0x01},
+		),
+		section(sectionMemory, []byte{0x01, 0x01, 0x02, 0x90, 0x01}),
+		section(sectionExport, []byte{0x02},
+			name("deploy"), []byte{externalFunc, 0x01},
+			name("memory"), []byte{externalMemory, 0x00},
+		),
+		section(sectionCode, []byte{0x01, 0x02, 0x00, 0x0b}),
+		section(sectionCustom, name("name"), []byte{0x1, 0x2, 0x3}),
+	}, nil)
+	module, err := Parse(code)
+	require.Nil(t, err)
+	max := uint32(144)
+	require.Equal(t, &types.WasmModule{
+		Exports:        []string{"deploy"},
+		Imports:        []*types.WasmImport{{Module: "env", Name: "set_storage"}},
+		Memory:         &types.WasmMemory{Min: 2, Max: &max},
+		CustomSections: []*types.WasmCustomSection{{Name: "name", Size: 3}},
+		Functions:      1,
+		CodeSize:       4,
+	}, module)
+
+	code = bytes.Join([][]byte{
+		header,
+		section(sectionImport, []byte{0x01}, name("env"), name("memory"), []byte{externalMemory, 0x00, 0x01}),
+	}, nil)
+	module, err = Parse(code)
+	require.Nil(t, err)
+	require.Equal(t, &types.WasmMemory{Min: 1, Imported: true}, module.Memory)
+	require.Empty(t, module.Exports)
+
+	for _, invalid := range [][]byte{
+		nil,
+		{0x00, 0x61, 0x73, 0x6d, 0x02, 0x00, 0x00, 0x00},
+		append(append([]byte{}, header...), sectionExport, 0x05, 0x01),
+		append(append([]byte{}, header...), section(sectionExport, []byte{0x01, 0x05, 'a'})...),
+		append(append([]byte{}, header...), section(sectionMemory, []byte{0x01, 0x04, 0x01})...),
+		append(append([]byte{}, header...), section(sectionCustom, name("a\x00"))...),
+		append(append([]byte{}, header...), section(sectionImport, []byte{0x01}, name("env"), name("f"), []byte{0x09})...),
+		append(append([]byte{}, header...), section(sectionCode, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01})...),
+	} {
+		_, err := Parse(invalid)
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func Test_Introspect(t *testing.T) {
+	info := Introspect(header)
+	require.Equal(t, len(header), info.Size)
+	require.NotNil(t, info.Module)
+	require.Empty(t, info.Error)
+
+	info = Introspect([]byte{0x1})
+	require.Nil(t, info.Module)
+	require.NotEmpty(t, info.Error)
+	require.NotEqual(t, info.CodeHash, Introspect([]byte{0x2}).CodeHash)
+}
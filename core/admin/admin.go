@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/verification"
+	logUtil "github.com/idena-network/idena-indexer/core/log"
+	"github.com/idena-network/idena-indexer/core/text"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/data"
+	"github.com/idena-network/idena-indexer/indexer"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	ActionPause                     = "pause"
+	ActionResume                    = "resume"
+	ActionResetTo                   = "resetTo"
+	ActionRestore                   = "restore"
+	ActionRequeueFailedFlipsContent = "requeueFailedFlipsContent"
+	ActionRefreshData               = "refreshData"
+	ActionReverifyContract          = "reverifyContract"
+	ActionReverifyFailedContracts   = "reverifyFailedContracts"
+	ActionUploadContractAbi         = "uploadContractAbi"
+	ActionDeleteContractAbi         = "deleteContractAbi"
+	ActionSetLogLevel               = "setLogLevel"
+	ActionDumpState                 = "dumpState"
+
+	maxErrorLength = 200
+)
+
+type Indexer interface {
+	Pause()
+	Resume()
+	ResetTo(height uint64) (usrErr, err error)
+	RequestRestore()
+	StateDump() *indexer.StateDump
+}
+
+type AbiRegistry interface {
+	Upload(contractAddress common.Address, data []byte) (usrErr, err error)
+	Delete(contractAddress common.Address) (usrErr, err error)
+}
+
+type LogLevels struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// Admin runs operator actions on the running indexer, every action is saved to the audit log before it is run
+type Admin struct {
+	db          Db
+	indexer     Indexer
+	dataService data.Service
+	verifier    verification.Verifier
+	abiRegistry AbiRegistry
+	logLevels   *logUtil.ComponentLevels
+	logger      log.Logger
+	now         func() time.Time
+}
+
+// NewAdmin creates admin, dataService is nil if the data service is disabled
+func NewAdmin(
+	db Db,
+	indexer Indexer,
+	dataService data.Service,
+	verifier verification.Verifier,
+	abiRegistry AbiRegistry,
+	logLevels *logUtil.ComponentLevels,
+	logger log.Logger,
+) *Admin {
+	return &Admin{
+		db:          db,
+		indexer:     indexer,
+		dataService: dataService,
+		verifier:    verifier,
+		abiRegistry: abiRegistry,
+		logLevels:   logLevels,
+		logger:      logger,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+func (a *Admin) Pause(remoteAddr string) (usrErr, err error) {
+	_, usrErr, err = a.run(ActionPause, nil, remoteAddr, func() (interface{}, error, error) {
+		a.indexer.Pause()
+		return nil, nil, nil
+	})
+	return usrErr, err
+}
+
+func (a *Admin) Resume(remoteAddr string) (usrErr, err error) {
+	_, usrErr, err = a.run(ActionResume, nil, remoteAddr, func() (interface{}, error, error) {
+		a.indexer.Resume()
+		return nil, nil, nil
+	})
+	return usrErr, err
+}
+
+// ResetTo removes indexed data above the height, indexing is stopped until the process is restarted
+func (a *Admin) ResetTo(height uint64, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"height": height}
+	_, usrErr, err = a.run(ActionResetTo, params, remoteAddr, func() (interface{}, error, error) {
+		usrErr, err := a.indexer.ResetTo(height)
+		return nil, usrErr, err
+	})
+	return usrErr, err
+}
+
+// Restore makes the indexer restore db data from the node state before indexing the next block
+func (a *Admin) Restore(remoteAddr string) (usrErr, err error) {
+	_, usrErr, err = a.run(ActionRestore, nil, remoteAddr, func() (interface{}, error, error) {
+		a.indexer.RequestRestore()
+		return nil, nil, nil
+	})
+	return usrErr, err
+}
+
+// RequeueFailedFlipsContent makes the flip content loader retry flips which reached the attempts limit and returns
+// their number
+func (a *Admin) RequeueFailedFlipsContent(remoteAddr string) (cnt int64, usrErr, err error) {
+	res, usrErr, err := a.run(ActionRequeueFailedFlipsContent, nil, remoteAddr, func() (interface{}, error, error) {
+		cnt, err := a.db.RequeueFailedFlipsContent(a.now())
+		return cnt, nil, err
+	})
+	cnt, _ = res.(int64)
+	return cnt, usrErr, err
+}
+
+func (a *Admin) RefreshData(name string, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"name": name}
+	_, usrErr, err = a.run(ActionRefreshData, params, remoteAddr, func() (interface{}, error, error) {
+		if a.dataService == nil {
+			return nil, errors.New("data service is disabled"), nil
+		}
+		usrErr, err := a.dataService.Refresh(name)
+		return nil, usrErr, err
+	})
+	return usrErr, err
+}
+
+func (a *Admin) ReverifyContract(address common.Address, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"address": address.Hex()}
+	_, usrErr, err = a.run(ActionReverifyContract, params, remoteAddr, func() (interface{}, error, error) {
+		usrErr, err := a.verifier.Reverify(address)
+		return nil, usrErr, err
+	})
+	return usrErr, err
+}
+
+// ReverifyFailedContracts queues all failed contract verifications to run again and returns their number
+func (a *Admin) ReverifyFailedContracts(remoteAddr string) (cnt int, usrErr, err error) {
+	res, usrErr, err := a.run(ActionReverifyFailedContracts, nil, remoteAddr, func() (interface{}, error, error) {
+		cnt, err := a.verifier.ReverifyFailed()
+		return cnt, nil, err
+	})
+	cnt, _ = res.(int)
+	return cnt, usrErr, err
+}
+
+// UploadContractAbi saves the ABI of the contract, an existing ABI has to be deleted first
+func (a *Admin) UploadContractAbi(address common.Address, data []byte, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"address": address.Hex()}
+	_, usrErr, err = a.run(ActionUploadContractAbi, params, remoteAddr, func() (interface{}, error, error) {
+		usrErr, err := a.abiRegistry.Upload(address, data)
+		return nil, usrErr, err
+	})
+	return usrErr, err
+}
+
+func (a *Admin) DeleteContractAbi(address common.Address, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"address": address.Hex()}
+	_, usrErr, err = a.run(ActionDeleteContractAbi, params, remoteAddr, func() (interface{}, error, error) {
+		usrErr, err := a.abiRegistry.Delete(address)
+		return nil, usrErr, err
+	})
+	return usrErr, err
+}
+
+// SetLogLevel sets the log level of the component, empty component means the default level
+func (a *Admin) SetLogLevel(component, level string, remoteAddr string) (usrErr, err error) {
+	params := map[string]interface{}{"component": component, "level": level}
+	_, usrErr, err = a.run(ActionSetLogLevel, params, remoteAddr, func() (interface{}, error, error) {
+		lvl, err := log.LvlFromString(level)
+		if err != nil {
+			return nil, err, nil
+		}
+		a.logLevels.SetLevel(component, lvl)
+		return nil, nil, nil
+	})
+	return usrErr, err
+}
+
+func (a *Admin) LogLevels() *LogLevels {
+	defaultLvl, levels := a.logLevels.Levels()
+	res := &LogLevels{
+		Default: defaultLvl.String(),
+	}
+	if len(levels) > 0 {
+		res.Components = make(map[string]string, len(levels))
+		for component, lvl := range levels {
+			res.Components[component] = lvl.String()
+		}
+	}
+	return res
+}
+
+func (a *Admin) DumpState(remoteAddr string) (*indexer.StateDump, error) {
+	res, _, err := a.run(ActionDumpState, nil, remoteAddr, func() (interface{}, error, error) {
+		return a.indexer.StateDump(), nil, nil
+	})
+	state, _ := res.(*indexer.StateDump)
+	return state, err
+}
+
+func (a *Admin) Actions(count uint64, continuationToken *string) ([]*types.AdminAction, *string, error) {
+	return a.db.Actions(count, continuationToken)
+}
+
+func (a *Admin) run(
+	action string,
+	params interface{},
+	remoteAddr string,
+	f func() (interface{}, error, error),
+) (interface{}, error, error) {
+	var paramsData []byte
+	if params != nil {
+		var err error
+		if paramsData, err = json.Marshal(params); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to marshal action params")
+		}
+	}
+	id, err := a.db.SaveAction(action, remoteAddr, paramsData, a.now())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to save action to audit log")
+	}
+	a.logger.Info(fmt.Sprintf("Running admin action %v %v", id, action), "params", string(paramsData),
+		"from", remoteAddr)
+	res, usrErr, err := f()
+	var errorMessage string
+	if usrErr != nil {
+		errorMessage = usrErr.Error()
+	} else if err != nil {
+		errorMessage = err.Error()
+	}
+	errorMessage = text.Truncate(errorMessage, maxErrorLength)
+	if completeErr := a.db.CompleteAction(id, errorMessage, a.now()); completeErr != nil {
+		a.logger.Error(fmt.Sprintf("Unable to complete admin action %v in audit log: %v", id, completeErr))
+	}
+	return res, usrErr, err
+}
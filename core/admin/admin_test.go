@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/indexer"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testAction struct {
+	action     string
+	remoteAddr string
+	params     string
+	completed  bool
+	error      string
+}
+
+type testDb struct {
+	actions []*testAction
+}
+
+func (db *testDb) SaveAction(action, remoteAddr string, params []byte, timestamp time.Time) (uint64, error) {
+	db.actions = append(db.actions, &testAction{action: action, remoteAddr: remoteAddr, params: string(params)})
+	return uint64(len(db.actions)), nil
+}
+
+func (db *testDb) CompleteAction(id uint64, errorMessage string, completedAt time.Time) error {
+	db.actions[id-1].completed = true
+	db.actions[id-1].error = errorMessage
+	return nil
+}
+
+func (db *testDb) RequeueFailedFlipsContent(nextAttemptAt time.Time) (int64, error) {
+	return 3, nil
+}
+
+func (db *testDb) Actions(count uint64, continuationToken *string) ([]*types.AdminAction, *string, error) {
+	return nil, nil, nil
+}
+
+type testIndexer struct {
+	paused bool
+}
+
+func (i *testIndexer) Pause() {
+	i.paused = true
+}
+
+func (i *testIndexer) Resume() {
+	i.paused = false
+}
+
+func (i *testIndexer) ResetTo(height uint64) (usrErr, err error) {
+	if height >= 10 {
+		return errors.New("height to reset should be less than last indexed height 10"), nil
+	}
+	return nil, nil
+}
+
+func (i *testIndexer) RequestRestore() {
+}
+
+func (i *testIndexer) StateDump() *indexer.StateDump {
+	return &indexer.StateDump{LastIndexedHeight: 10, Paused: i.paused}
+}
+
+type testAbiRegistry struct {
+	abis map[common.Address][]byte
+}
+
+func (r *testAbiRegistry) Upload(contractAddress common.Address, data []byte) (usrErr, err error) {
+	if _, ok := r.abis[contractAddress]; ok {
+		return errors.New("contract already has ABI"), nil
+	}
+	r.abis[contractAddress] = data
+	return nil, nil
+}
+
+func (r *testAbiRegistry) Delete(contractAddress common.Address) (usrErr, err error) {
+	if _, ok := r.abis[contractAddress]; !ok {
+		return errors.New("contract has no ABI"), nil
+	}
+	delete(r.abis, contractAddress)
+	return nil, nil
+}
+
+func Test_AdminActionsAreAuditLogged(t *testing.T) {
+	adminDb := &testDb{}
+	idx := &testIndexer{}
+	abiRegistry := &testAbiRegistry{abis: make(map[common.Address][]byte)}
+	a := NewAdmin(adminDb, idx, nil, nil, abiRegistry, nil, log.New())
+
+	usrErr, err := a.Pause("127.0.0.1")
+	require.Nil(t, usrErr)
+	require.Nil(t, err)
+	require.True(t, idx.paused)
+
+	usrErr, err = a.ResetTo(15, "127.0.0.1")
+	require.NotNil(t, usrErr)
+	require.Nil(t, err)
+
+	cnt, usrErr, err := a.RequeueFailedFlipsContent("127.0.0.2")
+	require.Nil(t, usrErr)
+	require.Nil(t, err)
+	require.Equal(t, int64(3), cnt)
+
+	usrErr, err = a.RefreshData("data1", "127.0.0.2")
+	require.NotNil(t, usrErr)
+	require.Nil(t, err)
+
+	contract := common.Address{0x1}
+	usrErr, err = a.UploadContractAbi(contract, []byte(`{"methods": []}`), "127.0.0.3")
+	require.Nil(t, usrErr)
+	require.Nil(t, err)
+	usrErr, err = a.UploadContractAbi(contract, []byte(`{"methods": [{"name": "transfer"}]}`), "127.0.0.3")
+	require.NotNil(t, usrErr)
+	require.Nil(t, err)
+	require.Equal(t, []byte(`{"methods": []}`), abiRegistry.abis[contract])
+	usrErr, err = a.DeleteContractAbi(contract, "127.0.0.3")
+	require.Nil(t, usrErr)
+	require.Nil(t, err)
+	require.Empty(t, abiRegistry.abis)
+
+	state, err := a.DumpState("127.0.0.1")
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), state.LastIndexedHeight)
+	require.True(t, state.Paused)
+
+	require.Equal(t, []*testAction{
+		{action: ActionPause, remoteAddr: "127.0.0.1", completed: true},
+		{action: ActionResetTo, remoteAddr: "127.0.0.1", params: `{"height":15}`, completed: true,
+			error: "height to reset should be less than last indexed height 10"},
+		{action: ActionRequeueFailedFlipsContent, remoteAddr: "127.0.0.2", completed: true},
+		{action: ActionRefreshData, remoteAddr: "127.0.0.2", params: `{"name":"data1"}`, completed: true,
+			error: "data service is disabled"},
+		{action: ActionUploadContractAbi, remoteAddr: "127.0.0.3", params: `{"address":"` + contract.Hex() + `"}`,
+			completed: true},
+		{action: ActionUploadContractAbi, remoteAddr: "127.0.0.3", params: `{"address":"` + contract.Hex() + `"}`,
+			completed: true, error: "contract already has ABI"},
+		{action: ActionDeleteContractAbi, remoteAddr: "127.0.0.3", params: `{"address":"` + contract.Hex() + `"}`,
+			completed: true},
+		{action: ActionDumpState, remoteAddr: "127.0.0.1", completed: true},
+	}, adminDb.actions)
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// failedFlipAttemptTimestamp marks flips_queue items which reached the attempts limit
+const failedFlipAttemptTimestamp = 9223372036854775807
+
+type Db interface {
+	SaveAction(action, remoteAddr string, params []byte, timestamp time.Time) (uint64, error)
+	CompleteAction(id uint64, errorMessage string, completedAt time.Time) error
+	// RequeueFailedFlipsContent resets attempts of flips whose content failed to load and returns their number
+	RequeueFailedFlipsContent(nextAttemptAt time.Time) (int64, error)
+	Actions(count uint64, continuationToken *string) ([]*types.AdminAction, *string, error)
+}
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+func (p *Postgres) SaveAction(action, remoteAddr string, params []byte, timestamp time.Time) (uint64, error) {
+	const query = `INSERT INTO admin_actions ("timestamp", action, params, remote_addr)
+VALUES ($1, $2, $3, $4)
+RETURNING id`
+	var id uint64
+	err := p.db.QueryRow(query, timestamp.Unix(), action, params, remoteAddr).Scan(&id)
+	return id, err
+}
+
+func (p *Postgres) CompleteAction(id uint64, errorMessage string, completedAt time.Time) error {
+	const query = `UPDATE admin_actions
+SET completed_at = $2,
+    error        = nullif($3, '')
+WHERE id = $1`
+	_, err := p.db.Exec(query, id, completedAt.Unix(), errorMessage)
+	return err
+}
+
+func (p *Postgres) RequeueFailedFlipsContent(nextAttemptAt time.Time) (int64, error) {
+	const query = `UPDATE flips_queue
+SET attempts               = 0,
+    next_attempt_timestamp = $2
+WHERE next_attempt_timestamp = $1`
+	res, err := p.db.Exec(query, int64(failedFlipAttemptTimestamp), nextAttemptAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Actions returns admin actions starting from the latest one
+func (p *Postgres) Actions(count uint64, continuationToken *string) ([]*types.AdminAction, *string, error) {
+	const query = `SELECT id, "timestamp", action, params, coalesce(remote_addr, ''), completed_at, coalesce(error, '')
+FROM admin_actions
+WHERE $2::bigint IS NULL OR id <= $2
+ORDER BY id DESC
+LIMIT $1`
+	var startId *uint64
+	if continuationToken != nil {
+		id, err := strconv.ParseUint(*continuationToken, 10, 64)
+		if err != nil {
+			return nil, nil, errors.New("invalid continuation token")
+		}
+		startId = &id
+	}
+	rows, err := p.db.Query(query, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.AdminAction
+	for rows.Next() {
+		item := &types.AdminAction{}
+		var timestamp int64
+		var params []byte
+		var completedAt sql.NullInt64
+		if err := rows.Scan(&item.Id, &timestamp, &item.Action, &params, &item.RemoteAddr, &completedAt,
+			&item.Error); err != nil {
+			return nil, nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		if len(params) > 0 {
+			item.Params = json.RawMessage(params)
+		}
+		if completedAt.Valid {
+			t := time.Unix(completedAt.Int64, 0).UTC()
+			item.CompletedAt = &t
+		}
+		res = append(res, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		t := strconv.FormatUint(res[count].Id, 10)
+		nextContinuationToken = &t
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
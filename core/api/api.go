@@ -2,10 +2,13 @@ package api
 
 import (
 	"github.com/idena-network/idena-go/blockchain"
+	types2 "github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-indexer/contract/abi"
 	"github.com/idena-network/idena-indexer/contract/verification"
+	"github.com/idena-network/idena-indexer/core/conversion"
 	"github.com/idena-network/idena-indexer/core/holder/contract"
 	"github.com/idena-network/idena-indexer/core/holder/online"
 	"github.com/idena-network/idena-indexer/core/holder/state"
@@ -26,6 +29,7 @@ type Api struct {
 	stateHolder      state.Holder
 	contractHolder   contract.Holder
 	contractVerifier verification.Verifier
+	abiRegistry      *abi.Registry
 }
 
 func NewApi(
@@ -36,6 +40,7 @@ func NewApi(
 	stateHolder state.Holder,
 	contractHolder contract.Holder,
 	contractVerifier verification.Verifier,
+	abiRegistry *abi.Registry,
 ) *Api {
 	return &Api{
 		onlineIdentities: onlineIdentities,
@@ -45,6 +50,7 @@ func NewApi(
 		stateHolder:      stateHolder,
 		contractHolder:   contractHolder,
 		contractVerifier: contractVerifier,
+		abiRegistry:      abiRegistry,
 	}
 }
 
@@ -174,7 +180,22 @@ func (a *Api) UpgradeVoting() []*types.UpgradeVotes {
 }
 
 func (a *Api) MemPoolTransaction(hash string) (*types.TransactionDetail, error) {
-	return a.memPool.GetTransaction(hash)
+	tx, err := a.memPool.GetTransaction(hash)
+	if err != nil || tx == nil || tx.Type != conversion.ConvertTxType(types2.CallContractTx) || len(tx.To) == 0 {
+		return tx, err
+	}
+	payload, err := a.memPool.GetTransactionRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	method, args, ok := abi.CallArgs(types2.CallContractTx, payload)
+	if !ok {
+		return tx, nil
+	}
+	if tx.Decoded, err = a.abiRegistry.Decode(common.HexToAddress(tx.To), &abi.Call{Method: method, Args: args}); err != nil {
+		return nil, errors.Wrap(err, "failed to decode contract call")
+	}
+	return tx, nil
 }
 
 func (a *Api) MemPoolTransactionRaw(hash string) (hexutil.Bytes, error) {
@@ -259,6 +280,44 @@ func (a *Api) IdentityWithProof(epoch uint64, address string) (*hexutil.Bytes, e
 	return a.stateHolder.IdentityWithProof(epoch, common.HexToAddress(address))
 }
 
+func (a *Api) EpochIdentity(epoch uint64, address string, withProof bool) (*types.EpochIdentity, error) {
+	return a.stateHolder.Identity(epoch, common.HexToAddress(address), withProof)
+}
+
+func (a *Api) EpochAccount(epoch uint64, address string, withProof bool) (*types.EpochAccount, error) {
+	return a.stateHolder.Account(epoch, common.HexToAddress(address), withProof)
+}
+
+func (a *Api) EpochContractValue(epoch uint64, address string, key []byte, withProof bool) (*types.EpochContractValue, error) {
+	return a.stateHolder.ContractValue(epoch, common.HexToAddress(address), key, withProof)
+}
+
+func (a *Api) IdentitiesDiff(fromEpoch, toEpoch uint64, count uint64, continuationToken *string) ([]*types.IdentityChange, *string, error) {
+	var startIndex uint64
+	if continuationToken != nil {
+		var err error
+		if startIndex, err = strconv.ParseUint(*continuationToken, 10, 64); err != nil {
+			return nil, nil, errors.New("invalid continuation token")
+		}
+	}
+	all, err := a.stateHolder.IdentitiesDiff(fromEpoch, toEpoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if startIndex >= uint64(len(all)) {
+		return nil, nil, nil
+	}
+	var nextContinuationToken *string
+	end := startIndex + count
+	if end < uint64(len(all)) {
+		t := strconv.FormatUint(end, 10)
+		nextContinuationToken = &t
+	} else {
+		end = uint64(len(all))
+	}
+	return all[startIndex:end], nextContinuationToken, nil
+}
+
 func (a *Api) Staking() (types.Staking, error) {
 	return a.onlineIdentities.Staking(), nil
 }
@@ -275,3 +334,25 @@ func (a *Api) VerifyContract(contractAddress string, data []byte, fileName strin
 	address := common.HexToAddress(contractAddress)
 	return a.contractVerifier.Submit(address, data, fileName)
 }
+
+// ContractVerification returns nil if no source has been submitted for the contract
+func (a *Api) ContractVerification(contractAddress string) (*types.ContractVerification, error) {
+	return a.contractVerifier.Verification(common.HexToAddress(contractAddress))
+}
+
+func (a *Api) ContractSourceFiles(contractAddress string) (files []*types.ContractSourceFile, usrErr, err error) {
+	return a.contractVerifier.SourceFiles(common.HexToAddress(contractAddress))
+}
+
+func (a *Api) ContractSourceFile(contractAddress string, name string) (data []byte, usrErr, err error) {
+	return a.contractVerifier.SourceFile(common.HexToAddress(contractAddress), name)
+}
+
+func (a *Api) ContractSource(contractAddress string) (data []byte, fileName string, usrErr, err error) {
+	return a.contractVerifier.Source(common.HexToAddress(contractAddress))
+}
+
+// ContractAbi returns nil if there is no ABI of the contract
+func (a *Api) ContractAbi(contractAddress string) (*abi.ABI, error) {
+	return a.abiRegistry.Get(common.HexToAddress(contractAddress))
+}
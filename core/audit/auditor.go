@@ -0,0 +1,393 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/appstate"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+
+	CheckBalance             = "balance"
+	CheckStake               = "stake"
+	CheckContractBalance     = "contractBalance"
+	CheckLatestBalanceUpdate = "latestBalanceUpdate"
+	CheckBalanceUpdateGaps   = "balanceUpdateGaps"
+	CheckBurntCoins          = "burntCoins"
+	CheckIdentityState       = "identityState"
+	CheckDelegation          = "delegation"
+	CheckPoolSize            = "poolSize"
+	CheckTokenBalance        = "tokenBalance"
+
+	// maxSavedDiscrepanciesPerCheck limits the number of discrepancies of the same check saved per run, all of them
+	// are counted anyway
+	maxSavedDiscrepanciesPerCheck = 100
+)
+
+var (
+	severities = map[string]string{
+		CheckBalance:             SeverityCritical,
+		CheckStake:               SeverityCritical,
+		CheckContractBalance:     SeverityCritical,
+		CheckLatestBalanceUpdate: SeverityCritical,
+		CheckBalanceUpdateGaps:   SeverityCritical,
+		CheckBurntCoins:          SeverityCritical,
+		CheckIdentityState:       SeverityWarning,
+		CheckDelegation:          SeverityWarning,
+		CheckPoolSize:            SeverityWarning,
+		CheckTokenBalance:        SeverityWarning,
+	}
+
+	// repairableChecks are checks of the data which restore.Restorer writes
+	repairableChecks = map[string]bool{
+		CheckBalance:         true,
+		CheckStake:           true,
+		CheckContractBalance: true,
+		CheckDelegation:      true,
+		CheckPoolSize:        true,
+	}
+)
+
+type BalancesAccessor interface {
+	Balances() ([]db.Balance, error)
+	LatestBalanceUpdates() ([]db.Balance, error)
+	BalanceUpdateGapCnt() (int, error)
+	BurntCoinsInconsistencyCnt() (int, error)
+}
+
+type TokenHolder interface {
+	Balance(appState *appstate.AppState, contractAddress common.Address, address []byte) (*big.Int, error)
+}
+
+type Config struct {
+	// IntervalBlocks is the number of blocks between audits
+	IntervalBlocks uint64
+	// Repair enables restoring db data from the node state if the audit finds it inconsistent
+	Repair bool
+	// MaxTokenBalances is the max number of randomly chosen token balances checked per audit, 0 disables the check
+	MaxTokenBalances int
+}
+
+// Auditor compares data saved by the indexer with the node state
+type Auditor struct {
+	db               Db
+	balancesAccessor BalancesAccessor
+	tokenHolder      TokenHolder
+	conf             Config
+	logger           log.Logger
+}
+
+func NewAuditor(db Db, balancesAccessor BalancesAccessor, tokenHolder TokenHolder, conf Config, logger log.Logger) *Auditor {
+	return &Auditor{
+		db:               db,
+		balancesAccessor: balancesAccessor,
+		tokenHolder:      tokenHolder,
+		conf:             conf,
+		logger:           logger,
+	}
+}
+
+// Audit checks the db if an audit is due at the height, saves found discrepancies and returns true if the db data
+// should be restored from the state
+func (a *Auditor) Audit(height uint64, appState *appstate.AppState) bool {
+	if a.conf.IntervalBlocks == 0 || height%a.conf.IntervalBlocks != 0 {
+		return false
+	}
+	r := a.run(height, appState)
+	run := &types.AuditRun{
+		Timestamp:     time.Now().UTC(),
+		BlockHeight:   height,
+		Discrepancies: r.total,
+		Incomplete:    r.incomplete,
+		Repair:        a.conf.Repair && r.repairable,
+	}
+	if err := a.db.SaveRun(run, r.saved); err != nil {
+		a.logger.Error(errors.Wrapf(err, "failed to save audit run, height %d", height).Error())
+	}
+	if run.Discrepancies > 0 {
+		a.logger.Warn(fmt.Sprintf("Audit at height %d found %d discrepancies", height, run.Discrepancies),
+			"repair", run.Repair)
+	} else {
+		a.logger.Info(fmt.Sprintf("Audit at height %d found no discrepancies", height))
+	}
+	return run.Repair
+}
+
+type report struct {
+	saved        []*types.AuditDiscrepancy
+	savedByCheck map[string]int
+	total        uint64
+	repairable   bool
+	incomplete   bool
+}
+
+func (r *report) add(check, address, contract, stateValue, dbValue string) {
+	r.total++
+	r.repairable = r.repairable || repairableChecks[check]
+	if r.savedByCheck[check] >= maxSavedDiscrepanciesPerCheck {
+		return
+	}
+	r.savedByCheck[check]++
+	r.saved = append(r.saved, &types.AuditDiscrepancy{
+		Check:      check,
+		Severity:   severities[check],
+		Address:    address,
+		Contract:   contract,
+		StateValue: stateValue,
+		DbValue:    dbValue,
+	})
+}
+
+func (a *Auditor) run(height uint64, appState *appstate.AppState) *report {
+	r := &report{
+		savedByCheck: make(map[string]int),
+	}
+	checks := []struct {
+		name string
+		f    func(height uint64, appState *appstate.AppState, r *report) error
+	}{
+		{"balances", a.checkBalances},
+		{"balance updates", a.checkBalanceUpdates},
+		{"identity states", a.checkIdentityStates},
+		{"delegations", a.checkDelegations},
+		{"token balances", a.checkTokenBalances},
+	}
+	for _, check := range checks {
+		if err := check.f(height, appState, r); err != nil {
+			r.incomplete = true
+			a.logger.Error(errors.Wrapf(err, "failed to check %v", check.name).Error())
+		}
+	}
+	return r
+}
+
+type balance struct {
+	balance  decimal.Decimal
+	stake    decimal.Decimal
+	contract bool
+}
+
+func (a *Auditor) checkBalances(_ uint64, appState *appstate.AppState, r *report) error {
+	stateBalances := make(map[string]*balance)
+	addAddress := func(addr common.Address) {
+		address := strings.ToLower(addr.Hex())
+		if _, ok := stateBalances[address]; ok {
+			return
+		}
+		stateBalances[address] = &balance{
+			balance:  blockchain.ConvertToFloat(appState.State.GetBalance(addr)),
+			stake:    blockchain.ConvertToFloat(appState.State.GetStakeBalance(addr)),
+			contract: appState.State.GetCodeHash(addr) != nil,
+		}
+	}
+	appState.State.IterateOverAccounts(func(addr common.Address, _ state.Account) {
+		addAddress(addr)
+	})
+	appState.State.IterateOverIdentities(func(addr common.Address, _ state.Identity) {
+		addAddress(addr)
+	})
+
+	dbBalances, err := a.balancesAccessor.Balances()
+	if err != nil {
+		return errors.Wrap(err, "failed to get db balances")
+	}
+	dbBalancesByAddress := make(map[string]*balance, len(dbBalances))
+	for _, item := range dbBalances {
+		dbBalancesByAddress[strings.ToLower(item.Address)] = &balance{
+			balance: item.Balance,
+			stake:   item.Stake,
+		}
+	}
+	zero := &balance{}
+	for address, stateBalance := range stateBalances {
+		dbBalance, ok := dbBalancesByAddress[address]
+		if !ok {
+			dbBalance = zero
+		}
+		if !stateBalance.balance.Equal(dbBalance.balance) {
+			check := CheckBalance
+			if stateBalance.contract {
+				check = CheckContractBalance
+			}
+			r.add(check, address, "", stateBalance.balance.String(), dbBalance.balance.String())
+		}
+		if !stateBalance.stake.Equal(dbBalance.stake) {
+			r.add(CheckStake, address, "", stateBalance.stake.String(), dbBalance.stake.String())
+		}
+	}
+	for address, dbBalance := range dbBalancesByAddress {
+		if _, ok := stateBalances[address]; ok {
+			continue
+		}
+		if !dbBalance.balance.IsZero() {
+			r.add(CheckBalance, address, "", zero.balance.String(), dbBalance.balance.String())
+		}
+		if !dbBalance.stake.IsZero() {
+			r.add(CheckStake, address, "", zero.stake.String(), dbBalance.stake.String())
+		}
+	}
+
+	dbLatestBalanceUpdates, err := a.balancesAccessor.LatestBalanceUpdates()
+	if err != nil {
+		return errors.Wrap(err, "failed to get db latest balance updates")
+	}
+	for _, item := range dbLatestBalanceUpdates {
+		address := strings.ToLower(item.Address)
+		stateBalance, ok := stateBalances[address]
+		if !ok {
+			stateBalance = zero
+		}
+		if !stateBalance.balance.Equal(item.Balance) {
+			r.add(CheckLatestBalanceUpdate, address, "", stateBalance.balance.String(), item.Balance.String())
+		}
+	}
+	return nil
+}
+
+func (a *Auditor) checkBalanceUpdates(_ uint64, _ *appstate.AppState, r *report) error {
+	gapCnt, err := a.balancesAccessor.BalanceUpdateGapCnt()
+	if err != nil {
+		return errors.Wrap(err, "failed to get balance update gap cnt")
+	}
+	if gapCnt > 0 {
+		r.add(CheckBalanceUpdateGaps, "", "", "0", strconv.Itoa(gapCnt))
+	}
+	inconsistencyCnt, err := a.balancesAccessor.BurntCoinsInconsistencyCnt()
+	if err != nil {
+		return errors.Wrap(err, "failed to get burnt coins inconsistency cnt")
+	}
+	if inconsistencyCnt > 0 {
+		r.add(CheckBurntCoins, "", "", "0", strconv.Itoa(inconsistencyCnt))
+	}
+	return nil
+}
+
+func (a *Auditor) checkIdentityStates(_ uint64, appState *appstate.AppState, r *report) error {
+	// Undefined and killed identities are considered absent since killed ones may be removed from the state
+	isPresent := func(identityState state.IdentityState) bool {
+		return identityState != state.Undefined && identityState != state.Killed
+	}
+	stateIdentityStates := make(map[string]state.IdentityState)
+	appState.State.IterateOverIdentities(func(addr common.Address, identity state.Identity) {
+		if isPresent(identity.State) {
+			stateIdentityStates[strings.ToLower(addr.Hex())] = identity.State
+		}
+	})
+	dbIdentityStates, err := a.db.IdentityStates()
+	if err != nil {
+		return errors.Wrap(err, "failed to get db identity states")
+	}
+	for address, stateIdentityState := range stateIdentityStates {
+		dbIdentityState := state.IdentityState(dbIdentityStates[address])
+		if stateIdentityState != dbIdentityState {
+			r.add(CheckIdentityState, address, "", identityStateName(stateIdentityState), identityStateName(dbIdentityState))
+		}
+	}
+	for address, v := range dbIdentityStates {
+		dbIdentityState := state.IdentityState(v)
+		if _, ok := stateIdentityStates[address]; !ok && isPresent(dbIdentityState) {
+			r.add(CheckIdentityState, address, "", identityStateName(state.Undefined), identityStateName(dbIdentityState))
+		}
+	}
+	return nil
+}
+
+func identityStateName(identityState state.IdentityState) string {
+	return conversion.ConvertIdentityState(uint8(identityState))
+}
+
+func (a *Auditor) checkDelegations(_ uint64, appState *appstate.AppState, r *report) error {
+	stateDelegations := make(map[string]string)
+	statePoolSizes := make(map[string]PoolSize)
+	appState.State.IterateOverIdentities(func(addr common.Address, identity state.Identity) {
+		delegatee := identity.Delegatee()
+		if delegatee == nil {
+			return
+		}
+		pool := strings.ToLower(delegatee.Hex())
+		stateDelegations[strings.ToLower(addr.Hex())] = pool
+		poolSize, ok := statePoolSizes[pool]
+		if !ok {
+			poolSize.Size = uint64(appState.ValidatorsCache.PoolSize(*delegatee))
+		}
+		poolSize.TotalDelegated++
+		statePoolSizes[pool] = poolSize
+	})
+
+	dbDelegations, err := a.db.Delegations()
+	if err != nil {
+		return errors.Wrap(err, "failed to get db delegations")
+	}
+	for delegator, stateDelegatee := range stateDelegations {
+		if dbDelegatee := dbDelegations[delegator]; stateDelegatee != dbDelegatee {
+			r.add(CheckDelegation, delegator, "", stateDelegatee, dbDelegatee)
+		}
+	}
+	for delegator, dbDelegatee := range dbDelegations {
+		if _, ok := stateDelegations[delegator]; !ok {
+			r.add(CheckDelegation, delegator, "", "", dbDelegatee)
+		}
+	}
+
+	dbPoolSizes, err := a.db.PoolSizes()
+	if err != nil {
+		return errors.Wrap(err, "failed to get db pool sizes")
+	}
+	poolSizeStr := func(v PoolSize) string {
+		return fmt.Sprintf("size: %d, total delegated: %d", v.Size, v.TotalDelegated)
+	}
+	for pool, statePoolSize := range statePoolSizes {
+		if dbPoolSize := dbPoolSizes[pool]; statePoolSize != dbPoolSize {
+			r.add(CheckPoolSize, pool, "", poolSizeStr(statePoolSize), poolSizeStr(dbPoolSize))
+		}
+	}
+	for pool, dbPoolSize := range dbPoolSizes {
+		if _, ok := statePoolSizes[pool]; !ok && dbPoolSize != (PoolSize{}) {
+			r.add(CheckPoolSize, pool, "", poolSizeStr(PoolSize{}), poolSizeStr(dbPoolSize))
+		}
+	}
+	return nil
+}
+
+func (a *Auditor) checkTokenBalances(height uint64, appState *appstate.AppState, r *report) error {
+	if a.conf.MaxTokenBalances <= 0 || a.tokenHolder == nil {
+		return nil
+	}
+	dbTokenBalances, err := a.db.TokenBalances(a.conf.MaxTokenBalances)
+	if err != nil {
+		return errors.Wrap(err, "failed to get db token balances")
+	}
+	if len(dbTokenBalances) == 0 {
+		return nil
+	}
+	// Contract calls are executed against a copy to keep the node state untouched
+	appStateCopy, err := appState.ForCheck(height)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get app state for height %d", height)
+	}
+	for _, item := range dbTokenBalances {
+		stateBalance, err := a.tokenHolder.Balance(appStateCopy, common.HexToAddress(item.Contract),
+			common.HexToAddress(item.Address).Bytes())
+		if err != nil {
+			return errors.Wrapf(err, "failed to get token balance, contract %v, address %v", item.Contract, item.Address)
+		}
+		if stateBalance.Cmp(item.Balance) != 0 {
+			r.add(CheckTokenBalance, item.Address, item.Contract, stateBalance.String(), item.Balance.String())
+		}
+	}
+	return nil
+}
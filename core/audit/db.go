@@ -0,0 +1,241 @@
+package audit
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Db interface {
+	IdentityStates() (map[string]uint8, error)
+	Delegations() (map[string]string, error)
+	PoolSizes() (map[string]PoolSize, error)
+	TokenBalances(count int) ([]*TokenBalance, error)
+	SaveRun(run *types.AuditRun, discrepancies []*types.AuditDiscrepancy) error
+}
+
+type PoolSize struct {
+	Size           uint64
+	TotalDelegated uint64
+}
+
+type TokenBalance struct {
+	Contract string
+	Address  string
+	Balance  *big.Int
+}
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+// IdentityStates returns actual identity states by lower case addresses
+func (p *Postgres) IdentityStates() (map[string]uint8, error) {
+	const query = `SELECT a.address, s.state
+FROM address_states s
+         JOIN addresses a ON a.id = s.address_id
+WHERE s.is_actual`
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]uint8)
+	for rows.Next() {
+		var address string
+		var state uint8
+		if err := rows.Scan(&address, &state); err != nil {
+			return nil, err
+		}
+		res[strings.ToLower(address)] = state
+	}
+	return res, rows.Err()
+}
+
+// Delegations returns delegatees by delegators, all addresses are lower case
+func (p *Postgres) Delegations() (map[string]string, error) {
+	const query = `SELECT dr.address, de.address
+FROM delegations d
+         JOIN addresses dr ON dr.id = d.delegator_address_id
+         JOIN addresses de ON de.id = d.delegatee_address_id`
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]string)
+	for rows.Next() {
+		var delegator, delegatee string
+		if err := rows.Scan(&delegator, &delegatee); err != nil {
+			return nil, err
+		}
+		res[strings.ToLower(delegator)] = strings.ToLower(delegatee)
+	}
+	return res, rows.Err()
+}
+
+// PoolSizes returns pool sizes by lower case pool addresses
+func (p *Postgres) PoolSizes() (map[string]PoolSize, error) {
+	const query = `SELECT a.address, ps.size, ps.total_delegated
+FROM pool_sizes ps
+         JOIN addresses a ON a.id = ps.address_id`
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]PoolSize)
+	for rows.Next() {
+		var address string
+		var item PoolSize
+		if err := rows.Scan(&address, &item.Size, &item.TotalDelegated); err != nil {
+			return nil, err
+		}
+		res[strings.ToLower(address)] = item
+	}
+	return res, rows.Err()
+}
+
+// TokenBalances returns randomly chosen token balances to be checked
+func (p *Postgres) TokenBalances(count int) ([]*TokenBalance, error) {
+	const query = `SELECT a.address, tb.address, tb.balance::text
+FROM token_balances tb
+         JOIN addresses a ON a.id = tb.contract_address_id
+ORDER BY random()
+LIMIT $1`
+	rows, err := p.db.Query(query, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*TokenBalance
+	for rows.Next() {
+		item := &TokenBalance{}
+		var balance string
+		if err := rows.Scan(&item.Contract, &item.Address, &balance); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if item.Balance, ok = new(big.Int).SetString(balance, 10); !ok {
+			return nil, errors.Errorf("invalid token balance %v", balance)
+		}
+		item.Contract, item.Address = strings.ToLower(item.Contract), strings.ToLower(item.Address)
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+// SaveRun saves the audit run and sets its id
+func (p *Postgres) SaveRun(run *types.AuditRun, discrepancies []*types.AuditDiscrepancy) error {
+	const insertRunQuery = `INSERT INTO audit_runs ("timestamp", block_height, discrepancies, incomplete, repair)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id`
+	const insertDiscrepancyQuery = `INSERT INTO audit_discrepancies (run_id, "check", severity, address, contract, state_value, db_value)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRow(insertRunQuery, run.Timestamp.Unix(), run.BlockHeight, run.Discrepancies, run.Incomplete,
+		run.Repair).Scan(&run.Id); err != nil {
+		return errors.Wrap(err, "failed to save audit run")
+	}
+	for _, item := range discrepancies {
+		if _, err := tx.Exec(insertDiscrepancyQuery, run.Id, item.Check, item.Severity, nullIfEmpty(item.Address),
+			nullIfEmpty(item.Contract), item.StateValue, item.DbValue); err != nil {
+			return errors.Wrap(err, "failed to save audit discrepancy")
+		}
+	}
+	return tx.Commit()
+}
+
+// Runs returns audit runs starting from the latest one
+func (p *Postgres) Runs(count uint64, continuationToken *string) ([]*types.AuditRun, *string, error) {
+	const query = `SELECT id, "timestamp", block_height, discrepancies, incomplete, repair
+FROM audit_runs
+WHERE $2::bigint IS NULL OR id <= $2
+ORDER BY id DESC
+LIMIT $1`
+	var startId *uint64
+	if continuationToken != nil {
+		id, err := strconv.ParseUint(*continuationToken, 10, 64)
+		if err != nil {
+			return nil, nil, errors.New("invalid continuation token")
+		}
+		startId = &id
+	}
+	rows, err := p.db.Query(query, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.AuditRun
+	for rows.Next() {
+		item := &types.AuditRun{}
+		var timestamp int64
+		if err := rows.Scan(&item.Id, &timestamp, &item.BlockHeight, &item.Discrepancies, &item.Incomplete,
+			&item.Repair); err != nil {
+			return nil, nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		res = append(res, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		t := strconv.FormatUint(res[count].Id, 10)
+		nextContinuationToken = &t
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+// Discrepancies returns saved discrepancies found by the audit run
+func (p *Postgres) Discrepancies(runId uint64) ([]*types.AuditDiscrepancy, error) {
+	const query = `SELECT "check", severity, coalesce(address, ''), coalesce(contract, ''), state_value, db_value
+FROM audit_discrepancies
+WHERE run_id = $1
+ORDER BY "check", address`
+	rows, err := p.db.Query(query, runId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.AuditDiscrepancy
+	for rows.Next() {
+		item := &types.AuditDiscrepancy{}
+		if err := rows.Scan(&item.Check, &item.Severity, &item.Address, &item.Contract, &item.StateValue,
+			&item.DbValue); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func nullIfEmpty(s string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package conversion
 import (
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
 )
 
 var (
@@ -30,6 +31,18 @@ var (
 		types.KillDelegatorTx:      "KillDelegatorTx",
 		types.StoreToIpfsTx:        "StoreToIpfsTx",
 	}
+
+	identityStateNames = map[uint8]string{
+		uint8(state.Undefined): "Undefined",
+		uint8(state.Invite):    "Invite",
+		uint8(state.Candidate): "Candidate",
+		uint8(state.Verified):  "Verified",
+		uint8(state.Suspended): "Suspended",
+		uint8(state.Killed):    "Killed",
+		uint8(state.Zombie):    "Zombie",
+		uint8(state.Newbie):    "Newbie",
+		uint8(state.Human):     "Human",
+	}
 )
 
 func ConvertAddress(address common.Address) string {
@@ -49,3 +62,17 @@ func ConvertHash(hash common.Hash) string {
 func ConvertTxType(txType uint16) string {
 	return txTypeNames[txType]
 }
+
+// TxTypeByName returns the tx type by its name returned by ConvertTxType, false if the name is unknown
+func TxTypeByName(name string) (uint16, bool) {
+	for txType, txTypeName := range txTypeNames {
+		if txTypeName == name {
+			return txType, true
+		}
+	}
+	return 0, false
+}
+
+func ConvertIdentityState(identityState uint8) string {
+	return identityStateNames[identityState]
+}
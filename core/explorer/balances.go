@@ -0,0 +1,64 @@
+package explorer
+
+import (
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	BalanceSourceDb   = "db"
+	BalanceSourceNode = "node"
+)
+
+// Balances finds balances at heights by indexed balance updates and falls back to the node state if the updates are not
+// enough, the node holds states of a limited number of recent blocks only
+type Balances struct {
+	db                *Postgres
+	lastIndexedHeight func() uint64
+	stateReadonly     func(height uint64) (*state.StateDB, error)
+	logger            log.Logger
+}
+
+func NewBalances(
+	db *Postgres,
+	lastIndexedHeight func() uint64,
+	stateReadonly func(height uint64) (*state.StateDB, error),
+	logger log.Logger,
+) *Balances {
+	return &Balances{
+		db:                db,
+		lastIndexedHeight: lastIndexedHeight,
+		stateReadonly:     stateReadonly,
+		logger:            logger,
+	}
+}
+
+func (b *Balances) BalanceAt(address common.Address, height uint64) (balance *types.HistoricalBalance, usrErr, err error) {
+	if lastIndexedHeight := b.lastIndexedHeight(); height > lastIndexedHeight {
+		return nil, errors.Errorf("height should not be greater than last indexed height %v", lastIndexedHeight), nil
+	}
+	balance, err = b.db.BalanceAt(conversion.ConvertAddress(address), height)
+	if err != nil {
+		return nil, nil, err
+	}
+	if balance != nil {
+		return balance, nil, nil
+	}
+	st, err := b.stateReadonly(height)
+	if err != nil {
+		b.logger.Debug("Unable to read node state", "height", height, "err", err)
+		return nil, errors.Errorf("balance at height %v is unknown, the node does not hold the state", height), nil
+	}
+	return &types.HistoricalBalance{
+		Address: conversion.ConvertAddress(address),
+		Height:  height,
+		Balance: blockchain.ConvertToFloat(st.GetBalance(address)),
+		Stake:   blockchain.ConvertToFloat(st.GetStakeBalance(address)),
+		Source:  BalanceSourceNode,
+	}, nil, nil
+}
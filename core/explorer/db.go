@@ -0,0 +1,740 @@
+package explorer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	types2 "github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"strconv"
+	"time"
+)
+
+const (
+	blockQuery = `SELECT b.height,
+       b.hash,
+       b.epoch,
+       b."timestamp",
+       (SELECT count(*) FROM transactions WHERE block_height = b.height),
+       coalesce(pa.address, ''),
+       b.is_empty,
+       b.validators_count,
+       coalesce(b.pool_validators_count, 0),
+       b.body_size,
+       b.full_size,
+       b.vrf_proposer_threshold,
+       b.fee_rate,
+       (SELECT array_agg(flag) FROM block_flags WHERE block_height = b.height),
+       b.upgrade,
+       coalesce(oa.address, ''),
+       coalesce(b.used_gas, 0)
+FROM blocks b
+         LEFT JOIN block_proposers bp ON bp.block_height = b.height
+         LEFT JOIN addresses pa ON pa.id = bp.address_id
+         LEFT JOIN addresses oa ON oa.id = b.offline_address_id
+`
+
+	txColumns = `t.id,
+       t.hash,
+       t.type,
+       t.block_height,
+       b."timestamp",
+       fa.address,
+       coalesce(ta.address, ''),
+       t.amount,
+       t.tips,
+       t.max_fee,
+       t.fee,
+       t.size,
+       t.nonce,
+       r.success,
+       r.gas_used,
+       r.gas_cost,
+       coalesce(r.method, ''),
+       coalesce(r.error_msg, '')`
+
+	txJoins = `
+         JOIN blocks b ON b.height = t.block_height
+         JOIN addresses fa ON fa.id = t."from"
+         LEFT JOIN addresses ta ON ta.id = t."to"
+         LEFT JOIN tx_receipts r ON r.tx_id = t.id
+`
+)
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+// Block returns nil if the block is not indexed
+func (p *Postgres) Block(height uint64) (*types.Block, error) {
+	return p.block(blockQuery+`WHERE b.height = $1`, height)
+}
+
+// BlockByHash returns nil if the block is not indexed
+func (p *Postgres) BlockByHash(hash string) (*types.Block, error) {
+	return p.block(blockQuery+`WHERE lower(b.hash) = lower($1)`, hash)
+}
+
+func (p *Postgres) block(query string, arg interface{}) (*types.Block, error) {
+	res := &types.Block{}
+	var timestamp int64
+	var upgrade sql.NullInt64
+	err := p.db.QueryRow(query, arg).Scan(
+		&res.Height,
+		&res.Hash,
+		&res.Epoch,
+		&timestamp,
+		&res.TxCount,
+		&res.Proposer,
+		&res.IsEmpty,
+		&res.ValidatorsCount,
+		&res.PoolValidatorsCount,
+		&res.BodySize,
+		&res.FullSize,
+		&res.VrfProposerThreshold,
+		&res.FeeRate,
+		pq.Array(&res.Flags),
+		&upgrade,
+		&res.OfflineAddress,
+		&res.GasUsed,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Timestamp = time.Unix(timestamp, 0).UTC()
+	if upgrade.Valid {
+		v := uint32(upgrade.Int64)
+		res.Upgrade = &v
+	}
+	return res, nil
+}
+
+// BlockTxs returns transactions of the block in the order they were applied
+func (p *Postgres) BlockTxs(height uint64, count uint64, continuationToken *string) ([]*types.TransactionSummary, *string, error) {
+	const query = `SELECT ` + txColumns + `
+FROM transactions t` + txJoins + `WHERE t.block_height = $1
+  AND ($3::bigint IS NULL OR t.id >= $3)
+ORDER BY t.id
+LIMIT $2`
+	startId, err := parseContinuationToken(continuationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := p.db.Query(query, height, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readTxs(rows, count)
+}
+
+// Transaction returns nil if the transaction is not indexed
+func (p *Postgres) Transaction(hash string) (*types.TransactionDetail, error) {
+	const query = `SELECT ` + txColumns + `,
+       b.epoch,
+       b.hash
+FROM transactions t` + txJoins + `WHERE lower(t.hash) = lower($1)`
+	var epoch uint64
+	var blockHash string
+	_, tx, err := scanTx(p.db.QueryRow(query, hash), &epoch, &blockHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &types.TransactionDetail{
+		Epoch:       epoch,
+		BlockHeight: tx.BlockHeight,
+		BlockHash:   blockHash,
+		Hash:        tx.Hash,
+		Type:        tx.Type,
+		From:        tx.From,
+		To:          tx.To,
+		Amount:      *tx.Amount,
+		Tips:        *tx.Tips,
+		MaxFee:      *tx.MaxFee,
+		Fee:         *tx.Fee,
+		Size:        tx.Size,
+		Nonce:       tx.Nonce,
+		Timestamp:   tx.Timestamp,
+		TxReceipt:   tx.TxReceipt,
+	}, nil
+}
+
+// TransactionContractCall returns the contract address and raw data of the call or deploy contract transaction, nil if
+// the transaction is not indexed or has no receipt, args are empty if the raw transaction is not saved yet
+func (p *Postgres) TransactionContractCall(hash string) (*common.Address, *abi.Call, error) {
+	const query = `SELECT t.id, coalesce(a.address, ''), coalesce(r.method, ''), r.action_result, tr.raw
+FROM transactions t
+         JOIN tx_receipts r ON r.tx_id = t.id
+         LEFT JOIN addresses a ON a.id = r.contract_address_id
+         LEFT JOIN transaction_raws tr ON tr.tx_id = t.id
+WHERE lower(t.hash) = lower($1)`
+	const eventsQuery = `SELECT coalesce(event_name, ''), "data" FROM tx_events WHERE tx_id = $1 ORDER BY idx`
+	var txId uint64
+	var address string
+	var raw []byte
+	call := &abi.Call{}
+	err := p.db.QueryRow(query, hash).Scan(&txId, &address, &call.Method, &call.Result, &raw)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(address) == 0 {
+		return nil, nil, nil
+	}
+	contractAddress := common.HexToAddress(address)
+	if len(raw) > 0 {
+		tx := new(types2.Transaction)
+		if err := tx.FromBytes(raw); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse raw transaction")
+		}
+		if _, args, ok := abi.CallArgs(tx.Type, tx.Payload); ok {
+			call.Args = args
+		}
+	}
+	rows, err := p.db.Query(eventsQuery, txId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		// events emitted by other contracts are saved without their addresses
+		event := &types2.TxEvent{Contract: contractAddress}
+		if err := rows.Scan(&event.EventName, pq.Array(&event.Data)); err != nil {
+			return nil, nil, err
+		}
+		call.Events = append(call.Events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return &contractAddress, call, nil
+}
+
+// ContractCode returns nil if the code of the contract is not introspected, the verification is nil if the source code
+// has not been submitted
+func (p *Postgres) ContractCode(address string) (*types.ContractCode, error) {
+	const query = `SELECT a.address,
+       cc.code_hash,
+       cc.size,
+       cc.module,
+       coalesce(cc.parse_error, ''),
+       (SELECT count(*) - 1 FROM contract_codes WHERE code_hash = cc.code_hash),
+       coalesce(vs.name, ''),
+       coalesce(cv.state_timestamp, 0),
+       coalesce(cv.file_name, ''),
+       coalesce(cv.error_message, '')
+FROM contract_codes cc
+         JOIN addresses a ON a.id = cc.contract_address_id
+         LEFT JOIN contract_verifications cv ON cv.contract_address_id = cc.contract_address_id
+         LEFT JOIN dic_contract_verification_states vs ON vs.id = cv.state
+WHERE cc.contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))`
+	res := &types.ContractCode{}
+	var module []byte
+	var verification types.ContractVerification
+	var stateTimestamp int64
+	err := p.db.QueryRow(query, address).Scan(&res.Address, &res.CodeHash, &res.Size, &module, &res.ParseError,
+		&res.Clones, &verification.State, &stateTimestamp, &verification.FileName, &verification.ErrorMessage)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if module != nil {
+		res.Module = &types.WasmModule{}
+		if err := json.Unmarshal(module, res.Module); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal wasm module")
+		}
+	}
+	if len(verification.State) > 0 {
+		verification.StateTimestamp = time.Unix(stateTimestamp, 0).UTC()
+		res.Verification = &verification
+	}
+	return res, nil
+}
+
+// ContractClones returns other contracts deployed with the same code as the contract starting from the latest one
+func (p *Postgres) ContractClones(address string, count uint64, continuationToken *string) ([]*types.ContractClone, *string, error) {
+	const query = `WITH contract AS (SELECT cc.contract_address_id, cc.code_hash
+                  FROM contract_codes cc
+                  WHERE cc.contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1)))
+SELECT c.tx_id, a.address, t.hash, b."timestamp"
+FROM contract_codes cc
+         JOIN contracts c ON c.contract_address_id = cc.contract_address_id
+         JOIN addresses a ON a.id = cc.contract_address_id
+         JOIN transactions t ON t.id = c.tx_id
+         JOIN blocks b ON b.height = t.block_height
+WHERE cc.code_hash = (SELECT code_hash FROM contract)
+  AND cc.contract_address_id <> (SELECT contract_address_id FROM contract)
+  AND ($3::bigint IS NULL OR c.tx_id <= $3)
+ORDER BY c.tx_id DESC
+LIMIT $2`
+	startId, err := parseContinuationToken(continuationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := p.db.Query(query, address, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.ContractClone
+	var ids []uint64
+	for rows.Next() {
+		item := &types.ContractClone{}
+		var id uint64
+		var timestamp int64
+		if err := rows.Scan(&id, &item.Address, &item.TxHash, &timestamp); err != nil {
+			return nil, nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		res = append(res, item)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		nextContinuationToken = continuationTokenOf(ids[count])
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+// AddressTxs returns transactions sent or received by the address starting from the latest one, all types are returned
+// if txTypes is empty
+func (p *Postgres) AddressTxs(
+	address string,
+	txTypes []uint16,
+	count uint64,
+	continuationToken *string,
+) ([]*types.TransactionSummary, *string, error) {
+	const query = `WITH address AS (SELECT id FROM addresses WHERE lower(address) = lower($1)),
+     ids AS ((SELECT id
+              FROM transactions
+              WHERE "from" = (SELECT id FROM address)
+                AND ($3::smallint[] IS NULL OR type = ANY ($3))
+                AND ($4::bigint IS NULL OR id <= $4)
+              ORDER BY id DESC
+              LIMIT $2)
+             UNION
+             (SELECT id
+              FROM transactions
+              WHERE "to" = (SELECT id FROM address)
+                AND ($3::smallint[] IS NULL OR type = ANY ($3))
+                AND ($4::bigint IS NULL OR id <= $4)
+              ORDER BY id DESC
+              LIMIT $2))
+SELECT ` + txColumns + `
+FROM ids
+         JOIN transactions t ON t.id = ids.id` + txJoins + `ORDER BY t.id DESC
+LIMIT $2`
+	startId, err := parseContinuationToken(continuationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	var typesArray interface{}
+	if len(txTypes) > 0 {
+		values := make([]int64, 0, len(txTypes))
+		for _, txType := range txTypes {
+			values = append(values, int64(txType))
+		}
+		typesArray = pq.Array(values)
+	}
+	rows, err := p.db.Query(query, address, count+1, typesArray, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readTxs(rows, count)
+}
+
+// AddressBalanceUpdates returns balance updates of the address starting from the latest one
+func (p *Postgres) AddressBalanceUpdates(address string, count uint64, continuationToken *string) ([]*types.BalanceUpdate, *string, error) {
+	const query = `SELECT bu.id,
+       bu.block_height,
+       b."timestamp",
+       coalesce(r.name, ''),
+       coalesce(t.hash, ''),
+       coalesce(ca.address, ''),
+       bu.balance_old,
+       bu.stake_old,
+       bu.penalty_old,
+       bu.balance_new,
+       bu.stake_new,
+       bu.penalty_new
+FROM balance_updates bu
+         JOIN blocks b ON b.height = bu.block_height
+         LEFT JOIN dic_balance_update_reasons r ON r.id = bu.reason
+         LEFT JOIN transactions t ON t.id = bu.tx_id
+         LEFT JOIN addresses ca ON ca.id = bu.contract_address_id
+WHERE bu.address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))
+  AND ($3::bigint IS NULL OR bu.id <= $3)
+ORDER BY bu.id DESC
+LIMIT $2`
+	startId, err := parseContinuationToken(continuationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := p.db.Query(query, address, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.BalanceUpdate
+	var ids []uint64
+	for rows.Next() {
+		item := &types.BalanceUpdate{}
+		var id uint64
+		var timestamp int64
+		var penaltyOld, penaltyNew decimal.NullDecimal
+		if err := rows.Scan(&id, &item.BlockHeight, &timestamp, &item.Reason, &item.TxHash, &item.ContractAddress,
+			&item.BalanceOld, &item.StakeOld, &penaltyOld, &item.BalanceNew, &item.StakeNew, &penaltyNew); err != nil {
+			return nil, nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		if penaltyOld.Valid {
+			item.PenaltyOld = &penaltyOld.Decimal
+		}
+		if penaltyNew.Valid {
+			item.PenaltyNew = &penaltyNew.Decimal
+		}
+		res = append(res, item)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		nextContinuationToken = continuationTokenOf(ids[count])
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+// AddressRewards returns validation rewards paid to the address for the epoch
+func (p *Postgres) AddressRewards(address string, epoch uint64) ([]*types.AddressReward, error) {
+	const query = `SELECT coalesce(rt.name, ''), vr.balance, vr.stake
+FROM epoch_identities ei
+         JOIN validation_rewards vr ON vr.ei_address_state_id = ei.address_state_id
+         LEFT JOIN dic_epoch_reward_types rt ON rt.id = vr.type
+WHERE ei.address_id = (SELECT id FROM addresses WHERE lower(address) = lower($1))
+  AND ei.epoch = $2
+ORDER BY vr.type`
+	rows, err := p.db.Query(query, address, epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.AddressReward
+	for rows.Next() {
+		item := &types.AddressReward{}
+		if err := rows.Scan(&item.Type, &item.Balance, &item.Stake); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+// EpochRewards returns nil if the epoch validation has not been indexed yet
+func (p *Postgres) EpochRewards(epoch uint64) (*types.EpochRewards, error) {
+	const query = `SELECT epoch,
+       total,
+       validation,
+       coalesce(staking, 0),
+       coalesce(candidate, 0),
+       flips,
+       coalesce(flips_extra, 0),
+       coalesce(reports, 0),
+       invitations,
+       foundation,
+       zero_wallet
+FROM total_rewards
+WHERE epoch = $1`
+	res := &types.EpochRewards{}
+	err := p.db.QueryRow(query, epoch).Scan(
+		&res.Epoch,
+		&res.Total,
+		&res.Validation,
+		&res.Staking,
+		&res.Candidate,
+		&res.Flips,
+		&res.FlipsExtra,
+		&res.Reports,
+		&res.Invitations,
+		&res.FoundationPayouts,
+		&res.ZeroWalletFund,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Epoch returns nil if the epoch is not indexed, the counters of the current epoch are updated with every block
+func (p *Postgres) Epoch(epoch uint64) (*types.EpochSummary, error) {
+	const query = `SELECT e.epoch,
+       e.validation_time,
+       es.validated_count,
+       es.candidate_count,
+       es.block_count,
+       es.empty_block_count,
+       es.tx_count,
+       es.invite_count,
+       es.flip_count,
+       coalesce(es.reported_flips, 0),
+       es.burnt,
+       es.minted,
+       es.total_balance,
+       es.total_stake,
+       es.min_score_for_invite
+FROM epochs e
+         JOIN epoch_summaries es ON es.epoch = e.epoch
+WHERE e.epoch = $1`
+	res := &types.EpochSummary{}
+	var validationTime int64
+	err := p.db.QueryRow(query, epoch).Scan(
+		&res.Epoch,
+		&validationTime,
+		&res.ValidatedCount,
+		&res.CandidateCount,
+		&res.BlockCount,
+		&res.EmptyBlockCount,
+		&res.TxCount,
+		&res.InviteCount,
+		&res.FlipCount,
+		&res.ReportedFlips,
+		&res.Burnt,
+		&res.Minted,
+		&res.TotalBalance,
+		&res.TotalStake,
+		&res.MinScoreForInvite,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.ValidationTime = time.Unix(validationTime, 0).UTC()
+	return res, nil
+}
+
+// BalanceAt returns the balance and stake of the address at the height by the latest balance checkpoint and balance
+// updates after it, nil if it can not be found by indexed data: the height is below the first indexed block or the
+// address got committee rewards which are aggregated in a single balance update for several blocks
+func (p *Postgres) BalanceAt(address string, height uint64) (*types.HistoricalBalance, error) {
+	tx, err := p.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var firstHeight sql.NullInt64
+	if err := tx.QueryRow(`SELECT min(height) FROM blocks`).Scan(&firstHeight); err != nil {
+		return nil, err
+	}
+	if !firstHeight.Valid || height < uint64(firstHeight.Int64) {
+		return nil, nil
+	}
+
+	res := &types.HistoricalBalance{
+		Address: address,
+		Height:  height,
+		Source:  BalanceSourceDb,
+	}
+	var addressId uint64
+	err = tx.QueryRow(`SELECT id FROM addresses WHERE lower(address) = lower($1)`, address).Scan(&addressId)
+	if err == sql.ErrNoRows {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	const checkpointQuery = `SELECT block_height, balance, stake
+FROM balance_checkpoints
+WHERE address_id = $1
+  AND block_height <= $2
+ORDER BY block_height DESC
+LIMIT 1`
+	var checkpointHeight uint64
+	var checkpointBalance, checkpointStake decimal.Decimal
+	err = tx.QueryRow(checkpointQuery, addressId, height).Scan(&checkpointHeight, &checkpointBalance, &checkpointStake)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	checkpointFound := err == nil
+
+	// committee reward balance updates started before the checkpoint may be extended by blocks after it
+	const prevUpdateQuery = `SELECT last_block_height, balance_new, stake_new
+FROM balance_updates
+WHERE address_id = $1
+  AND block_height <= $2
+  AND coalesce(last_block_height, block_height) > $3
+ORDER BY block_height DESC, id DESC
+LIMIT 1`
+	var lastBlockHeight sql.NullInt64
+	err = tx.QueryRow(prevUpdateQuery, addressId, height, checkpointHeight).Scan(&lastBlockHeight, &res.Balance, &res.Stake)
+	if err == nil {
+		if lastBlockHeight.Valid && height < uint64(lastBlockHeight.Int64) {
+			return nil, nil
+		}
+		return res, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	if checkpointFound {
+		res.Balance, res.Stake = checkpointBalance, checkpointStake
+		return res, nil
+	}
+
+	// balances restored from the node state are not recorded as balance updates, so the balance is taken from the
+	// first update after the height or from the actual balance if there are no such updates
+	const nextUpdateQuery = `SELECT balance_old, stake_old
+FROM balance_updates
+WHERE address_id = $1
+  AND block_height > $2
+ORDER BY block_height, id
+LIMIT 1`
+	err = tx.QueryRow(nextUpdateQuery, addressId, height).Scan(&res.Balance, &res.Stake)
+	if err == nil {
+		return res, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	const actualQuery = `SELECT coalesce(balance, 0), coalesce(stake, 0) FROM balances WHERE address_id = $1`
+	err = tx.QueryRow(actualQuery, addressId).Scan(&res.Balance, &res.Stake)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return res, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTx(rows rowScanner, extraDest ...interface{}) (uint64, *types.TransactionSummary, error) {
+	res := &types.TransactionSummary{}
+	var id uint64
+	var txType uint16
+	var timestamp int64
+	var amount, tips, maxFee, fee decimal.Decimal
+	var success sql.NullBool
+	var gasUsed sql.NullInt64
+	var gasCost decimal.NullDecimal
+	var method, errorMsg string
+	dest := []interface{}{
+		&id,
+		&res.Hash,
+		&txType,
+		&res.BlockHeight,
+		&timestamp,
+		&res.From,
+		&res.To,
+		&amount,
+		&tips,
+		&maxFee,
+		&fee,
+		&res.Size,
+		&res.Nonce,
+		&success,
+		&gasUsed,
+		&gasCost,
+		&method,
+		&errorMsg,
+	}
+	if err := rows.Scan(append(dest, extraDest...)...); err != nil {
+		return 0, nil, err
+	}
+	res.Type = conversion.ConvertTxType(txType)
+	t := time.Unix(timestamp, 0).UTC()
+	res.Timestamp = &t
+	res.Amount = &amount
+	res.Tips = &tips
+	res.MaxFee = &maxFee
+	res.Fee = &fee
+	if success.Valid {
+		res.TxReceipt = &types.TxReceipt{
+			Success:  success.Bool,
+			GasUsed:  uint64(gasUsed.Int64),
+			GasCost:  gasCost.Decimal,
+			Method:   method,
+			ErrorMsg: errorMsg,
+		}
+	}
+	return id, res, nil
+}
+
+func readTxs(rows *sql.Rows, count uint64) ([]*types.TransactionSummary, *string, error) {
+	defer rows.Close()
+	var res []*types.TransactionSummary
+	var ids []uint64
+	for rows.Next() {
+		id, item, err := scanTx(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		res = append(res, item)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		nextContinuationToken = continuationTokenOf(ids[count])
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+func parseContinuationToken(continuationToken *string) (*uint64, error) {
+	if continuationToken == nil {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(*continuationToken, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid continuation token")
+	}
+	return &id, nil
+}
+
+func continuationTokenOf(id uint64) *string {
+	res := strconv.FormatUint(id, 10)
+	return &res
+}
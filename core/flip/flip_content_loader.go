@@ -9,8 +9,10 @@ import (
 	"github.com/idena-network/idena-go/crypto"
 	"github.com/idena-network/idena-go/crypto/ecies"
 	"github.com/idena-network/idena-go/rlp"
+	"github.com/idena-network/idena-indexer/core/health"
 	"github.com/idena-network/idena-indexer/db"
 	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/monitoring"
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 	"golang.org/x/image/draw"
@@ -36,6 +38,7 @@ type ContentLoader struct {
 	attemptsLimit int
 	retryInterval time.Duration
 	logger        log.Logger
+	health        *health.Component
 }
 
 func StartContentLoader(
@@ -45,6 +48,7 @@ func StartContentLoader(
 	retryInterval time.Duration,
 	flipper *flip.Flipper,
 	logger log.Logger,
+	health *health.Component,
 ) {
 	l := &ContentLoader{
 		db:            db,
@@ -53,6 +57,7 @@ func StartContentLoader(
 		retryInterval: retryInterval,
 		flipper:       flipper,
 		logger:        logger,
+		health:        health,
 	}
 	l.initialize()
 }
@@ -66,11 +71,13 @@ func (l *ContentLoader) loop() {
 		flips, err := l.db.GetFlipsToLoadContent(new(big.Int).SetInt64(time.Now().UTC().Unix()), l.batchSize)
 		if err != nil {
 			l.logger.Error(errors.Wrap(err, "Unable to get flip cids").Error())
+			l.health.Fail(err)
 			time.Sleep(getCidsRetryInterval)
 			continue
 		}
 		if len(flips) == 0 {
 			l.logger.Debug("No flips to load")
+			l.health.Ok()
 			time.Sleep(getCidsRetryInterval)
 			continue
 		}
@@ -78,9 +85,11 @@ func (l *ContentLoader) loop() {
 		failedFlips, flipsContent := l.handleFlips(flips)
 		if err = l.db.SaveFlipsContent(failedFlips, flipsContent); err != nil {
 			l.logger.Error(errors.Wrap(err, "Unable to save flips content").Error())
+			l.health.Fail(err)
 			time.Sleep(getCidsRetryInterval)
 			continue
 		}
+		l.health.Ok()
 		l.logger.Debug("Flips content saved")
 	}
 }
@@ -91,6 +100,7 @@ func (l *ContentLoader) handleFlips(flips []*db.FlipToLoadContent) ([]*db.Failed
 	for _, f := range flips {
 		flipContent, err := l.getFlipContent(f)
 		if err != nil {
+			monitoring.FlipContentLoadFailures.Inc()
 			l.logger.Error(errors.
 				Wrapf(err, "unable to get flip content (cid %s, attempt %d)", f.Cid, f.Attempts+1).Error())
 			failedCid := &db.FailedFlipContent{
@@ -1,62 +1,56 @@
 package flip
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/idena-network/idena-go/core/flip"
+	"github.com/idena-network/idena-indexer/core/jobs"
 	"github.com/idena-network/idena-indexer/log"
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
-	"time"
 )
 
-const (
-	requestRetryInterval = time.Minute * 60
-	flipsToRetry         = 50
-)
+const JobTypeFlipSize = "flip-size"
 
 type Loader interface {
 	SubmitToLoad(cidBytes []byte, txHash common.Hash)
 }
 
+// NewLoader registers the flip size job type, flips submitted to load are queued as jobs so that they are retried
+// on failures and kept on restarts
 func NewLoader(
-	getEpoch func() uint64,
-	enabledRetry func() bool,
+	runner *jobs.Runner,
+	conf jobs.TypeConfig,
 	db DbAccessor,
 	flipper *flip.Flipper,
 	logger log.Logger,
 ) Loader {
 	l := &loaderImpl{
-		db:             db,
-		flipper:        flipper,
-		logger:         logger,
-		headers:        make(chan *flipHeader, 10),
-		headersToRetry: make(chan *flipHeader),
-		getEpoch:       getEpoch,
-		enabledRetry:   enabledRetry,
+		runner:  runner,
+		db:      db,
+		flipper: flipper,
+		logger:  logger,
 	}
-	l.initialize()
+	runner.Register(JobTypeFlipSize, conf, l.processJob)
 	return l
 }
 
 type loaderImpl struct {
-	db             DbAccessor
-	flipper        *flip.Flipper
-	headers        chan *flipHeader
-	headersToRetry chan *flipHeader
-	logger         log.Logger
-	getEpoch       func() uint64
-	enabledRetry   func() bool
+	runner  *jobs.Runner
+	db      DbAccessor
+	flipper *flip.Flipper
+	logger  log.Logger
 }
 
 type DbAccessor interface {
-	GetEpochFlipsWithoutSize(epoch uint64, limit int) (cids []string, err error)
 	SaveFlipSize(flipCid string, size int) error
 }
 
 type flipHeader struct {
-	cidBytes []byte
-	txHash   common.Hash
+	CidBytes hexutil.Bytes `json:"cid"`
+	TxHash   common.Hash   `json:"txHash"`
 }
 
 type flipBody struct {
@@ -64,24 +58,21 @@ type flipBody struct {
 	ipfsFlip *flip.IpfsFlip
 }
 
-func (l *loaderImpl) initialize() {
-	go l.loadLoop()
-	go l.processLoop()
-}
-
 func (l *loaderImpl) SubmitToLoad(cidBytes []byte, txHash common.Hash) {
-	l.headers <- &flipHeader{
-		cidBytes: cidBytes,
-		txHash:   txHash,
+	if err := l.runner.Enqueue(JobTypeFlipSize, &flipHeader{
+		CidBytes: cidBytes,
+		TxHash:   txHash,
+	}); err != nil {
+		l.logger.Error(errors.Wrapf(err, "Unable to submit flip to load, tx %s", txHash.Hex()).Error())
 	}
 }
 
 func (l *loaderImpl) load(header *flipHeader) (*flipBody, error) {
-	flipCid, err := cid.Parse(header.cidBytes)
+	flipCid, err := cid.Parse([]byte(header.CidBytes))
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to parse flip cid, tx %s", header.txHash.Hex())
+		return nil, errors.Wrapf(err, "unable to parse flip cid, tx %s", header.TxHash.Hex())
 	}
-	ipfsFlip, err := l.flipper.GetRawFlip(header.cidBytes)
+	ipfsFlip, err := l.flipper.GetRawFlip(header.CidBytes)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to load flip, cid %s", flipCid)
 	}
@@ -91,63 +82,19 @@ func (l *loaderImpl) load(header *flipHeader) (*flipBody, error) {
 	}, nil
 }
 
-func (l *loaderImpl) processLoop() {
-	for {
-		select {
-		case header := <-l.headers:
-			l.processHeader(header)
-			continue
-		default:
-		}
-
-		select {
-		case header := <-l.headers:
-			l.processHeader(header)
-			continue
-		case header := <-l.headersToRetry:
-			l.processHeader(header)
-			continue
-		}
+func (l *loaderImpl) processJob(payload json.RawMessage) error {
+	var header flipHeader
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return errors.Wrap(err, "unable to unmarshal flip header")
 	}
-}
-
-func (l *loaderImpl) processHeader(header *flipHeader) {
 	l.logger.Debug("Start processing flip")
-	body, err := l.load(header)
+	body, err := l.load(&header)
 	if err != nil {
-		l.logger.Error(errors.Wrap(err, "Unable to load flip").Error())
-		return
+		return err
 	}
 	if err := l.db.SaveFlipSize(body.cidStr, len(body.ipfsFlip.PublicPart)+len(body.ipfsFlip.PrivatePart)); err != nil {
-		l.logger.Error(errors.Wrap(err, "Unable to save flip size").Error())
-		return
+		return errors.Wrap(err, "unable to save flip size")
 	}
 	l.logger.Debug(fmt.Sprintf("Processed flip %s", body.cidStr))
-}
-
-func (l *loaderImpl) loadLoop() {
-	for {
-		time.Sleep(requestRetryInterval)
-		if !l.enabledRetry() {
-			l.logger.Debug("Retry is disabled")
-			continue
-		}
-		l.logger.Debug("Getting flips to retry loading")
-		cids, err := l.db.GetEpochFlipsWithoutSize(l.getEpoch(), flipsToRetry)
-		if err != nil {
-			l.logger.Error(errors.Wrap(err, "Unable to get flip from db to retry loading").Error())
-			continue
-		}
-		if len(cids) == 0 {
-			l.logger.Debug("No flips to retry loading")
-			continue
-		}
-		l.logger.Debug(fmt.Sprintf("%d flips to retry loading: %v", len(cids), cids))
-		for _, flipCidStr := range cids {
-			flipCid, _ := cid.Parse(flipCidStr)
-			l.headersToRetry <- &flipHeader{
-				cidBytes: flipCid.Bytes(),
-			}
-		}
-	}
+	return nil
 }
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"github.com/idena-network/idena-indexer/core/types"
+	"time"
+)
+
+type IndexerState interface {
+	LastIndexedHeight() uint64
+	LastBlockProcessedAt() time.Time
+	Paused() bool
+	Restoring() bool
+}
+
+// pingTimeout limits db ping duration so that probes don't hang if the db is not responding
+const pingTimeout = time.Second * 3
+
+type DbPinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Checker struct {
+	indexer        IndexerState
+	nodeHeadHeight func() uint64
+	db             DbPinger
+	components     *Components
+	maxSyncLag     uint64
+	maxBlockAge    time.Duration
+}
+
+// NewChecker creates a checker which considers the indexer ready if it is healthy, not restoring, its sync lag
+// doesn't exceed maxSyncLag and the last block was processed not earlier than maxBlockAge ago
+func NewChecker(
+	indexer IndexerState,
+	nodeHeadHeight func() uint64,
+	db DbPinger,
+	components *Components,
+	maxSyncLag uint64,
+	maxBlockAge time.Duration,
+) *Checker {
+	return &Checker{
+		indexer:        indexer,
+		nodeHeadHeight: nodeHeadHeight,
+		db:             db,
+		components:     components,
+		maxSyncLag:     maxSyncLag,
+		maxBlockAge:    maxBlockAge,
+	}
+}
+
+func (c *Checker) Check() *types.Health {
+	now := time.Now().UTC()
+	res := &types.Health{
+		LastIndexedHeight: c.indexer.LastIndexedHeight(),
+		NodeHeadHeight:    c.nodeHeadHeight(),
+		Paused:            c.indexer.Paused(),
+		Restoring:         c.indexer.Restoring(),
+		Components:        c.components.statuses(now),
+	}
+	if res.NodeHeadHeight > res.LastIndexedHeight {
+		res.SyncLag = res.NodeHeadHeight - res.LastIndexedHeight
+	}
+	blockAgeOk := false
+	if lastBlockProcessedAt := c.indexer.LastBlockProcessedAt(); !lastBlockProcessedAt.IsZero() {
+		age := now.Sub(lastBlockProcessedAt)
+		ageSec := age.Seconds()
+		res.LastBlockProcessedAt = &lastBlockProcessedAt
+		res.LastBlockAgeSec = &ageSec
+		blockAgeOk = age <= c.maxBlockAge
+	}
+
+	res.Postgres = types.ComponentHealth{
+		Name:         "postgres",
+		Healthy:      true,
+		LastActivity: &now,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := c.db.Ping(ctx); err != nil {
+		res.Postgres.Healthy = false
+		res.Postgres.LastActivity = nil
+		res.Postgres.LastError = err.Error()
+		res.Postgres.LastErrorTime = &now
+	}
+
+	res.Healthy = res.Postgres.Healthy
+	for _, component := range res.Components {
+		res.Healthy = res.Healthy && component.Healthy
+	}
+	res.Ready = res.Healthy && !res.Restoring && !res.Paused && res.SyncLag <= c.maxSyncLag && blockAgeOk
+	return res
+}
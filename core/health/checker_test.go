@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testIndexerState struct {
+	lastIndexedHeight    uint64
+	lastBlockProcessedAt time.Time
+	paused               bool
+	restoring            bool
+}
+
+func (s *testIndexerState) LastIndexedHeight() uint64 {
+	return s.lastIndexedHeight
+}
+
+func (s *testIndexerState) LastBlockProcessedAt() time.Time {
+	return s.lastBlockProcessedAt
+}
+
+func (s *testIndexerState) Paused() bool {
+	return s.paused
+}
+
+func (s *testIndexerState) Restoring() bool {
+	return s.restoring
+}
+
+type testDbPinger struct {
+	err error
+}
+
+func (p *testDbPinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func Test_Checker(t *testing.T) {
+	indexerState := &testIndexerState{
+		lastIndexedHeight:    98,
+		lastBlockProcessedAt: time.Now().UTC(),
+	}
+	var nodeHeadHeight uint64 = 100
+	db := &testDbPinger{}
+	components := NewComponents()
+	component := components.Register("test", time.Minute)
+	checker := NewChecker(indexerState, func() uint64 {
+		return nodeHeadHeight
+	}, db, components, 5, time.Minute)
+
+	res := checker.Check()
+	require.True(t, res.Healthy)
+	require.True(t, res.Ready)
+	require.Equal(t, uint64(2), res.SyncLag)
+	require.Len(t, res.Components, 1)
+	require.Nil(t, res.Components[0].LastActivity)
+
+	component.Fail(errors.New("test error"))
+	component.Ok()
+	res = checker.Check()
+	require.True(t, res.Healthy)
+	require.Equal(t, "test error", res.Components[0].LastError)
+	require.NotNil(t, res.Components[0].LastActivity)
+
+	nodeHeadHeight = 110
+	res = checker.Check()
+	require.True(t, res.Healthy)
+	require.False(t, res.Ready)
+
+	nodeHeadHeight = 100
+	indexerState.restoring = true
+	res = checker.Check()
+	require.True(t, res.Healthy)
+	require.False(t, res.Ready)
+
+	indexerState.restoring = false
+	indexerState.lastBlockProcessedAt = time.Now().UTC().Add(-time.Hour)
+	res = checker.Check()
+	require.True(t, res.Healthy)
+	require.False(t, res.Ready)
+
+	indexerState.lastBlockProcessedAt = time.Now().UTC()
+	db.err = errors.New("connection refused")
+	res = checker.Check()
+	require.False(t, res.Healthy)
+	require.False(t, res.Ready)
+	require.Equal(t, "connection refused", res.Postgres.LastError)
+}
+
+func Test_ComponentSilence(t *testing.T) {
+	components := NewComponents()
+	component := components.Register("test", time.Minute)
+	require.True(t, components.statuses(time.Now().UTC())[0].Healthy)
+	require.False(t, components.statuses(time.Now().UTC().Add(time.Minute * 2))[0].Healthy)
+	component.Ok()
+	require.True(t, components.statuses(time.Now().UTC())[0].Healthy)
+
+	var nilComponent *Component
+	nilComponent.Ok()
+	nilComponent.Fail(errors.New("test error"))
+}
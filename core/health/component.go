@@ -0,0 +1,93 @@
+package health
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"sync"
+	"time"
+)
+
+// Component tracks activity of a background loop. Methods of nil *Component do nothing so loops can be created
+// without health tracking.
+type Component struct {
+	name          string
+	maxSilence    time.Duration
+	mutex         sync.RWMutex
+	started       time.Time
+	lastActivity  time.Time
+	lastError     error
+	lastErrorTime time.Time
+}
+
+// Ok marks successful iteration of the loop
+func (c *Component) Ok() {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	c.lastActivity = time.Now().UTC()
+	c.mutex.Unlock()
+}
+
+// Fail records the last error of the loop, the loop becomes unhealthy if it doesn't call Ok during maxSilence
+func (c *Component) Fail(err error) {
+	if c == nil || err == nil {
+		return
+	}
+	c.mutex.Lock()
+	c.lastError = err
+	c.lastErrorTime = time.Now().UTC()
+	c.mutex.Unlock()
+}
+
+func (c *Component) status(now time.Time) types.ComponentHealth {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	res := types.ComponentHealth{
+		Name: c.name,
+	}
+	lastActivity := c.started
+	if !c.lastActivity.IsZero() {
+		v := c.lastActivity
+		res.LastActivity = &v
+		lastActivity = v
+	}
+	res.Healthy = now.Sub(lastActivity) <= c.maxSilence
+	if c.lastError != nil {
+		v := c.lastErrorTime
+		res.LastError = c.lastError.Error()
+		res.LastErrorTime = &v
+	}
+	return res
+}
+
+type Components struct {
+	mutex      sync.RWMutex
+	components []*Component
+}
+
+func NewComponents() *Components {
+	return &Components{}
+}
+
+// Register adds a background loop which is expected to call Ok at least once per maxSilence
+func (c *Components) Register(name string, maxSilence time.Duration) *Component {
+	component := &Component{
+		name:       name,
+		maxSilence: maxSilence,
+		started:    time.Now().UTC(),
+	}
+	c.mutex.Lock()
+	c.components = append(c.components, component)
+	c.mutex.Unlock()
+	return component
+}
+
+func (c *Components) statuses(now time.Time) []types.ComponentHealth {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	res := make([]types.ComponentHealth, 0, len(c.components))
+	for _, component := range c.components {
+		res = append(res, component.status(now))
+	}
+	return res
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/log"
+)
+
+// treeCache keeps the least recently used epoch trees within the max total size, it is not safe for concurrent use
+type treeCache struct {
+	maxSize   int64
+	size      int64
+	items     map[uint64]*list.Element
+	order     *list.List
+	onSizeSet func(size int64)
+	logger    log.Logger
+}
+
+type cachedTree struct {
+	epoch uint64
+	tree  *state.ImmutableTree
+	size  int64
+	// close releases resources of the tree, it is called once the tree is evicted and not used
+	close   func() error
+	refs    int
+	evicted bool
+}
+
+func newTreeCache(maxSize int64, onSizeSet func(size int64), logger log.Logger) *treeCache {
+	return &treeCache{
+		maxSize:   maxSize,
+		items:     make(map[uint64]*list.Element),
+		order:     list.New(),
+		onSizeSet: onSizeSet,
+		logger:    logger,
+	}
+}
+
+// acquire returns the cached tree which should be released after use
+func (c *treeCache) acquire(epoch uint64) (*cachedTree, bool) {
+	element, ok := c.items[epoch]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	item := element.Value.(*cachedTree)
+	item.refs++
+	return item, true
+}
+
+// add caches the acquired tree evicting the least recently used trees to fit the max size, the added tree is kept even
+// if it exceeds the max size itself
+func (c *treeCache) add(item *cachedTree) {
+	item.refs++
+	c.items[item.epoch] = c.order.PushFront(item)
+	c.size += item.size
+	for c.size > c.maxSize && c.order.Len() > 1 {
+		c.evict(c.order.Back())
+	}
+	c.onSizeSet(c.size)
+}
+
+func (c *treeCache) release(item *cachedTree) error {
+	item.refs--
+	if item.refs == 0 && item.evicted && item.close != nil {
+		return item.close()
+	}
+	return nil
+}
+
+func (c *treeCache) evict(element *list.Element) {
+	item := c.order.Remove(element).(*cachedTree)
+	delete(c.items, item.epoch)
+	c.size -= item.size
+	item.evicted = true
+	c.logger.Info(fmt.Sprintf("State for epoch %v evicted", item.epoch))
+	if item.refs == 0 && item.close != nil {
+		if err := item.close(); err != nil {
+			c.logger.Warn(fmt.Sprintf("Failed to close state for epoch %v: %v", item.epoch, err))
+		}
+	}
+}
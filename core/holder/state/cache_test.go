@@ -0,0 +1,70 @@
+package state
+
+import (
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_treeCache(t *testing.T) {
+	var size int64
+	closed := make(map[uint64]bool)
+	cache := newTreeCache(10, func(s int64) {
+		size = s
+	}, log.New())
+	newItem := func(epoch uint64, size int64) *cachedTree {
+		return &cachedTree{
+			epoch: epoch,
+			size:  size,
+			close: func() error {
+				closed[epoch] = true
+				return nil
+			},
+		}
+	}
+
+	item1, item2 := newItem(1, 4), newItem(2, 4)
+	cache.add(item1)
+	cache.add(item2)
+	require.Nil(t, cache.release(item1))
+	require.Nil(t, cache.release(item2))
+	require.Equal(t, int64(8), size)
+
+	// epoch 1 becomes the most recently used one
+	item, ok := cache.acquire(1)
+	require.True(t, ok)
+	require.Nil(t, cache.release(item))
+
+	// epoch 2 is evicted and closed since it is not used
+	item3 := newItem(3, 4)
+	cache.add(item3)
+	require.Nil(t, cache.release(item3))
+	require.Equal(t, int64(8), size)
+	_, ok = cache.acquire(2)
+	require.False(t, ok)
+	require.True(t, closed[2])
+
+	// epoch 1 is evicted while it is used and closed once released
+	item, ok = cache.acquire(1)
+	require.True(t, ok)
+	item3, ok = cache.acquire(3)
+	require.True(t, ok)
+	require.Nil(t, cache.release(item3))
+	item4 := newItem(4, 4)
+	cache.add(item4)
+	_, ok = cache.acquire(1)
+	require.False(t, ok)
+	require.False(t, closed[1])
+	require.Nil(t, cache.release(item))
+	require.True(t, closed[1])
+
+	// the state exceeding the max size is kept alone, used epoch 4 is not closed until released
+	cache.add(newItem(5, 20))
+	require.Equal(t, int64(20), size)
+	require.True(t, closed[3])
+	require.False(t, closed[4])
+	require.Nil(t, cache.release(item4))
+	require.True(t, closed[4])
+	_, ok = cache.acquire(5)
+	require.True(t, ok)
+}
@@ -0,0 +1,177 @@
+package state
+
+import (
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/ipfs/go-cid"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"sort"
+)
+
+func convertIdentity(epoch uint64, address common.Address, identity state.Identity) *types.EpochIdentity {
+	res := &types.EpochIdentity{
+		Address:           conversion.ConvertAddress(address),
+		Epoch:             epoch,
+		State:             conversion.ConvertIdentityState(uint8(identity.State)),
+		Stake:             blockchain.ConvertToFloat(identity.Stake),
+		ReplenishedStake:  convertOptionalAmount(identity.ReplenishedStake()),
+		LockedStake:       convertOptionalAmount(identity.LockedStake()),
+		Invites:           identity.Invites,
+		Birthday:          identity.Birthday,
+		Generation:        identity.Generation,
+		RequiredFlips:     identity.RequiredFlips,
+		QualifiedFlips:    identity.QualifiedFlips,
+		ShortFlipPoints:   identity.GetShortFlipPoints(),
+		Delegatee:         convertOptionalAddress(identity.Delegatee()),
+		UndelegationEpoch: identity.UndelegationEpoch(),
+		Penalty:           convertOptionalAmount(identity.Penalty),
+		PenaltySeconds:    identity.PenaltySeconds(),
+	}
+	res.PendingUndelegation = convertOptionalAddress(identity.PendingUndelegation())
+	for _, flip := range identity.Flips {
+		if c, err := cid.Parse(flip.Cid); err == nil {
+			res.Flips = append(res.Flips, c.String())
+		}
+	}
+	if identity.Inviter != nil {
+		res.Inviter = convertOptionalAddress(&identity.Inviter.Address)
+	}
+	for _, invitee := range identity.Invitees {
+		res.Invitees = append(res.Invitees, conversion.ConvertAddress(invitee.Address))
+	}
+	return res
+}
+
+func convertAccount(epoch uint64, address common.Address, account state.Account) *types.EpochAccount {
+	res := &types.EpochAccount{
+		Address:    conversion.ConvertAddress(address),
+		Epoch:      epoch,
+		Balance:    blockchain.ConvertToFloat(account.Balance),
+		Nonce:      account.Nonce,
+		NonceEpoch: account.Epoch,
+	}
+	if account.Contract != nil {
+		codeHash := conversion.ConvertHash(account.Contract.CodeHash)
+		res.ContractCodeHash = &codeHash
+		stake := blockchain.ConvertToFloat(account.Contract.Stake)
+		res.ContractStake = &stake
+	}
+	return res
+}
+
+func convertOptionalAmount(amount *big.Int) *decimal.Decimal {
+	if amount == nil || amount.Sign() == 0 {
+		return nil
+	}
+	res := blockchain.ConvertToFloat(amount)
+	return &res
+}
+
+func convertOptionalAddress(address *common.Address) *string {
+	if address == nil {
+		return nil
+	}
+	res := conversion.ConvertAddress(*address)
+	return &res
+}
+
+// identitiesDiff returns changes of identities sorted by address, identities missing in one of the states are compared
+// with the empty identity
+func identitiesDiff(from, to map[common.Address]*types.EpochIdentity) []*types.IdentityChange {
+	var res []*types.IdentityChange
+	add := func(address common.Address, prev, next *types.EpochIdentity) {
+		comparePrev, compareNext := prev, next
+		if comparePrev == nil {
+			comparePrev = emptyIdentity()
+		}
+		if compareNext == nil {
+			compareNext = emptyIdentity()
+		}
+		fields := changedIdentityFields(comparePrev, compareNext)
+		if len(fields) == 0 {
+			return
+		}
+		res = append(res, &types.IdentityChange{
+			Address: conversion.ConvertAddress(address),
+			Fields:  fields,
+			Old:     prev,
+			New:     next,
+		})
+	}
+	for address, prev := range from {
+		add(address, prev, to[address])
+	}
+	for address, next := range to {
+		if _, ok := from[address]; !ok {
+			add(address, nil, next)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Address < res[j].Address
+	})
+	return res
+}
+
+func emptyIdentity() *types.EpochIdentity {
+	return &types.EpochIdentity{
+		State: conversion.ConvertIdentityState(uint8(state.Undefined)),
+	}
+}
+
+func changedIdentityFields(prev, next *types.EpochIdentity) []string {
+	var res []string
+	check := func(field string, equal bool) {
+		if !equal {
+			res = append(res, field)
+		}
+	}
+	check("state", prev.State == next.State)
+	check("stake", prev.Stake.Equal(next.Stake))
+	check("replenishedStake", equalAmounts(prev.ReplenishedStake, next.ReplenishedStake))
+	check("lockedStake", equalAmounts(prev.LockedStake, next.LockedStake))
+	check("invites", prev.Invites == next.Invites)
+	check("birthday", prev.Birthday == next.Birthday)
+	check("generation", prev.Generation == next.Generation)
+	check("requiredFlips", prev.RequiredFlips == next.RequiredFlips)
+	check("flips", equalStringSlices(prev.Flips, next.Flips))
+	check("qualifiedFlips", prev.QualifiedFlips == next.QualifiedFlips)
+	check("shortFlipPoints", prev.ShortFlipPoints == next.ShortFlipPoints)
+	check("inviter", equalStrings(prev.Inviter, next.Inviter))
+	check("invitees", equalStringSlices(prev.Invitees, next.Invitees))
+	check("delegatee", equalStrings(prev.Delegatee, next.Delegatee))
+	check("pendingUndelegation", equalStrings(prev.PendingUndelegation, next.PendingUndelegation))
+	check("undelegationEpoch", prev.UndelegationEpoch == next.UndelegationEpoch)
+	check("penalty", equalAmounts(prev.Penalty, next.Penalty))
+	check("penaltySeconds", prev.PenaltySeconds == next.PenaltySeconds)
+	return res
+}
+
+func equalAmounts(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
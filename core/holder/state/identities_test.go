@@ -0,0 +1,59 @@
+package state
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func Test_identitiesDiff(t *testing.T) {
+	unchanged, changed, removed, added := common.Address{0x1}, common.Address{0x2}, common.Address{0x3}, common.Address{0x4}
+	inviter := common.Address{0x5}
+
+	identity := func(address common.Address, identityState state.IdentityState, stake int64, inviter *common.Address) *types.EpochIdentity {
+		return convertIdentity(1, address, state.Identity{
+			State: identityState,
+			Stake: big.NewInt(stake),
+			Inviter: func() *state.Inviter {
+				if inviter == nil {
+					return nil
+				}
+				return &state.Inviter{Address: *inviter}
+			}(),
+		})
+	}
+
+	from := map[common.Address]*types.EpochIdentity{
+		unchanged: identity(unchanged, state.Human, 10, nil),
+		changed:   identity(changed, state.Newbie, 10, nil),
+		removed:   identity(removed, state.Suspended, 0, nil),
+	}
+	to := map[common.Address]*types.EpochIdentity{
+		unchanged: identity(unchanged, state.Human, 10, nil),
+		changed:   identity(changed, state.Verified, 20, &inviter),
+		added:     identity(added, state.Candidate, 0, nil),
+	}
+
+	diff := identitiesDiff(from, to)
+	require.Len(t, diff, 3)
+
+	require.Equal(t, changed.Hex(), diff[0].Address)
+	require.Equal(t, []string{"state", "stake", "inviter"}, diff[0].Fields)
+	require.Equal(t, "Newbie", diff[0].Old.State)
+	require.Equal(t, "Verified", diff[0].New.State)
+
+	require.Equal(t, removed.Hex(), diff[1].Address)
+	require.Equal(t, []string{"state"}, diff[1].Fields)
+	require.NotNil(t, diff[1].Old)
+	require.Nil(t, diff[1].New)
+
+	require.Equal(t, added.Hex(), diff[2].Address)
+	require.Equal(t, []string{"state"}, diff[2].Fields)
+	require.Nil(t, diff[2].Old)
+	require.NotNil(t, diff[2].New)
+
+	require.Empty(t, identitiesDiff(from, from))
+}
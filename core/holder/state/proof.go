@@ -9,7 +9,10 @@ import (
 	"github.com/idena-network/idena-go/common/math"
 	"github.com/idena-network/idena-go/core/state"
 	models "github.com/idena-network/idena-go/protobuf"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
 	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/monitoring"
 	"github.com/mholt/archiver/v3"
 	"github.com/pkg/errors"
 	db "github.com/tendermint/tm-db"
@@ -17,34 +20,204 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
+// Holder reads states at epoch starts from the tree snapshots made by the indexer
 type Holder interface {
 	IdentityWithProof(epoch uint64, address common.Address) (*hexutil.Bytes, error)
+	Identity(epoch uint64, address common.Address, withProof bool) (*types.EpochIdentity, error)
+	Account(epoch uint64, address common.Address, withProof bool) (*types.EpochAccount, error)
+	ContractValue(epoch uint64, address common.Address, key []byte, withProof bool) (*types.EpochContractValue, error)
+	IdentitiesDiff(fromEpoch, toEpoch uint64) ([]*types.IdentityChange, error)
 }
 
-func NewHolder(treeSnapshotDir string, logger log.Logger) Holder {
-	return &holderImpl{
+const (
+	// extractedStateSize is the approximate memory size of the extracted state including the node cache of the tree and
+	// buffers of the db
+	extractedStateSize = 16 << 20
+	// nodeOverhead is the approximate memory size of a tree node excluding its key and value
+	nodeOverhead = 200
+
+	sourceSnapshot  = "snapshot"
+	sourceExtracted = "extracted"
+)
+
+// extractedKey is saved with the checksum of the snapshot once the snapshot is completely extracted
+var extractedKey = []byte("extracted")
+
+// CacheConfig limits memory used by loaded epoch states
+type CacheConfig struct {
+	// MaxSize is the max approximate size in bytes of epoch states kept in memory, least recently used states are
+	// evicted, a single state is kept even if it exceeds the limit
+	MaxSize int64
+	// ExtractDir enables extracting snapshots to LevelDB databases in the dir, extracted states take little memory and
+	// are reopened without parsing the snapshot files
+	ExtractDir string
+}
+
+func NewHolder(treeSnapshotDir string, cacheConf CacheConfig, logger log.Logger) Holder {
+	h := &holderImpl{
 		treeSnapshotDir: treeSnapshotDir,
-		statesByVersion: make(map[uint64]*state.StateDB),
-		logger:          logger,
+		extractDir:      cacheConf.ExtractDir,
+		cache: newTreeCache(cacheConf.MaxSize, func(size int64) {
+			monitoring.EpochStateCacheSize.Set(float64(size))
+		}, logger),
+		logger: logger,
+	}
+	if len(h.extractDir) > 0 {
+		if err := h.removeOutdatedExtractedStates(); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to remove outdated extracted states: %v", err))
+		}
 	}
+	return h
 }
 
 type holderImpl struct {
-	statesByVersion map[uint64]*state.StateDB
-	lock            sync.RWMutex
+	cache           *treeCache
+	lock            sync.Mutex
 	treeSnapshotDir string
+	extractDir      string
 	logger          log.Logger
 }
 
 func (h *holderImpl) IdentityWithProof(epoch uint64, address common.Address) (*hexutil.Bytes, error) {
-	state, err := h.getState(epoch)
+	item, err := h.acquireTree(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(item)
+	tree := item.tree
+	return valueWithProof(tree, state.StateDbKeys.IdentityKey(address))
+}
+
+func (h *holderImpl) Identity(epoch uint64, address common.Address, withProof bool) (*types.EpochIdentity, error) {
+	item, err := h.acquireTree(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(item)
+	tree := item.tree
+	key := state.StateDbKeys.IdentityKey(address)
+	_, data := tree.Get(key)
+	if data == nil {
+		return nil, nil
+	}
+	identity := state.Identity{}
+	if err := identity.FromBytes(data); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode identity %v", address.Hex())
+	}
+	res := convertIdentity(epoch, address, identity)
+	if withProof {
+		if res.Proof, err = valueWithProof(tree, key); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (h *holderImpl) Account(epoch uint64, address common.Address, withProof bool) (*types.EpochAccount, error) {
+	item, err := h.acquireTree(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(item)
+	tree := item.tree
+	key := state.StateDbKeys.AddressKey(address)
+	_, data := tree.Get(key)
+	if data == nil {
+		return nil, nil
+	}
+	account := state.Account{}
+	if err := account.FromBytes(data); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode account %v", address.Hex())
+	}
+	res := convertAccount(epoch, address, account)
+	if withProof {
+		if res.Proof, err = valueWithProof(tree, key); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (h *holderImpl) ContractValue(epoch uint64, address common.Address, key []byte, withProof bool) (*types.EpochContractValue, error) {
+	item, err := h.acquireTree(epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(item)
+	tree := item.tree
+	storeKey := state.StateDbKeys.ContractStoreKey(address, key)
+	_, data := tree.Get(storeKey)
+	if data == nil {
+		return nil, nil
+	}
+	value := hexutil.Bytes(data)
+	res := &types.EpochContractValue{
+		Address: conversion.ConvertAddress(address),
+		Epoch:   epoch,
+		Key:     hexutil.Encode(key),
+		Value:   &value,
+	}
+	if withProof {
+		if res.Proof, err = valueWithProof(tree, storeKey); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (h *holderImpl) IdentitiesDiff(fromEpoch, toEpoch uint64) ([]*types.IdentityChange, error) {
+	fromItem, err := h.acquireTree(fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(fromItem)
+	toItem, err := h.acquireTree(toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	defer h.releaseTree(toItem)
+	fromIdentities, err := readIdentities(fromItem.tree, fromEpoch)
 	if err != nil {
 		return nil, err
 	}
-	valueWithProof, err := state.GetIdentityWithProof(address)
+	toIdentities, err := readIdentities(toItem.tree, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	return identitiesDiff(fromIdentities, toIdentities), nil
+}
+
+func readIdentities(tree *state.ImmutableTree, epoch uint64) (map[common.Address]*types.EpochIdentity, error) {
+	res := make(map[common.Address]*types.EpochIdentity)
+	var err error
+	start, end := state.StateDbKeys.IdentityKey(common.MinAddr), state.StateDbKeys.IdentityKey(common.MaxAddr)
+	tree.IterateRange(start, end, true, func(key []byte, value []byte) bool {
+		if key == nil {
+			return true
+		}
+		address := state.StateDbKeys.IdentityKeyToAddress(key)
+		identity := state.Identity{}
+		if err = identity.FromBytes(value); err != nil {
+			err = errors.Wrapf(err, "unable to decode identity %v", address.Hex())
+			return true
+		}
+		res[address] = convertIdentity(epoch, address, identity)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func valueWithProof(tree *state.ImmutableTree, key []byte) (*hexutil.Bytes, error) {
+	valueWithProof, err := tree.GetWithProof(key)
 	if err != nil {
 		return nil, err
 	}
@@ -55,44 +228,156 @@ func (h *holderImpl) IdentityWithProof(epoch uint64, address common.Address) (*h
 	return &res, nil
 }
 
-func (h *holderImpl) getState(epoch uint64) (*state.StateDB, error) {
-	h.lock.RLock()
-	st, ok := h.statesByVersion[epoch]
-	h.lock.RUnlock()
-	if ok {
-		return st, nil
-	}
-
+func (h *holderImpl) acquireTree(epoch uint64) (*cachedTree, error) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
-	st, ok = h.statesByVersion[epoch]
-	if ok {
-		return st, nil
+	if item, ok := h.cache.acquire(epoch); ok {
+		monitoring.EpochStateCacheRequests.WithLabelValues("hit").Inc()
+		return item, nil
 	}
+	monitoring.EpochStateCacheRequests.WithLabelValues("miss").Inc()
 	h.logger.Info(fmt.Sprintf("Start loading state for epoch %v", epoch))
-	file, err := os.Open(path.Join(h.treeSnapshotDir, fmt.Sprintf("%v.tar", epoch)))
+	startTime := time.Now()
+	item, source, err := h.loadTree(epoch)
 	if err != nil {
 		return nil, err
 	}
+	monitoring.EpochStateLoadDuration.WithLabelValues(source).Observe(time.Since(startTime).Seconds())
+	h.cache.add(item)
+	h.logger.Info(fmt.Sprintf("State for epoch %v loaded from %v", epoch, source))
+	return item, nil
+}
+
+func (h *holderImpl) releaseTree(item *cachedTree) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err := h.cache.release(item); err != nil {
+		h.logger.Warn(fmt.Sprintf("Failed to close state for epoch %v: %v", item.epoch, err))
+	}
+}
+
+func (h *holderImpl) loadTree(epoch uint64) (*cachedTree, string, error) {
+	const height uint64 = math.MaxInt64
+	prefix := state.StateDbKeys.BuildDbPrefix(height)
+	if len(h.extractDir) == 0 {
+		tree, size, err := h.readSnapshot(epoch, db.NewPrefixDB(db.NewMemDB(), prefix), height)
+		if err != nil {
+			return nil, "", err
+		}
+		return &cachedTree{
+			epoch: epoch,
+			tree:  tree.GetImmutable(),
+			size:  size,
+		}, sourceSnapshot, nil
+	}
+
+	ldb, err := db.NewGoLevelDB(strconv.FormatUint(epoch, 10), h.extractDir)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to open extracted state")
+	}
+	pdb := db.NewPrefixDB(ldb, prefix)
+	tree, source, err := h.openExtractedTree(epoch, ldb, pdb, height)
+	if err != nil {
+		ldb.Close()
+		return nil, "", err
+	}
+	return &cachedTree{
+		epoch: epoch,
+		tree:  tree.GetImmutable(),
+		size:  extractedStateSize,
+		close: ldb.Close,
+	}, source, nil
+}
+
+// openExtractedTree extracts the snapshot if it has not been extracted yet or has changed since the extraction
+func (h *holderImpl) openExtractedTree(epoch uint64, ldb db.DB, pdb *db.PrefixDB, height uint64) (*state.MutableTree, string, error) {
+	extracted, err := ldb.Has(extractedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	checksum, err := readChecksum(SnapshotFilePath(h.treeSnapshotDir, epoch))
+	if err != nil {
+		return nil, "", err
+	}
+	if extracted && len(checksum) > 0 {
+		extractedChecksum, err := ldb.Get(extractedKey)
+		if err != nil {
+			return nil, "", err
+		}
+		extracted = checksum == string(extractedChecksum)
+	}
+	if extracted {
+		tree := state.NewMutableTree(pdb)
+		if _, err := tree.LoadVersion(int64(height)); err != nil {
+			return nil, "", errors.Wrap(err, "failed to load extracted state")
+		}
+		return tree, sourceExtracted, nil
+	}
+	if err := ldb.Delete(extractedKey); err != nil {
+		return nil, "", err
+	}
+	common.ClearDb(pdb)
+	tree, _, err := h.readSnapshot(epoch, pdb, height)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := ldb.SetSync(extractedKey, []byte(checksum)); err != nil {
+		return nil, "", err
+	}
+	return tree, sourceSnapshot, nil
+}
+
+func (h *holderImpl) readSnapshot(epoch uint64, pdb *db.PrefixDB, height uint64) (*state.MutableTree, int64, error) {
+	snapshotFilePath := SnapshotFilePath(h.treeSnapshotDir, epoch)
+	file, err := os.Open(snapshotFilePath)
+	if err != nil {
+		return nil, 0, err
+	}
 	defer file.Close()
-	mdb := db.NewMemDB()
-	st, err = state.NewLazy(mdb)
+	verified, err := verifyChecksum(snapshotFilePath, file)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	const height uint64 = math.MaxInt64
-	pdb := db.NewPrefixDB(mdb, state.StateDbKeys.BuildDbPrefix(height))
-	if err := readTreeFrom(pdb, height, file); err != nil {
-		return nil, err
+	if !verified {
+		h.logger.Warn(fmt.Sprintf("No checksum for snapshot of epoch %v, the snapshot is not verified", epoch))
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
 	}
-	st.CommitSnapshot(height, nil)
-	h.statesByVersion[epoch] = st
-	h.logger.Info(fmt.Sprintf("State for epoch %v loaded", epoch))
-	return st, nil
+	return readTreeFrom(pdb, height, file)
 }
 
-func readTreeFrom(pdb *db.PrefixDB, height uint64, from io.Reader) error {
+// removeOutdatedExtractedStates removes extracted states of snapshots removed from the snapshot dir
+func (h *holderImpl) removeOutdatedExtractedStates() error {
+	files, err := ioutil.ReadDir(h.extractDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if !file.IsDir() || !strings.HasSuffix(file.Name(), ".db") {
+			continue
+		}
+		epoch, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), ".db"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(SnapshotFilePath(h.treeSnapshotDir, epoch)); !os.IsNotExist(err) {
+			continue
+		}
+		if err := os.RemoveAll(path.Join(h.extractDir, file.Name())); err != nil {
+			return err
+		}
+		h.logger.Info(fmt.Sprintf("Removed extracted state for epoch %v", epoch))
+	}
+	return nil
+}
+
+// readTreeFrom returns the tree imported from the snapshot and its approximate memory size
+func readTreeFrom(pdb *db.PrefixDB, height uint64, from io.Reader) (*state.MutableTree, int64, error) {
 	tar := archiver.Tar{
 		MkdirAll:               true,
 		OverwriteExisting:      false,
@@ -100,25 +385,26 @@ func readTreeFrom(pdb *db.PrefixDB, height uint64, from io.Reader) error {
 	}
 
 	if err := tar.Open(from, 0); err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	tree := state.NewMutableTree(pdb)
 	importer, err := tree.Importer(int64(height))
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 	defer importer.Close()
 
+	var size int64
 	for file, err := tar.Read(); err == nil; file, err = tar.Read() {
 		if data, err := ioutil.ReadAll(file); err != nil {
 			common.ClearDb(pdb)
-			return err
+			return nil, 0, err
 		} else {
 			sb := new(models.ProtoSnapshotNodes)
 			if err := proto.Unmarshal(data, sb); err != nil {
 				common.ClearDb(pdb)
-				return err
+				return nil, 0, err
 			}
 			for _, node := range sb.Nodes {
 
@@ -134,21 +420,22 @@ func readTreeFrom(pdb *db.PrefixDB, height uint64, from io.Reader) error {
 				}
 
 				importer.Add(exportNode)
+				size += int64(len(exportNode.Key)+len(exportNode.Value)) + nodeOverhead
 			}
 		}
 	}
 	if err := importer.Commit(); err != nil {
 		common.ClearDb(pdb)
-		return err
+		return nil, 0, err
 	}
 
 	if _, err := tree.LoadVersion(int64(height)); err != nil {
 		common.ClearDb(pdb)
-		return err
+		return nil, 0, err
 	}
 	if !tree.ValidateTree() {
 		common.ClearDb(pdb)
-		return errors.New("corrupted tree")
+		return nil, 0, errors.New("corrupted tree")
 	}
-	return nil
+	return tree, size, nil
 }
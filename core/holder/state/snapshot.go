@@ -0,0 +1,123 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	snapshotFileExt = ".tar"
+	checksumFileExt = ".sha256"
+)
+
+// SnapshotFilePath returns the path of the tree snapshot made at the start of the epoch
+func SnapshotFilePath(dir string, epoch uint64) string {
+	return path.Join(dir, fmt.Sprintf("%v%v", epoch, snapshotFileExt))
+}
+
+// ChecksumFilePath returns the path of the file containing hex encoded sha256 of the snapshot file
+func ChecksumFilePath(snapshotFilePath string) string {
+	return snapshotFilePath + checksumFileExt
+}
+
+// NewChecksum returns the hash to write the snapshot file content to while making the snapshot
+func NewChecksum() hash.Hash {
+	return sha256.New()
+}
+
+// WriteChecksum saves the checksum of the snapshot file content written to it
+func WriteChecksum(snapshotFilePath string, checksum hash.Hash) error {
+	return ioutil.WriteFile(ChecksumFilePath(snapshotFilePath), []byte(hex.EncodeToString(checksum.Sum(nil))), 0644)
+}
+
+// RemoveSnapshot removes the snapshot file and its checksum
+func RemoveSnapshot(snapshotFilePath string) error {
+	if err := os.Remove(snapshotFilePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(ChecksumFilePath(snapshotFilePath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RemoveOutdatedSnapshots keeps snapshots of the latest epochs only, keep equal to 0 means all snapshots are kept
+func RemoveOutdatedSnapshots(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	epochs, err := snapshotEpochs(dir)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(epochs)-keep; i++ {
+		if err := RemoveSnapshot(SnapshotFilePath(dir, epochs[i])); err != nil {
+			return errors.Wrapf(err, "failed to remove snapshot of epoch %v", epochs[i])
+		}
+	}
+	return nil
+}
+
+// snapshotEpochs returns ascending epochs of snapshots in the dir
+func snapshotEpochs(dir string) ([]uint64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []uint64
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), snapshotFileExt) {
+			continue
+		}
+		epoch, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), snapshotFileExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		res = append(res, epoch)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res, nil
+}
+
+// readChecksum returns empty string if there is no checksum for the snapshot
+func readChecksum(snapshotFilePath string) (string, error) {
+	checksum, err := ioutil.ReadFile(ChecksumFilePath(snapshotFilePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(checksum)), nil
+}
+
+// verifyChecksum returns false if there is no checksum for the snapshot, the file is read to the end
+func verifyChecksum(snapshotFilePath string, file io.Reader) (bool, error) {
+	expected, err := readChecksum(snapshotFilePath)
+	if err != nil || len(expected) == 0 {
+		return false, err
+	}
+	checksum := NewChecksum()
+	if _, err := io.Copy(checksum, file); err != nil {
+		return false, err
+	}
+	if actual := hex.EncodeToString(checksum.Sum(nil)); actual != expected {
+		return false, errors.Errorf("snapshot checksum mismatch, expected %v, actual %v", expected, actual)
+	}
+	return true, nil
+}
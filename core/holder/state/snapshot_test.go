@@ -0,0 +1,40 @@
+package state
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_RemoveOutdatedSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	for _, epoch := range []uint64{8, 9, 10, 11} {
+		snapshotFilePath := SnapshotFilePath(dir, epoch)
+		require.Nil(t, ioutil.WriteFile(snapshotFilePath, []byte("snapshot"), 0644))
+		checksum := NewChecksum()
+		checksum.Write([]byte("snapshot"))
+		require.Nil(t, WriteChecksum(snapshotFilePath, checksum))
+	}
+	require.Nil(t, ioutil.WriteFile(dir+"/other.tar", nil, 0644))
+
+	require.Nil(t, RemoveOutdatedSnapshots(dir, 2))
+
+	epochs, err := snapshotEpochs(dir)
+	require.Nil(t, err)
+	require.Equal(t, []uint64{10, 11}, epochs)
+	_, err = os.Stat(ChecksumFilePath(SnapshotFilePath(dir, 9)))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(dir + "/other.tar")
+	require.Nil(t, err)
+
+	verified, err := verifyChecksum(SnapshotFilePath(dir, 10), strings.NewReader("snapshot"))
+	require.Nil(t, err)
+	require.True(t, verified)
+	_, err = verifyChecksum(SnapshotFilePath(dir, 10), strings.NewReader("corrupted"))
+	require.NotNil(t, err)
+	verified, err = verifyChecksum(SnapshotFilePath(dir, 12), strings.NewReader("snapshot"))
+	require.Nil(t, err)
+	require.False(t, verified)
+}
@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Db interface {
+	// SaveTypes saves registered job types keeping pause states of the existing ones
+	SaveTypes(jobTypes []string) error
+	SetPaused(jobType string, paused bool) error
+	Enqueue(jobType string, payload []byte, createdAt time.Time) error
+
+	// ClaimJobs returns jobs of the type which are due at now unless the type is paused, increments their attempts and
+	// postpones next attempts until leaseUntil so that the jobs are retried if the indexer stops before they are
+	// completed
+	ClaimJobs(jobType string, now, leaseUntil time.Time, limit int) ([]*Job, error)
+	// CompleteJob deletes the job along with its attempts
+	CompleteJob(id uint64) error
+	RetryJob(id uint64, nextAttemptAt time.Time, lastError string) error
+	MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error
+	SaveAttempt(jobId uint64, attempt *types.JobAttempt) error
+
+	Stats() ([]*types.JobTypeStats, error)
+	DeadLetters(jobType string, limit int) ([]*types.JobDeadLetter, error)
+	Attempts(jobId uint64) ([]*types.JobAttempt, error)
+}
+
+type Job struct {
+	Id       uint64
+	Attempts int
+	Payload  json.RawMessage
+}
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+func (p *Postgres) SaveTypes(jobTypes []string) error {
+	const query = `INSERT INTO job_types ("type") VALUES ($1) ON CONFLICT DO NOTHING`
+	for _, jobType := range jobTypes {
+		if _, err := p.db.Exec(query, jobType); err != nil {
+			return errors.Wrapf(err, "failed to save job type %v", jobType)
+		}
+	}
+	return nil
+}
+
+func (p *Postgres) SetPaused(jobType string, paused bool) error {
+	_, err := p.db.Exec(`UPDATE job_types SET paused = $2 WHERE "type" = $1`, jobType, paused)
+	return err
+}
+
+func (p *Postgres) Enqueue(jobType string, payload []byte, createdAt time.Time) error {
+	const query = `INSERT INTO jobs ("type", payload, next_attempt_at, created_at) VALUES ($1, $2, $3, $3)`
+	_, err := p.db.Exec(query, jobType, payload, createdAt.Unix())
+	return err
+}
+
+func (p *Postgres) ClaimJobs(jobType string, now, leaseUntil time.Time, limit int) ([]*Job, error) {
+	const query = `UPDATE jobs
+SET attempts = attempts + 1, next_attempt_at = $3
+WHERE id IN (SELECT j.id
+             FROM jobs j
+                      JOIN job_types t ON t."type" = j."type"
+             WHERE j."type" = $1
+               AND j.next_attempt_at <= $2
+               AND NOT t.paused
+             ORDER BY j.next_attempt_at, j.id
+             LIMIT $4 FOR UPDATE OF j SKIP LOCKED)
+RETURNING id, attempts, payload`
+	rows, err := p.db.Query(query, jobType, now.Unix(), leaseUntil.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Job
+	for rows.Next() {
+		item := &Job{}
+		var payload []byte
+		if err := rows.Scan(&item.Id, &item.Attempts, &payload); err != nil {
+			return nil, err
+		}
+		item.Payload = json.RawMessage(payload)
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) CompleteJob(id uint64) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM job_attempts WHERE job_id = $1", id); err != nil {
+		return errors.Wrap(err, "failed to delete attempts")
+	}
+	if _, err := tx.Exec("DELETE FROM jobs WHERE id = $1", id); err != nil {
+		return errors.Wrap(err, "failed to delete job")
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) RetryJob(id uint64, nextAttemptAt time.Time, lastError string) error {
+	const query = `UPDATE jobs
+SET next_attempt_at = $2,
+    last_error      = $3
+WHERE id = $1`
+	_, err := p.db.Exec(query, id, nextAttemptAt.Unix(), lastError)
+	return err
+}
+
+func (p *Postgres) MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	const insertQuery = `INSERT INTO job_dead_letters (job_id, "type", payload, attempts, last_error, created_at)
+SELECT id, "type", payload, attempts, $2, $3
+FROM jobs
+WHERE id = $1`
+	if _, err := tx.Exec(insertQuery, id, lastError, createdAt.Unix()); err != nil {
+		return errors.Wrap(err, "failed to insert dead letter")
+	}
+	if _, err := tx.Exec("DELETE FROM jobs WHERE id = $1", id); err != nil {
+		return errors.Wrap(err, "failed to delete job")
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) SaveAttempt(jobId uint64, attempt *types.JobAttempt) error {
+	const query = `INSERT INTO job_attempts (job_id, attempt, started_at, duration_ms, error)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING`
+	_, err := p.db.Exec(query, jobId, attempt.Attempt, attempt.StartedAt.Unix(), attempt.DurationMs, attempt.Error)
+	return err
+}
+
+func (p *Postgres) Stats() ([]*types.JobTypeStats, error) {
+	const query = `SELECT t."type",
+       t.paused,
+       coalesce(j.pending, 0),
+       coalesce(j.retrying, 0),
+       (SELECT count(*) FROM job_dead_letters d WHERE d."type" = t."type"),
+       j.oldest_created_at
+FROM job_types t
+         LEFT JOIN (SELECT "type",
+                           count(*)                          pending,
+                           count(*) FILTER (WHERE attempts > 0) retrying,
+                           min(created_at)                   oldest_created_at
+                    FROM jobs
+                    GROUP BY "type") j ON j."type" = t."type"
+ORDER BY t."type"`
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.JobTypeStats
+	for rows.Next() {
+		item := &types.JobTypeStats{}
+		var oldestCreatedAt sql.NullInt64
+		if err := rows.Scan(&item.Type, &item.Paused, &item.Pending, &item.Retrying, &item.DeadLetters,
+			&oldestCreatedAt); err != nil {
+			return nil, err
+		}
+		if oldestCreatedAt.Valid {
+			t := time.Unix(oldestCreatedAt.Int64, 0).UTC()
+			item.OldestJobAt = &t
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) DeadLetters(jobType string, limit int) ([]*types.JobDeadLetter, error) {
+	const query = `SELECT id, job_id, "type", payload, attempts, coalesce(last_error, ''), created_at
+FROM job_dead_letters
+WHERE "type" = $1
+ORDER BY id DESC
+LIMIT $2`
+	rows, err := p.db.Query(query, jobType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.JobDeadLetter
+	for rows.Next() {
+		item := &types.JobDeadLetter{}
+		var payload []byte
+		var createdAt int64
+		if err := rows.Scan(&item.Id, &item.JobId, &item.Type, &payload, &item.Attempts, &item.LastError,
+			&createdAt); err != nil {
+			return nil, err
+		}
+		item.Payload = json.RawMessage(payload)
+		item.CreatedAt = time.Unix(createdAt, 0).UTC()
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) Attempts(jobId uint64) ([]*types.JobAttempt, error) {
+	const query = `SELECT attempt, started_at, duration_ms, coalesce(error, '')
+FROM job_attempts
+WHERE job_id = $1
+ORDER BY attempt`
+	rows, err := p.db.Query(query, jobId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.JobAttempt
+	for rows.Next() {
+		item := &types.JobAttempt{}
+		var startedAt int64
+		if err := rows.Scan(&item.Attempt, &startedAt, &item.DurationMs, &item.Error); err != nil {
+			return nil, err
+		}
+		item.StartedAt = time.Unix(startedAt, 0).UTC()
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-indexer/core/health"
+	"github.com/idena-network/idena-indexer/core/text"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const maxLastErrorLength = 200
+
+// Handler processes the job payload, the job is retried if the returned error is not nil
+type Handler func(payload json.RawMessage) error
+
+type TypeConfig struct {
+	// Concurrency is the max number of jobs of the type processed at a time
+	Concurrency int
+	// MaxAttempts is the number of failed attempts after which the job is moved to dead letters
+	MaxAttempts int
+	// RetryInterval is the delay before the first retry, it is doubled for each next one up to MaxRetryInterval
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	// Lease is the max time to process the job, the job is claimed again after it if the indexer stops before the job
+	// is completed
+	Lease time.Duration
+}
+
+type jobType struct {
+	name    string
+	conf    TypeConfig
+	handler Handler
+	wake    chan struct{}
+}
+
+// Runner processes jobs queued in the db with per type concurrency limits and exponential backoff retries
+type Runner struct {
+	db           Db
+	pollInterval time.Duration
+	logger       log.Logger
+	health       *health.Component
+	now          func() time.Time
+	types        map[string]*jobType
+	ctx          context.Context
+	cancel       context.CancelFunc
+	loops        sync.WaitGroup
+}
+
+func NewRunner(db Db, pollInterval time.Duration, logger log.Logger, health *health.Component) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		db:           db,
+		pollInterval: pollInterval,
+		logger:       logger,
+		health:       health,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+		types:  make(map[string]*jobType),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register adds the job type handler, it must be called before Start
+func (r *Runner) Register(name string, conf TypeConfig, handler Handler) {
+	if _, ok := r.types[name]; ok {
+		panic(fmt.Sprintf("job type %v is already registered", name))
+	}
+	r.types[name] = &jobType{
+		name:    name,
+		conf:    conf,
+		handler: handler,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (r *Runner) Start() error {
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := r.db.SaveTypes(names); err != nil {
+		return errors.Wrap(err, "failed to save job types")
+	}
+	r.loops.Add(len(r.types))
+	for _, t := range r.types {
+		go r.loop(t)
+	}
+	return nil
+}
+
+// Stop waits for the jobs being processed, the unprocessed ones remain queued
+func (r *Runner) Stop() {
+	r.cancel()
+	r.loops.Wait()
+}
+
+// Enqueue saves the job with the payload marshalled to json
+func (r *Runner) Enqueue(name string, payload interface{}) error {
+	t, ok := r.types[name]
+	if !ok {
+		return errors.Errorf("unknown job type %v", name)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal job payload")
+	}
+	if err := r.db.Enqueue(name, data, r.now()); err != nil {
+		return errors.Wrapf(err, "failed to enqueue %v job", name)
+	}
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (r *Runner) Pause(name string) (usrErr, err error) {
+	return r.setPaused(name, true)
+}
+
+func (r *Runner) Resume(name string) (usrErr, err error) {
+	return r.setPaused(name, false)
+}
+
+func (r *Runner) setPaused(name string, paused bool) (usrErr, err error) {
+	t, ok := r.types[name]
+	if !ok {
+		return errors.Errorf("job type %v not found", name), nil
+	}
+	if err := r.db.SetPaused(name, paused); err != nil {
+		return nil, errors.Wrapf(err, "failed to update job type %v", name)
+	}
+	if !paused {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil, nil
+}
+
+func (r *Runner) Stats() ([]*types.JobTypeStats, error) {
+	return r.db.Stats()
+}
+
+func (r *Runner) DeadLetters(name string, limit int) ([]*types.JobDeadLetter, error) {
+	return r.db.DeadLetters(name, limit)
+}
+
+func (r *Runner) Attempts(jobId uint64) ([]*types.JobAttempt, error) {
+	return r.db.Attempts(jobId)
+}
+
+func (r *Runner) loop(t *jobType) {
+	defer r.loops.Done()
+	for {
+		claimed, err := r.run(t)
+		if err != nil {
+			r.logger.Warn(fmt.Sprintf("failed to run %v jobs: %v", t.name, err))
+			r.health.Fail(err)
+		} else {
+			r.health.Ok()
+		}
+		if err == nil && claimed == t.conf.Concurrency {
+			if r.ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-t.wake:
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+func (r *Runner) run(t *jobType) (int, error) {
+	now := r.now()
+	jobs, err := r.db.ClaimJobs(t.name, now, now.Add(t.conf.Lease), t.conf.Concurrency)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to claim jobs")
+	}
+	attempts := make([]*types.JobAttempt, len(jobs))
+	handleErrs := make([]error, len(jobs))
+	wg := sync.WaitGroup{}
+	wg.Add(len(jobs))
+	for i, job := range jobs {
+		go func(i int, job *Job) {
+			defer wg.Done()
+			attempts[i], handleErrs[i] = r.process(t, job)
+		}(i, job)
+	}
+	wg.Wait()
+	for i, job := range jobs {
+		if err := r.completeJob(t, job, attempts[i], handleErrs[i]); err != nil {
+			return 0, errors.Wrapf(err, "failed to complete %v job %v", t.name, job.Id)
+		}
+	}
+	return len(jobs), nil
+}
+
+func (r *Runner) process(t *jobType, job *Job) (*types.JobAttempt, error) {
+	startedAt := r.now()
+	err := t.handler(job.Payload)
+	attempt := &types.JobAttempt{
+		Attempt:    job.Attempts,
+		StartedAt:  startedAt,
+		DurationMs: r.now().Sub(startedAt).Milliseconds(),
+	}
+	if err != nil {
+		attempt.Error = text.Truncate(err.Error(), maxLastErrorLength)
+	}
+	return attempt, err
+}
+
+func (r *Runner) completeJob(t *jobType, job *Job, attempt *types.JobAttempt, handleErr error) error {
+	if handleErr == nil {
+		return r.db.CompleteJob(job.Id)
+	}
+	if err := r.db.SaveAttempt(job.Id, attempt); err != nil {
+		return errors.Wrap(err, "failed to save attempt")
+	}
+	now := r.now()
+	if job.Attempts >= t.conf.MaxAttempts {
+		r.logger.Warn(fmt.Sprintf("%v job %v moved to dead letters after %v attempts: %v", t.name, job.Id,
+			job.Attempts, attempt.Error))
+		return r.db.MoveToDeadLetters(job.Id, attempt.Error, now)
+	}
+	r.logger.Debug(fmt.Sprintf("failed to process %v job %v, attempt %v: %v", t.name, job.Id, job.Attempts,
+		attempt.Error))
+	return r.db.RetryJob(job.Id, now.Add(retryDelay(t.conf, job.Attempts)), attempt.Error)
+}
+
+func retryDelay(conf TypeConfig, attempts int) time.Duration {
+	delay := conf.RetryInterval
+	for i := 1; i < attempts && delay < conf.MaxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > conf.MaxRetryInterval {
+		delay = conf.MaxRetryInterval
+	}
+	return delay
+}
@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testDb struct {
+	paused      map[string]bool
+	enqueued    []string
+	claimed     []*Job
+	claimLimit  int
+	completed   []uint64
+	retried     map[uint64]time.Time
+	deadLetters []uint64
+	attempts    map[uint64][]*types.JobAttempt
+}
+
+func (db *testDb) SaveTypes(jobTypes []string) error {
+	return nil
+}
+
+func (db *testDb) SetPaused(jobType string, paused bool) error {
+	db.paused[jobType] = paused
+	return nil
+}
+
+func (db *testDb) Enqueue(jobType string, payload []byte, createdAt time.Time) error {
+	db.enqueued = append(db.enqueued, string(payload))
+	return nil
+}
+
+func (db *testDb) ClaimJobs(jobType string, now, leaseUntil time.Time, limit int) ([]*Job, error) {
+	db.claimLimit = limit
+	res := db.claimed
+	db.claimed = nil
+	return res, nil
+}
+
+func (db *testDb) CompleteJob(id uint64) error {
+	db.completed = append(db.completed, id)
+	return nil
+}
+
+func (db *testDb) RetryJob(id uint64, nextAttemptAt time.Time, lastError string) error {
+	db.retried[id] = nextAttemptAt
+	return nil
+}
+
+func (db *testDb) MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error {
+	db.deadLetters = append(db.deadLetters, id)
+	return nil
+}
+
+func (db *testDb) SaveAttempt(jobId uint64, attempt *types.JobAttempt) error {
+	db.attempts[jobId] = append(db.attempts[jobId], attempt)
+	return nil
+}
+
+func (db *testDb) Stats() ([]*types.JobTypeStats, error) {
+	return nil, nil
+}
+
+func (db *testDb) DeadLetters(jobType string, limit int) ([]*types.JobDeadLetter, error) {
+	return nil, nil
+}
+
+func (db *testDb) Attempts(jobId uint64) ([]*types.JobAttempt, error) {
+	return db.attempts[jobId], nil
+}
+
+func Test_RunnerRun(t *testing.T) {
+	jobsDb := &testDb{
+		paused:   make(map[string]bool),
+		retried:  make(map[uint64]time.Time),
+		attempts: make(map[uint64][]*types.JobAttempt),
+	}
+	runner := NewRunner(jobsDb, time.Second, log.New(), nil)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	runner.now = func() time.Time {
+		return now
+	}
+	runner.Register("test", TypeConfig{
+		Concurrency:      5,
+		MaxAttempts:      3,
+		RetryInterval:    time.Second * 10,
+		MaxRetryInterval: time.Second * 30,
+		Lease:            time.Minute,
+	}, func(payload json.RawMessage) error {
+		var value string
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return err
+		}
+		if value == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	require.NotNil(t, runner.Enqueue("unknown", "ok"))
+	require.Nil(t, runner.Enqueue("test", "ok"))
+	require.Equal(t, []string{`"ok"`}, jobsDb.enqueued)
+
+	jobsDb.claimed = []*Job{
+		{Id: 1, Attempts: 1, Payload: json.RawMessage(`"ok"`)},
+		{Id: 2, Attempts: 1, Payload: json.RawMessage(`"fail"`)},
+		{Id: 3, Attempts: 2, Payload: json.RawMessage(`"fail"`)},
+		{Id: 4, Attempts: 3, Payload: json.RawMessage(`"fail"`)},
+	}
+	cnt, err := runner.run(runner.types["test"])
+	require.Nil(t, err)
+	require.Equal(t, 4, cnt)
+	require.Equal(t, 5, jobsDb.claimLimit)
+	require.Equal(t, []uint64{1}, jobsDb.completed)
+	require.Equal(t, map[uint64]time.Time{
+		2: now.Add(time.Second * 10),
+		3: now.Add(time.Second * 20),
+	}, jobsDb.retried)
+	require.Equal(t, []uint64{4}, jobsDb.deadLetters)
+	require.Len(t, jobsDb.attempts, 3)
+	require.Equal(t, 3, jobsDb.attempts[4][0].Attempt)
+	require.Equal(t, "failed", jobsDb.attempts[4][0].Error)
+
+	usrErr, err := runner.Pause("unknown")
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+	usrErr, err = runner.Pause("test")
+	require.Nil(t, err)
+	require.Nil(t, usrErr)
+	require.True(t, jobsDb.paused["test"])
+}
+
+func Test_retryDelay(t *testing.T) {
+	conf := TypeConfig{
+		RetryInterval:    time.Second * 10,
+		MaxRetryInterval: time.Second * 60,
+	}
+	require.Equal(t, time.Second*10, retryDelay(conf, 1))
+	require.Equal(t, time.Second*20, retryDelay(conf, 2))
+	require.Equal(t, time.Second*40, retryDelay(conf, 3))
+	require.Equal(t, time.Second*60, retryDelay(conf, 4))
+	require.Equal(t, time.Second*60, retryDelay(conf, 20))
+}
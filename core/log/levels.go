@@ -0,0 +1,68 @@
+package log
+
+import (
+	"github.com/idena-network/idena-indexer/log"
+	"sync"
+)
+
+// ComponentLevels filters records by levels set for components, records of other components are filtered by the
+// default level
+type ComponentLevels struct {
+	handler    log.Handler
+	mutex      sync.RWMutex
+	defaultLvl log.Lvl
+	levels     map[string]log.Lvl
+}
+
+func NewComponentLevels(defaultLvl log.Lvl, handler log.Handler) *ComponentLevels {
+	return &ComponentLevels{
+		handler:    handler,
+		defaultLvl: defaultLvl,
+		levels:     make(map[string]log.Lvl),
+	}
+}
+
+func (c *ComponentLevels) Log(r *log.Record) error {
+	c.mutex.RLock()
+	lvl, ok := c.levels[component(r.Ctx)]
+	if !ok {
+		lvl = c.defaultLvl
+	}
+	c.mutex.RUnlock()
+	if r.Lvl > lvl {
+		return nil
+	}
+	return c.handler.Log(r)
+}
+
+// SetLevel sets the component level, empty component means the default level
+func (c *ComponentLevels) SetLevel(component string, lvl log.Lvl) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(component) == 0 {
+		c.defaultLvl = lvl
+		return
+	}
+	c.levels[component] = lvl
+}
+
+func (c *ComponentLevels) Levels() (defaultLvl log.Lvl, levels map[string]log.Lvl) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	levels = make(map[string]log.Lvl, len(c.levels))
+	for component, lvl := range c.levels {
+		levels[component] = lvl
+	}
+	return c.defaultLvl, levels
+}
+
+func component(ctx []interface{}) string {
+	for i := 0; i+1 < len(ctx); i += 2 {
+		if key, ok := ctx[i].(string); ok && key == "component" {
+			if value, ok := ctx[i+1].(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
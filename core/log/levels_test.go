@@ -0,0 +1,42 @@
+package log
+
+import (
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testHandler struct {
+	messages []string
+}
+
+func (h *testHandler) Log(r *log.Record) error {
+	h.messages = append(h.messages, r.Msg)
+	return nil
+}
+
+func Test_ComponentLevels(t *testing.T) {
+	handler := &testHandler{}
+	levels := NewComponentLevels(log.LvlInfo, handler)
+	logger := log.New()
+	logger.SetHandler(levels)
+	componentLogger := logger.New("component", "c1")
+
+	logger.Debug("1")
+	logger.Info("2")
+	componentLogger.Debug("3")
+
+	levels.SetLevel("c1", log.LvlDebug)
+	logger.Debug("4")
+	componentLogger.Debug("5")
+
+	levels.SetLevel("", log.LvlWarn)
+	logger.Info("6")
+	componentLogger.Info("7")
+
+	require.Equal(t, []string{"2", "5", "7"}, handler.messages)
+
+	defaultLvl, componentLevels := levels.Levels()
+	require.Equal(t, log.LvlWarn, defaultLvl)
+	require.Equal(t, map[string]log.Lvl{"c1": log.LvlDebug}, componentLevels)
+}
@@ -24,6 +24,13 @@ type Contracts interface {
 	GetAddressContractTxs(address, contractAddress string) ([]db.Transaction, error)
 	ProcessTx(tx *types.Transaction) error
 	RemoveTx(tx *types.Transaction)
+	Size() ContractsSize
+}
+
+type ContractsSize struct {
+	Txs                 int
+	Addresses           int
+	OracleVotingDeploys int
 }
 
 func NewContracts(appState *appstate.AppState, chain *blockchain.Blockchain, nodeConfig *config.Config, logger log.Logger, tokenContractHolder stats.TokenContractHolder) Contracts {
@@ -159,18 +166,22 @@ func (t *addressContractTxs) get(address, contractAddress string) []db.Transacti
 func (c *contractsImpl) startSizeLogging() {
 	for {
 		time.Sleep(time.Minute * 5)
-		var deploys, txs int
-		c.oracleVotingDeploysMutex.RLock()
-		deploys = len(c.oracleVotingDeploys)
-		c.oracleVotingDeploysMutex.RUnlock()
+		size := c.Size()
+		c.logger.Debug(fmt.Sprintf("txs: %v, addresses: %v, deploys: %v", size.Txs, size.Addresses, size.OracleVotingDeploys))
+	}
+}
 
-		c.addressContractTxs.mutex.RLock()
-		txs = len(c.addressContractTxs.contractAddressesByTxHash)
-		addresses := len(c.addressContractTxs.txsByAddressAndContract)
-		c.addressContractTxs.mutex.RUnlock()
+func (c *contractsImpl) Size() ContractsSize {
+	var res ContractsSize
+	c.oracleVotingDeploysMutex.RLock()
+	res.OracleVotingDeploys = len(c.oracleVotingDeploys)
+	c.oracleVotingDeploysMutex.RUnlock()
 
-		c.logger.Debug(fmt.Sprintf("txs: %v, addresses: %v, deploys: %v", txs, addresses, deploys))
-	}
+	c.addressContractTxs.mutex.RLock()
+	res.Txs = len(c.addressContractTxs.contractAddressesByTxHash)
+	res.Addresses = len(c.addressContractTxs.txsByAddressAndContract)
+	c.addressContractTxs.mutex.RUnlock()
+	return res
 }
 
 func (c *contractsImpl) GetOracleVotingContractDeploys(author common.Address) ([]db.OracleVotingContract, error) {
@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"context"
 	"fmt"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common/eventbus"
@@ -24,6 +25,9 @@ type Indexer struct {
 	cache                      *indexerCache
 	log                        log.Logger
 	mutex                      sync.Mutex
+	ctx                        context.Context
+	cancel                     context.CancelFunc
+	loops                      sync.WaitGroup
 }
 
 type indexerCache struct {
@@ -53,7 +57,10 @@ type txWrapper struct {
 }
 
 func NewIndexer(db db.Accessor, log log.Logger) *Indexer {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Indexer{
+		ctx:                        ctx,
+		cancel:                     cancel,
 		db:                         db,
 		flipPrivateKeysPackageChan: make(chan *flipPrivateKeysPackageWrapper, queueSize),
 		flipKeyChan:                make(chan *flipKeyWrapper, queueSize),
@@ -66,6 +73,7 @@ func NewIndexer(db db.Accessor, log log.Logger) *Indexer {
 
 func (indexer *Indexer) Initialize(bus eventbus.Bus) {
 	indexer.subscribe(bus)
+	indexer.loops.Add(5)
 	go indexer.saveDataLoop()
 	go indexer.listenFlipPrivateKeysPackages()
 	go indexer.listenFlipKeys()
@@ -80,7 +88,10 @@ func (indexer *Indexer) subscribe(bus eventbus.Bus) {
 			key:  newFlipKeysPackageEvent.Key,
 			time: time.Now().UTC().Unix(),
 		}
-		indexer.flipPrivateKeysPackageChan <- w
+		select {
+		case indexer.flipPrivateKeysPackageChan <- w:
+		case <-indexer.ctx.Done():
+		}
 	})
 
 	bus.Subscribe(events.NewFlipKeyID, func(e eventbus.Event) {
@@ -89,100 +100,150 @@ func (indexer *Indexer) subscribe(bus eventbus.Bus) {
 			key:  newFlipKeyEvent.Key,
 			time: time.Now().UTC().Unix(),
 		}
-		indexer.flipKeyChan <- w
+		select {
+		case indexer.flipKeyChan <- w:
+		case <-indexer.ctx.Done():
+		}
 	})
 
 	bus.Subscribe(events.NewTxEventID, func(e eventbus.Event) {
 		newTxEvent := e.(*events.NewTxEvent)
-		if newTxEvent.Tx.Type == types.SubmitAnswersHashTx {
-			w := &txWrapper{
-				tx:   newTxEvent.Tx,
-				time: time.Now().UTC().Unix(),
-			}
-			indexer.answerHashTxChan <- w
+		var txChan chan *txWrapper
+		switch newTxEvent.Tx.Type {
+		case types.SubmitAnswersHashTx:
+			txChan = indexer.answerHashTxChan
+		case types.SubmitShortAnswersTx:
+			txChan = indexer.shortAnswersTxChan
+		default:
 			return
 		}
-		if newTxEvent.Tx.Type == types.SubmitShortAnswersTx {
-			w := &txWrapper{
-				tx:   newTxEvent.Tx,
-				time: time.Now().UTC().Unix(),
-			}
-			indexer.shortAnswersTxChan <- w
-			return
+		w := &txWrapper{
+			tx:   newTxEvent.Tx,
+			time: time.Now().UTC().Unix(),
+		}
+		select {
+		case txChan <- w:
+		case <-indexer.ctx.Done():
 		}
 	})
 }
 
 func (indexer *Indexer) listenFlipPrivateKeysPackages() {
+	defer indexer.loops.Done()
 	for {
-		flipPrivateKeysPackage := <-indexer.flipPrivateKeysPackageChan
-		sender, err := types.SenderFlipKeysPackage(flipPrivateKeysPackage.key)
-		if err != nil {
-			indexer.log.Error(errors.Wrapf(err, "Unable to define flip keys package (%v) sender", flipPrivateKeysPackage.key).Error())
-			continue
-		}
-		flipKeyTimestamp := &db.MemPoolActionTimestamp{
-			Address: conversion.ConvertAddress(sender),
-			Epoch:   uint64(flipPrivateKeysPackage.key.Epoch),
-			Time:    flipPrivateKeysPackage.time,
+		select {
+		case item := <-indexer.flipPrivateKeysPackageChan:
+			indexer.handleFlipPrivateKeysPackage(item)
+		case <-indexer.ctx.Done():
+			for len(indexer.flipPrivateKeysPackageChan) > 0 {
+				indexer.handleFlipPrivateKeysPackage(<-indexer.flipPrivateKeysPackageChan)
+			}
+			return
 		}
-		indexer.addFlipPrivateKeysPackageTimestamp(flipKeyTimestamp)
 	}
 }
 
+func (indexer *Indexer) handleFlipPrivateKeysPackage(flipPrivateKeysPackage *flipPrivateKeysPackageWrapper) {
+	sender, err := types.SenderFlipKeysPackage(flipPrivateKeysPackage.key)
+	if err != nil {
+		indexer.log.Error(errors.Wrapf(err, "Unable to define flip keys package (%v) sender", flipPrivateKeysPackage.key).Error())
+		return
+	}
+	flipKeyTimestamp := &db.MemPoolActionTimestamp{
+		Address: conversion.ConvertAddress(sender),
+		Epoch:   uint64(flipPrivateKeysPackage.key.Epoch),
+		Time:    flipPrivateKeysPackage.time,
+	}
+	indexer.addFlipPrivateKeysPackageTimestamp(flipKeyTimestamp)
+}
+
 func (indexer *Indexer) listenFlipKeys() {
+	defer indexer.loops.Done()
 	for {
-		flipKey := <-indexer.flipKeyChan
-		sender, err := types.SenderFlipKey(flipKey.key)
-		if err != nil {
-			indexer.log.Error(errors.Wrapf(err, "Unable to define flip key (%v) sender", flipKey.key).Error())
-			continue
-		}
-		flipKeyTimestamp := &db.MemPoolActionTimestamp{
-			Address: conversion.ConvertAddress(sender),
-			Epoch:   uint64(flipKey.key.Epoch),
-			Time:    flipKey.time,
+		select {
+		case item := <-indexer.flipKeyChan:
+			indexer.handleFlipKey(item)
+		case <-indexer.ctx.Done():
+			for len(indexer.flipKeyChan) > 0 {
+				indexer.handleFlipKey(<-indexer.flipKeyChan)
+			}
+			return
 		}
-		indexer.addFlipKeyTimestamp(flipKeyTimestamp)
 	}
 }
 
+func (indexer *Indexer) handleFlipKey(flipKey *flipKeyWrapper) {
+	sender, err := types.SenderFlipKey(flipKey.key)
+	if err != nil {
+		indexer.log.Error(errors.Wrapf(err, "Unable to define flip key (%v) sender", flipKey.key).Error())
+		return
+	}
+	flipKeyTimestamp := &db.MemPoolActionTimestamp{
+		Address: conversion.ConvertAddress(sender),
+		Epoch:   uint64(flipKey.key.Epoch),
+		Time:    flipKey.time,
+	}
+	indexer.addFlipKeyTimestamp(flipKeyTimestamp)
+}
+
 func (indexer *Indexer) listenAnswersHashTxs() {
+	defer indexer.loops.Done()
 	for {
-		tx := <-indexer.answerHashTxChan
-
-		sender, err := types.Sender(tx.tx)
-		if err != nil {
-			indexer.log.Error(errors.Wrapf(err, "Unable to define tx (%v) sender", tx.tx.Hash().Hex()).Error())
-			continue
-		}
-		txTimestamp := &db.MemPoolActionTimestamp{
-			Address: conversion.ConvertAddress(sender),
-			Epoch:   uint64(tx.tx.Epoch),
-			Time:    tx.time,
+		select {
+		case item := <-indexer.answerHashTxChan:
+			indexer.handleAnswersHashTx(item)
+		case <-indexer.ctx.Done():
+			for len(indexer.answerHashTxChan) > 0 {
+				indexer.handleAnswersHashTx(<-indexer.answerHashTxChan)
+			}
+			return
 		}
-		indexer.addAnswersHashTxTimestamp(txTimestamp)
 	}
 }
 
+func (indexer *Indexer) handleAnswersHashTx(tx *txWrapper) {
+	sender, err := types.Sender(tx.tx)
+	if err != nil {
+		indexer.log.Error(errors.Wrapf(err, "Unable to define tx (%v) sender", tx.tx.Hash().Hex()).Error())
+		return
+	}
+	txTimestamp := &db.MemPoolActionTimestamp{
+		Address: conversion.ConvertAddress(sender),
+		Epoch:   uint64(tx.tx.Epoch),
+		Time:    tx.time,
+	}
+	indexer.addAnswersHashTxTimestamp(txTimestamp)
+}
+
 func (indexer *Indexer) listenShortAnswersTxs() {
+	defer indexer.loops.Done()
 	for {
-		tx := <-indexer.shortAnswersTxChan
-
-		sender, err := types.Sender(tx.tx)
-		if err != nil {
-			indexer.log.Error(errors.Wrapf(err, "Unable to define tx (%v) sender", tx.tx.Hash().Hex()).Error())
-			continue
-		}
-		txTimestamp := &db.MemPoolActionTimestamp{
-			Address: conversion.ConvertAddress(sender),
-			Epoch:   uint64(tx.tx.Epoch),
-			Time:    tx.time,
+		select {
+		case item := <-indexer.shortAnswersTxChan:
+			indexer.handleShortAnswersTx(item)
+		case <-indexer.ctx.Done():
+			for len(indexer.shortAnswersTxChan) > 0 {
+				indexer.handleShortAnswersTx(<-indexer.shortAnswersTxChan)
+			}
+			return
 		}
-		indexer.addShortAnswersTxTimestamp(txTimestamp)
 	}
 }
 
+func (indexer *Indexer) handleShortAnswersTx(tx *txWrapper) {
+	sender, err := types.Sender(tx.tx)
+	if err != nil {
+		indexer.log.Error(errors.Wrapf(err, "Unable to define tx (%v) sender", tx.tx.Hash().Hex()).Error())
+		return
+	}
+	txTimestamp := &db.MemPoolActionTimestamp{
+		Address: conversion.ConvertAddress(sender),
+		Epoch:   uint64(tx.tx.Epoch),
+		Time:    tx.time,
+	}
+	indexer.addShortAnswersTxTimestamp(txTimestamp)
+}
+
 func (indexer *Indexer) addFlipPrivateKeysPackageTimestamp(timestamp *db.MemPoolActionTimestamp) {
 	indexer.cache.flipPrivateKeysPackageTimestampsMutex.Lock()
 	defer indexer.cache.flipPrivateKeysPackageTimestampsMutex.Unlock()
@@ -280,9 +341,16 @@ func (indexer *Indexer) getShortAnswersTxTimestampsToSave() []*db.MemPoolActionT
 }
 
 func (indexer *Indexer) saveDataLoop() {
+	defer indexer.loops.Done()
+	ticker := time.NewTicker(time.Second * 10)
+	defer ticker.Stop()
 	for {
-		time.Sleep(time.Second * 10)
-		indexer.saveData()
+		select {
+		case <-ticker.C:
+			indexer.saveData()
+		case <-indexer.ctx.Done():
+			return
+		}
 	}
 }
 
@@ -330,6 +398,9 @@ func (indexer *Indexer) saveData() {
 	), "d", duration)
 }
 
+// Destroy stops listening to the node events, waits for queued events to be handled and saves all received timestamps
 func (indexer *Indexer) Destroy() {
+	indexer.cancel()
+	indexer.loops.Wait()
 	indexer.saveData()
 }
@@ -0,0 +1,52 @@
+package mempool
+
+import (
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/events"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type memPoolDataAccessor struct {
+	db.Accessor
+	saved []*db.MemPoolData
+}
+
+func (a *memPoolDataAccessor) SaveMemPoolData(data *db.MemPoolData) error {
+	a.saved = append(a.saved, data)
+	return nil
+}
+
+func Test_indexerDestroySavesQueuedTimestamps(t *testing.T) {
+	accessor := &memPoolDataAccessor{}
+	indexer := NewIndexer(accessor, log.New("component", "mpi"))
+	bus := eventbus.New()
+	indexer.Initialize(bus)
+
+	key, _ := crypto.GenerateKey()
+	for i := 0; i < 100; i++ {
+		tx, err := types.SignTx(&types.Transaction{Type: types.SubmitAnswersHashTx, Epoch: 3, AccountNonce: uint32(i)}, key)
+		require.Nil(t, err)
+		bus.Publish(&events.NewTxEvent{Tx: tx})
+	}
+	indexer.Destroy()
+
+	var timestamps []*db.MemPoolActionTimestamp
+	for _, data := range accessor.saved {
+		timestamps = append(timestamps, data.AnswersHashTxTimestamps...)
+	}
+	require.Len(t, timestamps, 100)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey).Hex(), timestamps[0].Address)
+	require.Equal(t, uint64(3), timestamps[0].Epoch)
+
+	// events received after destroy are dropped without blocking
+	tx, err := types.SignTx(&types.Transaction{Type: types.SubmitShortAnswersTx, Epoch: 3}, key)
+	require.Nil(t, err)
+	for i := 0; i < queueSize+1; i++ {
+		bus.Publish(&events.NewTxEvent{Tx: tx})
+	}
+}
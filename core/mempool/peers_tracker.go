@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"context"
 	"github.com/idena-network/idena-indexer/db"
 	"github.com/idena-network/idena-indexer/log"
 	iface "github.com/ipfs/interface-go-ipfs-core"
@@ -17,8 +18,10 @@ type PeersTracker interface {
 	AddPeersData(peersData []iface.ConnectionInfo, time time.Time)
 }
 
-func NewPeersTracker(dbAccessor db.Accessor, logger log.Logger) PeersTracker {
+// NewPeersTracker starts tracking peers count until ctx is done
+func NewPeersTracker(ctx context.Context, dbAccessor db.Accessor, logger log.Logger) PeersTracker {
 	pt := &peersTracker{
+		ctx:            ctx,
 		dbAccessor:     dbAccessor,
 		peersDataQueue: make(chan *peersDataWrapper, 10),
 		peersData:      cache.New(expiration, expiration*2),
@@ -30,6 +33,7 @@ func NewPeersTracker(dbAccessor db.Accessor, logger log.Logger) PeersTracker {
 }
 
 type peersTracker struct {
+	ctx            context.Context
 	dbAccessor     db.Accessor
 	peersDataQueue chan *peersDataWrapper
 	peersData      *cache.Cache
@@ -49,12 +53,20 @@ func (pt *peersTracker) AddPeersData(peersData []iface.ConnectionInfo, timestamp
 		peersData: peersData,
 		timestamp: timestamp,
 	}
-	pt.peersDataQueue <- wrapper
+	select {
+	case pt.peersDataQueue <- wrapper:
+	case <-pt.ctx.Done():
+	}
 }
 
 func (pt *peersTracker) handlePeers() {
 	for {
-		wrapper := <-pt.peersDataQueue
+		var wrapper *peersDataWrapper
+		select {
+		case wrapper = <-pt.peersDataQueue:
+		case <-pt.ctx.Done():
+			return
+		}
 		if len(wrapper.peersData) == 0 {
 			continue
 		}
@@ -65,17 +77,22 @@ func (pt *peersTracker) handlePeers() {
 }
 
 func (pt *peersTracker) track() {
-	time.Sleep(expiration)
+	delay := expiration
 	for {
+		select {
+		case <-time.After(delay):
+		case <-pt.ctx.Done():
+			return
+		}
 		pt.peersData.DeleteExpired()
 		now := time.Now()
 		n := pt.peersData.ItemCount()
 		if err := pt.dbAccessor.SavePeersCount(n, now); err != nil {
 			pt.logger.Warn("Failed to track peers count", "n", n, "err", err)
-			time.Sleep(time.Minute)
+			delay = time.Minute
 		} else {
 			pt.logger.Debug("Tracked peers count", "n", n)
-			time.Sleep(period)
+			delay = period
 		}
 	}
 }
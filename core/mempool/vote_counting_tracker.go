@@ -1,15 +1,18 @@
 package mempool
 
 import (
+	"context"
 	mapset "github.com/deckarep/golang-set"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/core/validators"
 	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-indexer/core/health"
 	"github.com/idena-network/idena-indexer/core/stats"
 	"github.com/idena-network/idena-indexer/db"
 	"github.com/idena-network/idena-indexer/log"
 	"github.com/pkg/errors"
+	"sync"
 	"time"
 )
 
@@ -22,7 +25,9 @@ type VoteCountingTracker interface {
 	SubmitBlockProposalEvent(e *stats.BlockProposalEvent)
 }
 
-func NewVoteCountingTracker(dbAccessor db.Accessor, logger log.Logger) VoteCountingTracker {
+// NewVoteCountingTracker starts saving submitted events until ctx is done, events queued by then are saved before wg
+// is released
+func NewVoteCountingTracker(ctx context.Context, wg *sync.WaitGroup, dbAccessor db.Accessor, logger log.Logger, health *health.Component) VoteCountingTracker {
 	res := &voteCountingTracker{
 		dbAccessor:        dbAccessor,
 		countingQueue:     make(chan *voteCountingResultEventWrapper, 100),
@@ -30,8 +35,13 @@ func NewVoteCountingTracker(dbAccessor db.Accessor, logger log.Logger) VoteCount
 		proofQueue:        make(chan *proofProposalEventWrapper, 1000),
 		blockQueue:        make(chan *stats.BlockProposalEvent, 1000),
 		logger:            logger,
+		health:            health,
 	}
-	go res.track()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res.track(ctx)
+	}()
 	return res
 }
 
@@ -42,6 +52,7 @@ type voteCountingTracker struct {
 	proofQueue        chan *proofProposalEventWrapper
 	blockQueue        chan *stats.BlockProposalEvent
 	logger            log.Logger
+	health            *health.Component
 }
 
 type voteCountingStepResultEventWrapper struct {
@@ -153,8 +164,9 @@ func (t *voteCountingTracker) SubmitBlockProposalEvent(e *stats.BlockProposalEve
 	}
 }
 
-func (t *voteCountingTracker) track() {
+func (t *voteCountingTracker) track(ctx context.Context) {
 	deleteOldDataTicker := time.NewTicker(time.Minute)
+	defer deleteOldDataTicker.Stop()
 	for {
 		select {
 		case countingStep := <-t.countingStepQueue:
@@ -167,10 +179,28 @@ func (t *voteCountingTracker) track() {
 			t.handleBlockProposal(blockProposal)
 		case <-deleteOldDataTicker.C:
 			t.deleteOldData()
+		case <-ctx.Done():
+			t.saveQueued()
+			return
 		}
 	}
 }
 
+func (t *voteCountingTracker) saveQueued() {
+	for len(t.countingStepQueue) > 0 {
+		t.handleCountingStep(<-t.countingStepQueue)
+	}
+	for len(t.countingQueue) > 0 {
+		t.handleCounting(<-t.countingQueue)
+	}
+	for len(t.proofQueue) > 0 {
+		t.handleProofProposal(<-t.proofQueue)
+	}
+	for len(t.blockQueue) > 0 {
+		t.handleBlockProposal(<-t.blockQueue)
+	}
+}
+
 func (t *voteCountingTracker) handleCountingStep(countingStep *voteCountingStepResultEventWrapper) {
 	err := t.dbAccessor.SaveVoteCountingStepResult(convertVoteCountingStepResultEventWrapper(countingStep))
 	for err != nil {
@@ -323,6 +353,9 @@ func convertBlockProposalEvent(value *stats.BlockProposalEvent) *db.BlockProposa
 func (t *voteCountingTracker) deleteOldData() {
 	if err := t.dbAccessor.DeleteVoteCountingOldData(); err != nil {
 		t.logger.Error("Failed to delete vote counting old data", "err", err)
+		t.health.Fail(err)
+		return
 	}
+	t.health.Ok()
 	t.logger.Trace("Vote counting old data deleted")
 }
@@ -0,0 +1,96 @@
+package reorg
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// Postgres reads the history of indexer db resets recorded by the indexer
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+// Reorgs returns reorgs starting from the latest one
+func (p *Postgres) Reorgs(count uint64, continuationToken *string) ([]*types.Reorg, *string, error) {
+	const query = `SELECT id, "timestamp", reset_height, old_tip_height, coalesce(old_tip_hash, ''), new_tip_hash, depth,
+       removed_txs, removed_balance_updates, removed_rewards
+FROM reorg_history
+WHERE $2::bigint IS NULL OR id <= $2
+ORDER BY id DESC
+LIMIT $1`
+	var startId *uint64
+	if continuationToken != nil {
+		id, err := strconv.ParseUint(*continuationToken, 10, 64)
+		if err != nil {
+			return nil, nil, errors.New("invalid continuation token")
+		}
+		startId = &id
+	}
+	rows, err := p.db.Query(query, count+1, startId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.Reorg
+	for rows.Next() {
+		item := &types.Reorg{}
+		var timestamp int64
+		if err := rows.Scan(&item.Id, &timestamp, &item.ResetHeight, &item.OldTipHeight, &item.OldTipHash,
+			&item.NewTipHash, &item.Depth, &item.RemovedTxs, &item.RemovedBalanceUpdates, &item.RemovedRewards); err != nil {
+			return nil, nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		res = append(res, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		t := strconv.FormatUint(res[count].Id, 10)
+		nextContinuationToken = &t
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+// ReorgTxs returns transactions removed by the reorg, a transaction included again by later blocks has the height
+// of the block
+func (p *Postgres) ReorgTxs(id uint64) ([]*types.ReorgTx, error) {
+	const query = `SELECT hash, reincluded_block_height FROM reorg_history_txs WHERE reorg_id = $1 ORDER BY hash`
+	rows, err := p.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.ReorgTx
+	for rows.Next() {
+		item := &types.ReorgTx{}
+		var reincludedBlockHeight sql.NullInt64
+		if err := rows.Scan(&item.Hash, &reincludedBlockHeight); err != nil {
+			return nil, err
+		}
+		if reincludedBlockHeight.Valid {
+			height := uint64(reincludedBlockHeight.Int64)
+			item.ReincludedBlockHeight = &height
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"crypto/subtle"
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/core/admin"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+type adminRouterInitializer struct {
+	admin  *admin.Admin
+	token  string
+	logger log.Logger
+}
+
+// NewAdminRouterInitializer creates router for operator actions, requests should have the token in the Authorization
+// header, the router is expected to be served on a separate port which is not exposed publicly
+func NewAdminRouterInitializer(admin *admin.Admin, token string, logger log.Logger) RouterInitializer {
+	return &adminRouterInitializer{
+		admin:  admin,
+		token:  token,
+		logger: logger,
+	}
+}
+
+func (ri *adminRouterInitializer) InitRouter(router *mux.Router) {
+	router.Use(ri.authenticate)
+
+	router.Path(strings.ToLower("/Indexing/Pause")).Methods(http.MethodPost).HandlerFunc(ri.pause)
+	router.Path(strings.ToLower("/Indexing/Resume")).Methods(http.MethodPost).HandlerFunc(ri.resume)
+	router.Path(strings.ToLower("/Indexing/ResetTo")).
+		Queries("height", "{height}").
+		Methods(http.MethodPost).
+		HandlerFunc(ri.resetTo)
+	router.Path(strings.ToLower("/Indexing/Restore")).Methods(http.MethodPost).HandlerFunc(ri.restore)
+	router.Path(strings.ToLower("/Indexing/State")).Methods(http.MethodGet).HandlerFunc(ri.state)
+
+	router.Path(strings.ToLower("/FlipsContent/RequeueFailed")).
+		Methods(http.MethodPost).
+		HandlerFunc(ri.requeueFailedFlipsContent)
+	router.Path(strings.ToLower("/Data/Refresh")).
+		Queries("name", "{name}").
+		Methods(http.MethodPost).
+		HandlerFunc(ri.refreshData)
+	router.Path(strings.ToLower("/Contract/{address}/Reverify")).Methods(http.MethodPost).HandlerFunc(ri.reverifyContract)
+	router.Path(strings.ToLower("/Contracts/ReverifyFailed")).
+		Methods(http.MethodPost).
+		HandlerFunc(ri.reverifyFailedContracts)
+	router.Path(strings.ToLower("/Contract/{address}/Abi")).Methods(http.MethodPost).HandlerFunc(ri.uploadContractAbi)
+	router.Path(strings.ToLower("/Contract/{address}/Abi")).Methods(http.MethodDelete).HandlerFunc(ri.deleteContractAbi)
+
+	router.Path(strings.ToLower("/LogLevels")).Methods(http.MethodGet).HandlerFunc(ri.logLevels)
+	router.Path(strings.ToLower("/LogLevels")).
+		Queries("level", "{level}").
+		Methods(http.MethodPost).
+		HandlerFunc(ri.setLogLevel)
+
+	router.Path(strings.ToLower("/Actions")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.actions)
+}
+
+func (ri *adminRouterInitializer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, bearerPrefix)), []byte(ri.token)) != 1 {
+			ri.logger.Warn("Unauthorized admin request", "url", r.URL, "from", GetIP(r))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			WriteResponseWithUserErr(w, nil, errors.New("unauthorized"), nil, ri.logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ri *adminRouterInitializer) pause(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.admin.Pause(GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) resume(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.admin.Resume(GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) resetTo(w http.ResponseWriter, r *http.Request) {
+	height, err := ReadUintUrlValue(r.Form, "height")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	usrErr, err := ri.admin.ResetTo(height, GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) restore(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.admin.Restore(GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) state(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.admin.DumpState(GetIP(r))
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) requeueFailedFlipsContent(w http.ResponseWriter, r *http.Request) {
+	resp, usrErr, err := ri.admin.RequeueFailedFlipsContent(GetIP(r))
+	WriteResponseWithUserErr(w, resp, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) refreshData(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.admin.RefreshData(r.Form.Get("name"), GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) reverifyContract(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !common.IsHexAddress(address) {
+		WriteResponseWithUserErr(w, nil, errors.Errorf("wrong address %v", address), nil, ri.logger)
+		return
+	}
+	usrErr, err := ri.admin.ReverifyContract(common.HexToAddress(address), GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) reverifyFailedContracts(w http.ResponseWriter, r *http.Request) {
+	resp, usrErr, err := ri.admin.ReverifyFailedContracts(GetIP(r))
+	WriteResponseWithUserErr(w, resp, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) uploadContractAbi(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !common.IsHexAddress(address) {
+		WriteResponseWithUserErr(w, nil, errors.Errorf("wrong address %v", address), nil, ri.logger)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, abi.MaxAbiSize+1))
+	if err != nil {
+		WriteResponse(w, nil, errors.Wrap(err, "failed to read request data"), ri.logger)
+		return
+	}
+	usrErr, err := ri.admin.UploadContractAbi(common.HexToAddress(address), data, GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) deleteContractAbi(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !common.IsHexAddress(address) {
+		WriteResponseWithUserErr(w, nil, errors.Errorf("wrong address %v", address), nil, ri.logger)
+		return
+	}
+	usrErr, err := ri.admin.DeleteContractAbi(common.HexToAddress(address), GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) logLevels(w http.ResponseWriter, r *http.Request) {
+	WriteResponse(w, ri.admin.LogLevels(), nil, ri.logger)
+}
+
+func (ri *adminRouterInitializer) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.admin.SetLogLevel(r.Form.Get("component"), r.Form.Get("level"), GetIP(r))
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *adminRouterInitializer) actions(w http.ResponseWriter, r *http.Request) {
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.admin.Actions(count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
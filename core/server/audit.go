@@ -0,0 +1,51 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/audit"
+	"github.com/idena-network/idena-indexer/log"
+	"net/http"
+	"strings"
+)
+
+type auditRouterInitializer struct {
+	db     *audit.Postgres
+	logger log.Logger
+}
+
+func NewAuditRouterInitializer(db *audit.Postgres, logger log.Logger) RouterInitializer {
+	return &auditRouterInitializer{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (ri *auditRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Audit/Runs")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.runs)
+	router.Path(strings.ToLower("/Audit/Runs/{id:[0-9]+}/Discrepancies")).
+		Methods(http.MethodGet).
+		HandlerFunc(ri.discrepancies)
+}
+
+func (ri *auditRouterInitializer) runs(w http.ResponseWriter, r *http.Request) {
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.db.Runs(count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *auditRouterInitializer) discrepancies(w http.ResponseWriter, r *http.Request) {
+	id, err := ReadUint(mux.Vars(r), "id")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.Discrepancies(id)
+	WriteResponse(w, resp, err, ri.logger)
+}
@@ -130,6 +130,19 @@ func ReadUintUrlValue(params url.Values, name string) (uint64, error) {
 	return value, nil
 }
 
+// ReadBoolUrlValue returns false if the param is absent
+func ReadBoolUrlValue(params url.Values, name string) (bool, error) {
+	v := params.Get(name)
+	if len(v) == 0 {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("wrong value %s=%v", name, v))
+	}
+	return value, nil
+}
+
 func GetIP(r *http.Request) string {
 	header := r.Header.Get("X-Forwarded-For")
 	if len(header) > 0 {
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/explorer"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+type explorerRouterInitializer struct {
+	db          *explorer.Postgres
+	balances    *explorer.Balances
+	abiRegistry *abi.Registry
+	logger      log.Logger
+}
+
+// NewExplorerRouterInitializer creates router for indexed blocks, transactions, balance updates, rewards, epochs and
+// code of wasm contracts, contract calls of transactions are decoded by the ABI registry
+func NewExplorerRouterInitializer(
+	db *explorer.Postgres,
+	balances *explorer.Balances,
+	abiRegistry *abi.Registry,
+	logger log.Logger,
+) RouterInitializer {
+	return &explorerRouterInitializer{
+		db:          db,
+		balances:    balances,
+		abiRegistry: abiRegistry,
+		logger:      logger,
+	}
+}
+
+func (ri *explorerRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Block/{height:[0-9]+}")).Methods(http.MethodGet).HandlerFunc(ri.block)
+	router.Path(strings.ToLower("/Block/{hash:0x[0-9a-fA-F]{64}}")).Methods(http.MethodGet).HandlerFunc(ri.blockByHash)
+	router.Path(strings.ToLower("/Block/{height:[0-9]+}/Txs")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.blockTxs)
+
+	router.Path(strings.ToLower("/Transaction/{hash:0x[0-9a-fA-F]{64}}")).Methods(http.MethodGet).HandlerFunc(ri.transaction)
+
+	router.Path(strings.ToLower("/Address/{address}/Txs")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.addressTxs)
+	router.Path(strings.ToLower("/Address/{address}/BalanceUpdates")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.addressBalanceUpdates)
+	router.Path(strings.ToLower("/Address/{address}/Balance")).
+		Queries("height", "{height}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.addressBalance)
+	router.Path(strings.ToLower("/Address/{address}/Epoch/{epoch:[0-9]+}/Rewards")).
+		Methods(http.MethodGet).
+		HandlerFunc(ri.addressRewards)
+
+	router.Path(strings.ToLower("/Contract/{address}/Code")).Methods(http.MethodGet).HandlerFunc(ri.contractCode)
+	router.Path(strings.ToLower("/Contract/{address}/Clones")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.contractClones)
+
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}")).Methods(http.MethodGet).HandlerFunc(ri.epoch)
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}/Rewards")).Methods(http.MethodGet).HandlerFunc(ri.epochRewards)
+}
+
+func (ri *explorerRouterInitializer) block(w http.ResponseWriter, r *http.Request) {
+	height, err := ReadUint(mux.Vars(r), "height")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.Block(height)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) blockByHash(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.db.BlockByHash(mux.Vars(r)["hash"])
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) blockTxs(w http.ResponseWriter, r *http.Request) {
+	height, err := ReadUint(mux.Vars(r), "height")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.db.BlockTxs(height, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) transaction(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["hash"]
+	resp, err := ri.db.Transaction(hash)
+	if err == nil && resp != nil && resp.TxReceipt != nil {
+		resp.Decoded, err = ri.decodeContractCall(hash)
+	}
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) decodeContractCall(hash string) (*types.DecodedContractCall, error) {
+	contractAddress, call, err := ri.db.TransactionContractCall(hash)
+	if err != nil || call == nil {
+		return nil, err
+	}
+	return ri.abiRegistry.Decode(*contractAddress, call)
+}
+
+// addressTxs accepts optional comma separated tx type names in the types param, e.g. types=SendTx,CallContract
+func (ri *explorerRouterInitializer) addressTxs(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	var txTypes []uint16
+	if v := r.Form.Get("types"); len(v) > 0 {
+		for _, name := range strings.Split(v, ",") {
+			txType, ok := conversion.TxTypeByName(strings.TrimSpace(name))
+			if !ok {
+				WriteErrorResponse(w, errors.Errorf("unknown tx type %v", name), ri.logger)
+				return
+			}
+			txTypes = append(txTypes, txType)
+		}
+	}
+	resp, nextContinuationToken, err := ri.db.AddressTxs(address, txTypes, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) addressBalanceUpdates(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.db.AddressBalanceUpdates(address, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) addressBalance(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	height, err := ReadUintUrlValue(r.Form, "height")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, usrErr, err := ri.balances.BalanceAt(common.HexToAddress(address), height)
+	WriteResponseWithUserErr(w, resp, usrErr, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) addressRewards(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.AddressRewards(address, epoch)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) contractCode(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.ContractCode(address)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) contractClones(w http.ResponseWriter, r *http.Request) {
+	address, err := readAddress(mux.Vars(r))
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.db.ContractClones(address, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) epoch(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.Epoch(epoch)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *explorerRouterInitializer) epochRewards(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.EpochRewards(epoch)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func readAddress(vars map[string]string) (string, error) {
+	address := vars["address"]
+	if !common.IsHexAddress(address) {
+		return "", errors.Errorf("wrong address %v", address)
+	}
+	return address, nil
+}
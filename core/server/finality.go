@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"net/http"
+	"strings"
+)
+
+type finalityRouterInitializer struct {
+	lastIndexedHeight func() uint64
+	confirmations     uint64
+	logger            log.Logger
+}
+
+func NewFinalityRouterInitializer(lastIndexedHeight func() uint64, confirmations uint64, logger log.Logger) RouterInitializer {
+	return &finalityRouterInitializer{
+		lastIndexedHeight: lastIndexedHeight,
+		confirmations:     confirmations,
+		logger:            logger,
+	}
+}
+
+func (ri *finalityRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Finality")).Methods(http.MethodGet).HandlerFunc(ri.finality)
+}
+
+func (ri *finalityRouterInitializer) finality(w http.ResponseWriter, r *http.Request) {
+	resp := types.Finality{
+		LastIndexedHeight: ri.lastIndexedHeight(),
+		Confirmations:     ri.confirmations,
+	}
+	if resp.LastIndexedHeight > ri.confirmations {
+		resp.FinalizedHeight = resp.LastIndexedHeight - ri.confirmations
+	}
+	WriteResponse(w, resp, nil, ri.logger)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/health"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"net/http"
+	"strings"
+)
+
+type healthRouterInitializer struct {
+	checker *health.Checker
+	logger  log.Logger
+}
+
+func NewHealthRouterInitializer(checker *health.Checker, logger log.Logger) RouterInitializer {
+	return &healthRouterInitializer{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+func (ri *healthRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Health")).HandlerFunc(ri.health)
+	router.Path(strings.ToLower("/Ready")).HandlerFunc(ri.ready)
+}
+
+func (ri *healthRouterInitializer) health(w http.ResponseWriter, r *http.Request) {
+	resp := ri.checker.Check()
+	ri.write(w, resp, resp.Healthy)
+}
+
+func (ri *healthRouterInitializer) ready(w http.ResponseWriter, r *http.Request) {
+	resp := ri.checker.Check()
+	ri.write(w, resp, resp.Ready)
+}
+
+func (ri *healthRouterInitializer) write(w http.ResponseWriter, resp *types.Health, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		ri.logger.Error(fmt.Sprintf("Unable to write health response: %v", err))
+	}
+}
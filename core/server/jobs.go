@@ -0,0 +1,72 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/jobs"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+type jobsRouterInitializer struct {
+	runner *jobs.Runner
+	logger log.Logger
+}
+
+func NewJobsRouterInitializer(runner *jobs.Runner, logger log.Logger) RouterInitializer {
+	return &jobsRouterInitializer{
+		runner: runner,
+		logger: logger,
+	}
+}
+
+func (ri *jobsRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Jobs")).Methods(http.MethodGet).HandlerFunc(ri.stats)
+	router.Path(strings.ToLower("/Jobs/{id:[0-9]+}/Attempts")).Methods(http.MethodGet).HandlerFunc(ri.attempts)
+	router.Path(strings.ToLower("/Jobs/{type:[a-z-]+}/DeadLetters")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.deadLetters)
+	router.Path(strings.ToLower("/Jobs/{type:[a-z-]+}/Pause")).Methods(http.MethodPost).HandlerFunc(ri.pause)
+	router.Path(strings.ToLower("/Jobs/{type:[a-z-]+}/Resume")).Methods(http.MethodPost).HandlerFunc(ri.resume)
+}
+
+func (ri *jobsRouterInitializer) stats(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.runner.Stats()
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *jobsRouterInitializer) attempts(w http.ResponseWriter, r *http.Request) {
+	id, err := ReadUint(mux.Vars(r), "id")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.runner.Attempts(id)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *jobsRouterInitializer) deadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, err := ReadUintUrlValue(r.Form, "limit")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	if limit > 100 {
+		WriteErrorResponse(w, errors.Errorf("too big value limit=%d", limit), ri.logger)
+		return
+	}
+	resp, err := ri.runner.DeadLetters(mux.Vars(r)["type"], int(limit))
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *jobsRouterInitializer) pause(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.runner.Pause(mux.Vars(r)["type"])
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *jobsRouterInitializer) resume(w http.ResponseWriter, r *http.Request) {
+	usrErr, err := ri.runner.Resume(mux.Vars(r)["type"])
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/plugins/manifest"
+	"net/http"
+	"strings"
+)
+
+type manifestsRouterInitializer struct {
+	db     *manifest.Postgres
+	logger log.Logger
+}
+
+func NewManifestsRouterInitializer(db *manifest.Postgres, logger log.Logger) RouterInitializer {
+	return &manifestsRouterInitializer{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (ri *manifestsRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Manifests")).Methods(http.MethodGet).HandlerFunc(ri.manifests)
+	router.Path(strings.ToLower("/Manifests/{manifest}/Events/{event}")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.events)
+}
+
+func (ri *manifestsRouterInitializer) manifests(w http.ResponseWriter, r *http.Request) {
+	WriteResponse(w, ri.db.Manifests(), nil, ri.logger)
+}
+
+// events accepts args of the event and the contract address as query params to filter events by
+func (ri *manifestsRouterInitializer) events(w http.ResponseWriter, r *http.Request) {
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	filters := make(map[string]string)
+	for name := range r.Form {
+		if name != strings.ToLower(name) || name == "limit" || name == "continuationtoken" {
+			continue
+		}
+		filters[name] = r.Form.Get(name)
+	}
+	vars := mux.Vars(r)
+	resp, nextContinuationToken, err := ri.db.Events(vars["manifest"], vars["event"], filters, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
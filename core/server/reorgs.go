@@ -0,0 +1,49 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/reorg"
+	"github.com/idena-network/idena-indexer/log"
+	"net/http"
+	"strings"
+)
+
+type reorgsRouterInitializer struct {
+	db     *reorg.Postgres
+	logger log.Logger
+}
+
+func NewReorgsRouterInitializer(db *reorg.Postgres, logger log.Logger) RouterInitializer {
+	return &reorgsRouterInitializer{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (ri *reorgsRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Reorgs")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.reorgs)
+	router.Path(strings.ToLower("/Reorgs/{id:[0-9]+}/Txs")).Methods(http.MethodGet).HandlerFunc(ri.reorgTxs)
+}
+
+func (ri *reorgsRouterInitializer) reorgs(w http.ResponseWriter, r *http.Request) {
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.db.Reorgs(count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
+func (ri *reorgsRouterInitializer) reorgTxs(w http.ResponseWriter, r *http.Request) {
+	id, err := ReadUint(mux.Vars(r), "id")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.db.ReorgTxs(id)
+	WriteResponse(w, resp, err, ri.logger)
+}
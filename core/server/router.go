@@ -3,11 +3,14 @@ package server
 import (
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/idena-network/idena-indexer/core/api"
 	"github.com/idena-network/idena-indexer/log"
 	"github.com/pkg/errors"
 	"io"
+	"mime"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 )
@@ -68,6 +71,12 @@ func (ri *routerInitializer) InitRouter(router *mux.Router) {
 
 	router.Path(strings.ToLower("/Address/{address}/IdentityWithProof")).
 		Queries("epoch", "{epoch:[0-9]+}").HandlerFunc(ri.identityWithProof)
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}/Identity/{address}")).HandlerFunc(ri.epochIdentity)
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}/Account/{address}")).HandlerFunc(ri.epochAccount)
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}/Contract/{address}/Value")).
+		Queries("key", "{key}").HandlerFunc(ri.epochContractValue)
+	router.Path(strings.ToLower("/Epoch/{epoch:[0-9]+}/IdentitiesDiff")).
+		Queries("to", "{to:[0-9]+}", "limit", "{limit}").HandlerFunc(ri.identitiesDiff)
 
 	router.Path(strings.ToLower("/Staking")).HandlerFunc(ri.staking)
 	router.Path(strings.ToLower("/StakingV2")).HandlerFunc(ri.stakingV2)
@@ -77,6 +86,16 @@ func (ri *routerInitializer) InitRouter(router *mux.Router) {
 	router.Path(strings.ToLower("/ForkCommittee/Count")).HandlerFunc(ri.forkCommitteeSize)
 
 	router.Path(strings.ToLower("/Contract/{address}/Verify")).HandlerFunc(ri.verifyContract)
+	router.Path(strings.ToLower("/Contract/{address}/Abi")).Methods(http.MethodGet).HandlerFunc(ri.contractAbi)
+	router.Path(strings.ToLower("/Contract/{address}/Verification")).Methods(http.MethodGet).
+		HandlerFunc(ri.contractVerification)
+	router.Path(strings.ToLower("/Contract/{address}/Source")).Methods(http.MethodGet).HandlerFunc(ri.contractSource)
+	router.Path(strings.ToLower("/Contract/{address}/Source/Files")).Methods(http.MethodGet).
+		HandlerFunc(ri.contractSourceFiles)
+	router.Path(strings.ToLower("/Contract/{address}/Source/File")).
+		Queries("name", "{name}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.contractSourceFile)
 }
 
 func (ri *routerInitializer) onlineIdentitiesCount(w http.ResponseWriter, r *http.Request) {
@@ -228,6 +247,79 @@ func (ri *routerInitializer) identityWithProof(w http.ResponseWriter, r *http.Re
 	WriteResponse(w, resp, err, ri.logger)
 }
 
+// epochIdentity returns the identity at the epoch start, the proof param set to true adds the value proof
+func (ri *routerInitializer) epochIdentity(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	withProof, err := ReadBoolUrlValue(r.Form, "proof")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.api.EpochIdentity(epoch, mux.Vars(r)["address"], withProof)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *routerInitializer) epochAccount(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	withProof, err := ReadBoolUrlValue(r.Form, "proof")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.api.EpochAccount(epoch, mux.Vars(r)["address"], withProof)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+// epochContractValue expects the hex encoded storage key in the key param
+func (ri *routerInitializer) epochContractValue(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	key, err := hexutil.Decode(r.Form.Get("key"))
+	if err != nil {
+		WriteErrorResponse(w, errors.Errorf("wrong value key=%v", r.Form.Get("key")), ri.logger)
+		return
+	}
+	withProof, err := ReadBoolUrlValue(r.Form, "proof")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, err := ri.api.EpochContractValue(epoch, mux.Vars(r)["address"], key, withProof)
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+// identitiesDiff returns identities with fields changed between starts of the epoch and the epoch set by the to param
+func (ri *routerInitializer) identitiesDiff(w http.ResponseWriter, r *http.Request) {
+	epoch, err := ReadUint(mux.Vars(r), "epoch")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	toEpoch, err := ReadUintUrlValue(r.Form, "to")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	count, continuationToken, err := ReadPaginatorParams(r.Form)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	resp, nextContinuationToken, err := ri.api.IdentitiesDiff(epoch, toEpoch, count, continuationToken)
+	WriteResponsePage(w, resp, nextContinuationToken, err, ri.logger)
+}
+
 func (ri *routerInitializer) staking(w http.ResponseWriter, r *http.Request) {
 	resp, err := ri.api.Staking()
 	WriteResponse(w, resp.Weight, err, ri.logger)
@@ -260,3 +352,58 @@ func (ri *routerInitializer) verifyContract(w http.ResponseWriter, r *http.Reque
 	usrErr, err := ri.api.VerifyContract(address, data, fileName)
 	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
 }
+
+func (ri *routerInitializer) contractAbi(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.api.ContractAbi(mux.Vars(r)["address"])
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+type metricsRouterInitializer struct {
+	handler http.Handler
+}
+
+func NewMetricsRouterInitializer(handler http.Handler) RouterInitializer {
+	return &metricsRouterInitializer{
+		handler: handler,
+	}
+}
+
+func (ri *metricsRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path("/metrics").Handler(ri.handler)
+}
+
+func (ri *routerInitializer) contractVerification(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.api.ContractVerification(mux.Vars(r)["address"])
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+// contractSource downloads the verified source archive
+func (ri *routerInitializer) contractSource(w http.ResponseWriter, r *http.Request) {
+	data, fileName, usrErr, err := ri.api.ContractSource(mux.Vars(r)["address"])
+	writeFile(w, data, fileName, usrErr, err, ri.logger)
+}
+
+func (ri *routerInitializer) contractSourceFiles(w http.ResponseWriter, r *http.Request) {
+	resp, usrErr, err := ri.api.ContractSourceFiles(mux.Vars(r)["address"])
+	WriteResponseWithUserErr(w, resp, usrErr, err, ri.logger)
+}
+
+// contractSourceFile downloads the file of the verified source, the name param is the file path in the archive
+func (ri *routerInitializer) contractSourceFile(w http.ResponseWriter, r *http.Request) {
+	name := r.Form.Get("name")
+	data, usrErr, err := ri.api.ContractSourceFile(mux.Vars(r)["address"], name)
+	writeFile(w, data, path.Base(name), usrErr, err, ri.logger)
+}
+
+// writeFile writes the json error response if there is an error
+func writeFile(w http.ResponseWriter, data []byte, fileName string, usrErr, err error, logger log.Logger) {
+	if usrErr != nil || err != nil {
+		WriteResponseWithUserErr(w, nil, usrErr, err, logger)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	if _, err := w.Write(data); err != nil {
+		logger.Error(fmt.Sprintf("Unable to write API response: %v", err))
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -22,10 +23,11 @@ func NewServer(
 }
 
 type Server struct {
-	port    int
-	counter int
-	log     log.Logger
-	mutex   sync.Mutex
+	port       int
+	counter    int
+	log        log.Logger
+	mutex      sync.Mutex
+	httpServer *http.Server
 }
 
 func (s *Server) Start(routerInitializers ...RouterInitializer) {
@@ -38,14 +40,31 @@ func (s *Server) Start(routerInitializers ...RouterInitializer) {
 
 	headersOk := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type"})
 	originsOk := handlers.AllowedOrigins([]string{"*"})
-	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"})
-	err := http.ListenAndServe(fmt.Sprintf(":%d", s.port),
-		handlers.CORS(originsOk, headersOk, methodsOk)(s.requestFilter(apiRouter)))
-	if err != nil {
+	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.port),
+		Handler: handlers.CORS(originsOk, headersOk, methodsOk)(s.requestFilter(apiRouter)),
+	}
+	s.mutex.Lock()
+	s.httpServer = httpServer
+	s.mutex.Unlock()
+	err := httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		panic(err)
 	}
 }
 
+// Stop stops accepting requests and waits for active ones to be completed until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	s.mutex.Lock()
+	httpServer := s.httpServer
+	s.mutex.Unlock()
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
+}
+
 func (s *Server) generateReqId() int {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
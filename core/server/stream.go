@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/idena-network/idena-indexer/core/stream"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	streamPingInterval = time.Second * 30
+	streamWriteTimeout = time.Second * 10
+)
+
+type streamRouterInitializer struct {
+	hub          *stream.Hub
+	finalizedHub *stream.Hub
+	upgrader     websocket.Upgrader
+	logger       log.Logger
+}
+
+// NewStreamRouterInitializer creates stream endpoints, subscribers requesting finalized=true get messages of final
+// blocks from finalizedHub
+func NewStreamRouterInitializer(hub, finalizedHub *stream.Hub, logger log.Logger) RouterInitializer {
+	return &streamRouterInitializer{
+		hub:          hub,
+		finalizedHub: finalizedHub,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+		logger: logger,
+	}
+}
+
+func (ri *streamRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Stream/Sse")).HandlerFunc(ri.sse)
+	router.Path(strings.ToLower("/Stream/Ws")).HandlerFunc(ri.ws)
+}
+
+// subscribe returns the hub the subscription is made on to unsubscribe from it once the request is completed
+func (ri *streamRouterInitializer) subscribe(r *http.Request) (*stream.Hub, *stream.Subscription, error) {
+	filter, err := stream.NewFilter(
+		readListUrlValue(r.Form, "types"),
+		readListUrlValue(r.Form, "address"),
+		readListUrlValue(r.Form, "txtype"),
+		readListUrlValue(r.Form, "contract"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fromHeight *uint64
+	if len(r.Form.Get("fromheight")) > 0 {
+		v, err := ReadUintUrlValue(r.Form, "fromheight")
+		if err != nil {
+			return nil, nil, err
+		}
+		fromHeight = &v
+	} else if lastEventId := r.Header.Get("Last-Event-ID"); len(lastEventId) > 0 {
+		// event id is block height, messages of the block are sent again since the client could miss some of them
+		v, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			return nil, nil, errors.Errorf("wrong value Last-Event-ID=%v", lastEventId)
+		}
+		fromHeight = &v
+	}
+	hub := ri.hub
+	if r.Form.Get("finalized") == "true" {
+		hub = ri.finalizedHub
+	}
+	sub, err := hub.Subscribe(filter, fromHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hub, sub, nil
+}
+
+func (ri *streamRouterInitializer) sse(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteErrorResponse(w, errors.New("streaming is not supported"), ri.logger)
+		return
+	}
+	hub, sub, err := ri.subscribe(r)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	defer hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case message := <-sub.Messages():
+			data, err := json.Marshal(message)
+			if err != nil {
+				ri.logger.Error(fmt.Sprintf("Unable to marshal stream message: %v", err))
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", message.Height, message.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-sub.Done():
+			if err := sub.Err(); err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", strconv.Quote(err.Error()))
+				flusher.Flush()
+			}
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (ri *streamRouterInitializer) ws(w http.ResponseWriter, r *http.Request) {
+	hub, sub, err := ri.subscribe(r)
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	defer hub.Unsubscribe(sub)
+
+	conn, err := ri.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		ri.logger.Debug(fmt.Sprintf("Unable to upgrade stream connection: %v", err))
+		return
+	}
+	defer conn.Close()
+
+	// incoming messages are not expected, reading is needed to process control frames and detect disconnection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case message := <-sub.Messages():
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		case <-sub.Done():
+			var reason string
+			if err := sub.Err(); err != nil {
+				reason = err.Error()
+			}
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, reason),
+				time.Now().Add(streamWriteTimeout))
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+func readListUrlValue(params url.Values, name string) []string {
+	var res []string
+	for _, value := range params[name] {
+		res = append(res, strings.Split(value, ",")...)
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/core/webhook"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxWebhookRequestSize = 64 * 1024
+
+type webhooksRouterInitializer struct {
+	dispatcher *webhook.Dispatcher
+	logger     log.Logger
+}
+
+func NewWebhooksRouterInitializer(dispatcher *webhook.Dispatcher, logger log.Logger) RouterInitializer {
+	return &webhooksRouterInitializer{
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+func (ri *webhooksRouterInitializer) InitRouter(router *mux.Router) {
+	router.Path(strings.ToLower("/Webhooks")).Methods(http.MethodPost).HandlerFunc(ri.register)
+	router.Path(strings.ToLower("/Webhooks")).Methods(http.MethodGet).HandlerFunc(ri.webhooks)
+	router.Path(strings.ToLower("/Webhooks/{id:[0-9]+}")).Methods(http.MethodDelete).HandlerFunc(ri.delete)
+	router.Path(strings.ToLower("/Webhooks/{id:[0-9]+}/DeadLetters")).
+		Queries("limit", "{limit}").
+		Methods(http.MethodGet).
+		HandlerFunc(ri.deadLetters)
+}
+
+type registerWebhookRequest struct {
+	Url    string              `json:"url"`
+	Filter types.WebhookFilter `json:"filter"`
+}
+
+func (ri *webhooksRouterInitializer) register(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookRequestSize))
+	if err != nil {
+		WriteErrorResponse(w, errors.Wrap(err, "failed to read request data"), ri.logger)
+		return
+	}
+	var req registerWebhookRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		WriteResponseWithUserErr(w, nil, errors.Wrap(err, "wrong request data"), nil, ri.logger)
+		return
+	}
+	resp, usrErr, err := ri.dispatcher.Register(req.Url, req.Filter)
+	WriteResponseWithUserErr(w, resp, usrErr, err, ri.logger)
+}
+
+func (ri *webhooksRouterInitializer) webhooks(w http.ResponseWriter, r *http.Request) {
+	resp, err := ri.dispatcher.Webhooks()
+	WriteResponse(w, resp, err, ri.logger)
+}
+
+func (ri *webhooksRouterInitializer) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := ReadUint(mux.Vars(r), "id")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	usrErr, err := ri.dispatcher.Delete(id)
+	WriteResponseWithUserErr(w, nil, usrErr, err, ri.logger)
+}
+
+func (ri *webhooksRouterInitializer) deadLetters(w http.ResponseWriter, r *http.Request) {
+	id, err := ReadUint(mux.Vars(r), "id")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	limit, err := ReadUintUrlValue(r.Form, "limit")
+	if err != nil {
+		WriteErrorResponse(w, err, ri.logger)
+		return
+	}
+	if limit > 100 {
+		WriteErrorResponse(w, errors.Errorf("too big value limit=%d", limit), ri.logger)
+		return
+	}
+	resp, err := ri.dispatcher.DeadLetters(id, int(limit))
+	WriteResponse(w, resp, err, ri.logger)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/idena-network/idena-go/stats/collector"
 	statsTypes "github.com/idena-network/idena-go/stats/types"
 	"github.com/idena-network/idena-go/vm/helpers"
+	"github.com/idena-network/idena-indexer/contract/wasm"
 	"github.com/idena-network/idena-indexer/core/conversion"
 	"github.com/idena-network/idena-indexer/db"
 	"github.com/idena-network/idena-indexer/log"
@@ -1887,6 +1888,7 @@ func (c *statsCollector) AddTxReceipt(txReceipt *types.TxReceipt, appState *apps
 				TxHash:          c.pending.tx.tx.Hash(),
 				ContractAddress: contractAddress,
 				Code:            code,
+				Wasm:            wasm.Introspect(code),
 			})
 			deployedWasmContracts = append(deployedWasmContracts, contractAddress)
 		}
@@ -0,0 +1,242 @@
+package stream
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Db interface {
+	// Messages returns messages of saved blocks with heights in the range [fromHeight, toHeight] ordered by height
+	Messages(fromHeight, toHeight uint64) ([]*types.StreamMessage, error)
+	// MinReplayHeight returns min height starting from which messages of saved blocks can be reproduced exactly
+	MinReplayHeight() (uint64, error)
+}
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+func (p *Postgres) Messages(fromHeight, toHeight uint64) ([]*types.StreamMessage, error) {
+	blocks, err := p.blocks(fromHeight, toHeight)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get blocks")
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	txsByHeight, err := p.transactions(fromHeight, toHeight)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get transactions")
+	}
+	balanceUpdatesByHeight, err := p.balanceUpdates(fromHeight, toHeight)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get balance updates")
+	}
+	var res []*types.StreamMessage
+	for _, block := range blocks {
+		res = append(res, &types.StreamMessage{
+			Type:   MessageTypeBlock,
+			Height: block.Height,
+			Block:  block,
+		})
+		res = append(res, txsByHeight[block.Height]...)
+		res = append(res, balanceUpdatesByHeight[block.Height]...)
+		if block.ValidationFinished {
+			res = append(res, epochMessage(block.Height, block.Epoch+1))
+		}
+	}
+	return res, nil
+}
+
+func (p *Postgres) MinReplayHeight() (uint64, error) {
+	// per block committee reward updates are kept for the last blocks only, older ones are merged
+	const query = `SELECT coalesce((SELECT min(block_height) FROM latest_committee_reward_balance_updates),
+                (SELECT max(coalesce(last_block_height, block_height)) + 1 FROM balance_updates WHERE reason = $1),
+                0)`
+	var res uint64
+	err := p.db.QueryRow(query, db.CommitteeRewardReason).Scan(&res)
+	return res, err
+}
+
+func (p *Postgres) blocks(fromHeight, toHeight uint64) ([]*types.StreamBlock, error) {
+	const query = `SELECT b.height,
+       b.hash,
+       b.epoch,
+       b."timestamp",
+       coalesce(a.address, ''),
+       (SELECT count(*) FROM transactions t WHERE t.block_height = b.height),
+       exists(SELECT 1 FROM block_flags bf WHERE bf.block_height = b.height AND bf.flag = $3)
+FROM blocks b
+         LEFT JOIN block_proposers bp ON bp.block_height = b.height
+         LEFT JOIN addresses a ON a.id = bp.address_id
+WHERE b.height >= $1
+  AND b.height <= $2
+ORDER BY b.height`
+	rows, err := p.db.Query(query, fromHeight, toHeight, validationFinishedFlag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.StreamBlock
+	for rows.Next() {
+		item := &types.StreamBlock{}
+		var timestamp int64
+		if err := rows.Scan(
+			&item.Height,
+			&item.Hash,
+			&item.Epoch,
+			&timestamp,
+			&item.Proposer,
+			&item.TxCount,
+			&item.ValidationFinished,
+		); err != nil {
+			return nil, err
+		}
+		item.Timestamp = time.Unix(timestamp, 0).UTC()
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) transactions(fromHeight, toHeight uint64) (map[uint64][]*types.StreamMessage, error) {
+	const query = `SELECT t.block_height,
+       t.hash,
+       dtt.name,
+       af.address,
+       coalesce(at.address, ''),
+       t.amount,
+       t.tips,
+       t.max_fee,
+       t.fee,
+       t.nonce
+FROM transactions t
+         JOIN dic_tx_types dtt ON dtt.id = t.type
+         JOIN addresses af ON af.id = t."from"
+         LEFT JOIN addresses at ON at.id = t."to"
+WHERE t.block_height >= $1
+  AND t.block_height <= $2
+ORDER BY t.id`
+	rows, err := p.db.Query(query, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[uint64][]*types.StreamMessage)
+	for rows.Next() {
+		item := &types.StreamTransaction{}
+		var height uint64
+		if err := rows.Scan(
+			&height,
+			&item.Hash,
+			&item.Type,
+			&item.From,
+			&item.To,
+			&item.Amount,
+			&item.Tips,
+			&item.MaxFee,
+			&item.Fee,
+			&item.Nonce,
+		); err != nil {
+			return nil, err
+		}
+		res[height] = append(res[height], &types.StreamMessage{
+			Type:        MessageTypeTransaction,
+			Height:      height,
+			Transaction: item,
+		})
+	}
+	return res, rows.Err()
+}
+
+// balanceUpdates returns balance updates in the same order as BlockMessages does. Consecutive committee reward
+// updates of an address are merged into one balance_updates row, so per block values are taken from
+// latest_committee_reward_balance_updates which keeps them for the last blocks only (see MinReplayHeight).
+func (p *Postgres) balanceUpdates(fromHeight, toHeight uint64) (map[uint64][]*types.StreamMessage, error) {
+	const query = `SELECT bu.block_height,
+       a.address,
+       bu.balance_old,
+       bu.stake_old,
+       bu.balance_new,
+       bu.stake_new,
+       dbur.name,
+       coalesce(t.hash, ''),
+       coalesce(ca.address, '')
+FROM balance_updates bu
+         JOIN addresses a ON a.id = bu.address_id
+         JOIN dic_balance_update_reasons dbur ON dbur.id = bu.reason
+         LEFT JOIN transactions t ON t.id = bu.tx_id
+         LEFT JOIN addresses ca ON ca.id = bu.contract_address_id
+WHERE bu.block_height >= $1
+  AND bu.block_height <= $2
+  AND bu.reason <> $3
+ORDER BY bu.id`
+	const committeeRewardQuery = `SELECT l.block_height,
+       a.address,
+       l.balance_old,
+       l.stake_old,
+       l.balance_new,
+       l.stake_new,
+       dbur.name,
+       '',
+       ''
+FROM latest_committee_reward_balance_updates l
+         JOIN addresses a ON a.id = l.address_id
+         JOIN dic_balance_update_reasons dbur ON dbur.id = $3
+WHERE l.block_height >= $1
+  AND l.block_height <= $2
+ORDER BY l.block_height, lower(a.address)`
+	res := make(map[uint64][]*types.StreamMessage)
+	for _, q := range []string{query, committeeRewardQuery} {
+		if err := p.readBalanceUpdates(res, q, fromHeight, toHeight, db.CommitteeRewardReason); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (p *Postgres) readBalanceUpdates(res map[uint64][]*types.StreamMessage, query string, args ...interface{}) error {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := &types.StreamBalanceUpdate{}
+		var height uint64
+		if err := rows.Scan(
+			&height,
+			&item.Address,
+			&item.BalanceOld,
+			&item.StakeOld,
+			&item.BalanceNew,
+			&item.StakeNew,
+			&item.Reason,
+			&item.TxHash,
+			&item.ContractAddress,
+		); err != nil {
+			return err
+		}
+		res[height] = append(res[height], &types.StreamMessage{
+			Type:          MessageTypeBalanceUpdate,
+			Height:        height,
+			BalanceUpdate: item,
+		})
+	}
+	return rows.Err()
+}
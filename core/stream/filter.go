@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	MessageTypeBlock         = "block"
+	MessageTypeTransaction   = "transaction"
+	MessageTypeBalanceUpdate = "balanceUpdate"
+	MessageTypeEpoch         = "epoch"
+	MessageTypeReset         = "reset"
+)
+
+var messageTypes = map[string]struct{}{
+	MessageTypeBlock:         {},
+	MessageTypeTransaction:   {},
+	MessageTypeBalanceUpdate: {},
+	MessageTypeEpoch:         {},
+	MessageTypeReset:         {},
+}
+
+// Filter selects stream messages to send to a subscriber. Empty criteria match everything, values within a criterion
+// are combined with OR and different criteria are combined with AND. Address and contract criteria are applied to
+// transactions and balance updates only, tx type criterion is applied to transactions only. Reset messages match any
+// filter since subscribers must revert the data they received for the reset blocks.
+type Filter struct {
+	messageTypes map[string]struct{}
+	addresses    map[string]struct{}
+	txTypes      map[string]struct{}
+	contracts    map[string]struct{}
+}
+
+func NewFilter(messageTypes, addresses, txTypes, contracts []string) (*Filter, error) {
+	res := &Filter{
+		messageTypes: toSet(messageTypes, false),
+		addresses:    toSet(addresses, true),
+		txTypes:      toSet(txTypes, false),
+		contracts:    toSet(contracts, true),
+	}
+	for messageType := range res.messageTypes {
+		if !isMessageType(messageType) {
+			return nil, errors.Errorf("unknown message type %v", messageType)
+		}
+	}
+	return res, nil
+}
+
+func isMessageType(messageType string) bool {
+	_, ok := messageTypes[messageType]
+	return ok
+}
+
+func toSet(values []string, lower bool) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+		if lower {
+			value = strings.ToLower(value)
+		}
+		res[value] = struct{}{}
+	}
+	return res
+}
+
+func contains(set map[string]struct{}, values ...string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+		if _, ok := set[strings.ToLower(value)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) Match(message *types.StreamMessage) bool {
+	if f == nil || message.Type == MessageTypeReset {
+		return true
+	}
+	if len(f.messageTypes) > 0 {
+		if _, ok := f.messageTypes[message.Type]; !ok {
+			return false
+		}
+	}
+	switch message.Type {
+	case MessageTypeTransaction:
+		tx := message.Transaction
+		if len(f.txTypes) > 0 {
+			if _, ok := f.txTypes[tx.Type]; !ok {
+				return false
+			}
+		}
+		return contains(f.addresses, tx.From, tx.To) && contains(f.contracts, tx.To)
+	case MessageTypeBalanceUpdate:
+		balanceUpdate := message.BalanceUpdate
+		return contains(f.addresses, balanceUpdate.Address) && contains(f.contracts, balanceUpdate.ContractAddress)
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_NewFilter(t *testing.T) {
+	_, err := NewFilter([]string{MessageTypeBlock, "unknown"}, nil, nil, nil)
+	require.NotNil(t, err)
+
+	filter, err := NewFilter([]string{" block", ""}, []string{"0xAbC"}, nil, nil)
+	require.Nil(t, err)
+	require.Len(t, filter.messageTypes, 1)
+	require.Contains(t, filter.addresses, "0xabc")
+	require.Nil(t, filter.txTypes)
+}
+
+func Test_FilterMatch(t *testing.T) {
+	block := &types.StreamMessage{Type: MessageTypeBlock, Block: &types.StreamBlock{}}
+	epoch := &types.StreamMessage{Type: MessageTypeEpoch, Epoch: &types.StreamEpoch{}}
+	reset := ResetMessage(1)
+	sendTx := &types.StreamMessage{Type: MessageTypeTransaction, Transaction: &types.StreamTransaction{
+		Type: "SendTx",
+		From: "0xAAA",
+		To:   "0xBBB",
+	}}
+	callTx := &types.StreamMessage{Type: MessageTypeTransaction, Transaction: &types.StreamTransaction{
+		Type: "CallContractTx",
+		From: "0xCCC",
+		To:   "0xDDD",
+	}}
+	balanceUpdate := &types.StreamMessage{Type: MessageTypeBalanceUpdate, BalanceUpdate: &types.StreamBalanceUpdate{
+		Address: "0xAAA",
+	}}
+	contractBalanceUpdate := &types.StreamMessage{Type: MessageTypeBalanceUpdate, BalanceUpdate: &types.StreamBalanceUpdate{
+		Address:         "0xCCC",
+		ContractAddress: "0xDDD",
+	}}
+	all := []*types.StreamMessage{block, epoch, reset, sendTx, callTx, balanceUpdate, contractBalanceUpdate}
+
+	newFilter := func(messageTypes, addresses, txTypes, contracts []string) *Filter {
+		filter, err := NewFilter(messageTypes, addresses, txTypes, contracts)
+		require.Nil(t, err)
+		return filter
+	}
+
+	for _, tc := range []struct {
+		name     string
+		filter   *Filter
+		expected []*types.StreamMessage
+	}{
+		{
+			name:     "nil",
+			expected: all,
+		},
+		{
+			name:     "empty",
+			filter:   newFilter(nil, nil, nil, nil),
+			expected: all,
+		},
+		{
+			name:     "message types",
+			filter:   newFilter([]string{MessageTypeBlock, MessageTypeEpoch}, nil, nil, nil),
+			expected: []*types.StreamMessage{block, epoch, reset},
+		},
+		{
+			name:     "address",
+			filter:   newFilter(nil, []string{"0xaaa"}, nil, nil),
+			expected: []*types.StreamMessage{block, epoch, reset, sendTx, balanceUpdate},
+		},
+		{
+			name:     "recipient address",
+			filter:   newFilter([]string{MessageTypeTransaction}, []string{"0xbbb"}, nil, nil),
+			expected: []*types.StreamMessage{reset, sendTx},
+		},
+		{
+			name:     "tx type",
+			filter:   newFilter([]string{MessageTypeTransaction}, nil, []string{"CallContractTx"}, nil),
+			expected: []*types.StreamMessage{reset, callTx},
+		},
+		{
+			name:     "contract",
+			filter:   newFilter([]string{MessageTypeTransaction, MessageTypeBalanceUpdate}, nil, nil, []string{"0xddd"}),
+			expected: []*types.StreamMessage{reset, callTx, contractBalanceUpdate},
+		},
+		{
+			name:     "address and contract",
+			filter:   newFilter(nil, []string{"0xaaa"}, nil, []string{"0xddd"}),
+			expected: []*types.StreamMessage{block, epoch, reset},
+		},
+		{
+			name:     "address or address",
+			filter:   newFilter([]string{MessageTypeBalanceUpdate}, []string{"0xaaa", "0xccc"}, nil, nil),
+			expected: []*types.StreamMessage{reset, balanceUpdate, contractBalanceUpdate},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var matched []*types.StreamMessage
+			for _, message := range all {
+				if tc.filter.Match(message) {
+					matched = append(matched, message)
+				}
+			}
+			require.Equal(t, tc.expected, matched)
+		})
+	}
+}
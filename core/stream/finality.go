@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+// Finality holds back messages of saved blocks until there are enough blocks on top of them, messages of final
+// blocks are published by a separate hub for subscribers which need final data only
+type Finality struct {
+	db              Db
+	confirmations   uint64
+	mutex           sync.Mutex
+	pending         [][]*types.StreamMessage
+	finalizedHeight *uint64
+}
+
+func NewFinality(db Db, confirmations uint64) *Finality {
+	return &Finality{
+		db:            db,
+		confirmations: confirmations,
+	}
+}
+
+// FinalizedHeight returns the max final height for the last saved height
+func (f *Finality) FinalizedHeight(lastHeight uint64) uint64 {
+	if lastHeight < f.confirmations {
+		return 0
+	}
+	return lastHeight - f.confirmations
+}
+
+// Publish takes messages of the saved block and returns messages of blocks which have become final ordered by height
+func (f *Finality) Publish(messages []*types.StreamMessage) ([][]*types.StreamMessage, error) {
+	if len(messages) == 0 || messages[0].Height == 0 {
+		return nil, nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	height := messages[0].Height
+	f.pending = append(f.pending, messages)
+	toHeight := f.FinalizedHeight(height)
+	fromHeight := f.FinalizedHeight(height-1) + 1
+	if f.finalizedHeight != nil {
+		fromHeight = *f.finalizedHeight + 1
+	}
+	var res [][]*types.StreamMessage
+	for finalHeight := fromHeight; finalHeight <= toHeight; finalHeight++ {
+		for len(f.pending) > 0 && f.pending[0][0].Height < finalHeight {
+			f.pending = f.pending[1:]
+		}
+		if len(f.pending) > 0 && f.pending[0][0].Height == finalHeight {
+			res = append(res, f.pending[0])
+			f.pending = f.pending[1:]
+		} else {
+			// blocks saved before the indexer start are not pending
+			blockMessages, err := f.db.Messages(finalHeight, finalHeight)
+			if err != nil {
+				return res, errors.Wrapf(err, "failed to load messages of final block %v", finalHeight)
+			}
+			if len(blockMessages) > 0 {
+				res = append(res, blockMessages)
+			}
+		}
+		v := finalHeight
+		f.finalizedHeight = &v
+	}
+	return res, nil
+}
+
+// Reset drops messages of blocks above height and returns true if final blocks have been reset
+func (f *Finality) Reset(height uint64) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for len(f.pending) > 0 && f.pending[len(f.pending)-1][0].Height > height {
+		f.pending = f.pending[:len(f.pending)-1]
+	}
+	if f.finalizedHeight != nil && height < *f.finalizedHeight {
+		f.finalizedHeight = &height
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func finalHeights(blocks [][]*types.StreamMessage) []uint64 {
+	var res []uint64
+	for _, messages := range blocks {
+		res = append(res, messages[0].Height)
+	}
+	return res
+}
+
+func Test_FinalityPublish(t *testing.T) {
+	finality := NewFinality(newTestDb(0), 2)
+
+	for height := uint64(1); height <= 2; height++ {
+		blocks, err := finality.Publish(testBlockMessages(height))
+		require.Nil(t, err)
+		require.Empty(t, blocks)
+	}
+	blocks, err := finality.Publish(testBlockMessages(3))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{1}, finalHeights(blocks))
+	require.Len(t, blocks[0], 2)
+
+	blocks, err = finality.Publish(testBlockMessages(4))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{2}, finalHeights(blocks))
+	require.Equal(t, uint64(2), finality.FinalizedHeight(4))
+}
+
+func Test_FinalityLoadsBlocksSavedBeforeStart(t *testing.T) {
+	finality := NewFinality(newTestDb(10), 3)
+
+	blocks, err := finality.Publish(testBlockMessages(11))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{8}, finalHeights(blocks))
+
+	blocks, err = finality.Publish(testBlockMessages(12))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{9}, finalHeights(blocks))
+}
+
+func Test_FinalityReset(t *testing.T) {
+	finality := NewFinality(newTestDb(0), 2)
+	for height := uint64(1); height <= 5; height++ {
+		_, err := finality.Publish(testBlockMessages(height))
+		require.Nil(t, err)
+	}
+
+	// not final blocks are reset
+	require.False(t, finality.Reset(3))
+	for height := uint64(4); height <= 5; height++ {
+		blocks, err := finality.Publish(testBlockMessages(height))
+		require.Nil(t, err)
+		require.Empty(t, blocks)
+	}
+	blocks, err := finality.Publish(testBlockMessages(6))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{4}, finalHeights(blocks))
+
+	// final blocks are reset
+	require.True(t, finality.Reset(1))
+	for height := uint64(2); height <= 3; height++ {
+		blocks, err := finality.Publish(testBlockMessages(height))
+		require.Nil(t, err)
+		require.Empty(t, blocks)
+	}
+	blocks, err = finality.Publish(testBlockMessages(4))
+	require.Nil(t, err)
+	require.Equal(t, []uint64{2}, finalHeights(blocks))
+}
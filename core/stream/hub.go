@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+const replayBatchBlocks = 100
+
+var (
+	errTooSlow  = errors.New("subscriber is too slow, resubscribe using fromHeight to continue")
+	errShutdown = errors.New("stream is stopped")
+)
+
+// Hub delivers messages of saved blocks to subscribers. Subscribers requesting a start height get messages of already
+// saved blocks from the db first and then switch to live messages without gaps or duplicates.
+type Hub struct {
+	db              Db
+	lastHeight      func() uint64
+	bufferSize      int
+	maxReplayBlocks uint64
+	logger          log.Logger
+
+	mutex           sync.Mutex
+	subscribers     map[*Subscription]struct{}
+	publishedHeight *uint64
+	stopped         bool
+}
+
+func NewHub(db Db, lastHeight func() uint64, bufferSize int, maxReplayBlocks uint64, logger log.Logger) *Hub {
+	return &Hub{
+		db:              db,
+		lastHeight:      lastHeight,
+		bufferSize:      bufferSize,
+		maxReplayBlocks: maxReplayBlocks,
+		logger:          logger,
+		subscribers:     make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends messages of one saved block to all subscribers, subscribers that cannot keep up are disconnected
+func (h *Hub) Publish(messages []*types.StreamMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	height := messages[0].Height
+	h.publishedHeight = &height
+	h.pushLocked(messages)
+}
+
+// Reset notifies subscribers that data of blocks above height has been reverted
+func (h *Hub) Reset(height uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.publishedHeight = &height
+	for sub := range h.subscribers {
+		sub.limitReplay(height)
+	}
+	h.pushLocked([]*types.StreamMessage{ResetMessage(height)})
+}
+
+func (h *Hub) pushLocked(messages []*types.StreamMessage) {
+	for sub := range h.subscribers {
+		if !sub.push(messages) {
+			h.logger.Debug("Stream subscriber disconnected", "err", sub.Err())
+			delete(h.subscribers, sub)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. If fromHeight is not nil, messages of saved blocks starting from fromHeight
+// are sent before live ones.
+func (h *Hub) Subscribe(filter *Filter, fromHeight *uint64) (*Subscription, error) {
+	var minReplayHeight uint64
+	if fromHeight != nil {
+		var err error
+		if minReplayHeight, err = h.db.MinReplayHeight(); err != nil {
+			return nil, errors.Wrap(err, "failed to get min height to replay")
+		}
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.stopped {
+		return nil, errShutdown
+	}
+	sub := newSubscription(filter, h.bufferSize)
+	if fromHeight != nil {
+		toHeight := h.lastPublishedHeight()
+		if *fromHeight <= toHeight {
+			if h.maxReplayBlocks > 0 && toHeight-*fromHeight+1 > h.maxReplayBlocks && toHeight-h.maxReplayBlocks+1 > minReplayHeight {
+				minReplayHeight = toHeight - h.maxReplayBlocks + 1
+			}
+			if *fromHeight < minReplayHeight {
+				return nil, errors.Errorf("too old fromHeight %v, min allowed value is %v", *fromHeight, minReplayHeight)
+			}
+			sub.replayTo, sub.replaying = toHeight, true
+			go h.replay(sub, *fromHeight)
+		}
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe removes the subscriber and closes its Done channel, the Messages channel is never closed
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mutex.Lock()
+	delete(h.subscribers, sub)
+	h.mutex.Unlock()
+	sub.close(nil)
+}
+
+// Stop disconnects all subscribers and rejects new ones
+func (h *Hub) Stop() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stopped = true
+	for sub := range h.subscribers {
+		sub.close(errShutdown)
+		delete(h.subscribers, sub)
+	}
+}
+
+func (h *Hub) lastPublishedHeight() uint64 {
+	if h.publishedHeight != nil {
+		return *h.publishedHeight
+	}
+	return h.lastHeight()
+}
+
+func (h *Hub) replay(sub *Subscription, fromHeight uint64) {
+	for from := fromHeight; from <= sub.replayLimit(); from += replayBatchBlocks {
+		to := from + replayBatchBlocks - 1
+		if limit := sub.replayLimit(); to > limit {
+			to = limit
+		}
+		messages, err := h.db.Messages(from, to)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("Unable to load stream messages for heights %v-%v: %v", from, to, err))
+			h.mutex.Lock()
+			delete(h.subscribers, sub)
+			h.mutex.Unlock()
+			sub.close(errors.Wrap(err, "failed to load saved blocks"))
+			return
+		}
+		for _, message := range messages {
+			// blocks above the limit could be reset and indexed again while they were being loaded,
+			// they are sent as live messages then
+			if message.Height > sub.replayLimit() || !sub.filter.Match(message) {
+				continue
+			}
+			select {
+			case sub.messages <- message:
+			case <-sub.done:
+				return
+			}
+		}
+	}
+	if !sub.completeReplay() {
+		h.mutex.Lock()
+		delete(h.subscribers, sub)
+		h.mutex.Unlock()
+	}
+}
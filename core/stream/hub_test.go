@@ -0,0 +1,253 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testDb struct {
+	messagesByHeight map[uint64][]*types.StreamMessage
+	minReplayHeight  uint64
+	wait             chan struct{}
+}
+
+func (db *testDb) Messages(fromHeight, toHeight uint64) ([]*types.StreamMessage, error) {
+	if db.wait != nil {
+		<-db.wait
+	}
+	var res []*types.StreamMessage
+	for height := fromHeight; height <= toHeight; height++ {
+		res = append(res, db.messagesByHeight[height]...)
+	}
+	return res, nil
+}
+
+func (db *testDb) MinReplayHeight() (uint64, error) {
+	return db.minReplayHeight, nil
+}
+
+func testBlockMessages(height uint64) []*types.StreamMessage {
+	return []*types.StreamMessage{
+		{Type: MessageTypeBlock, Height: height, Block: &types.StreamBlock{Height: height}},
+		{Type: MessageTypeTransaction, Height: height, Transaction: &types.StreamTransaction{Type: "SendTx", From: "0x1"}},
+	}
+}
+
+func newTestDb(toHeight uint64) *testDb {
+	db := &testDb{
+		messagesByHeight: make(map[uint64][]*types.StreamMessage),
+	}
+	for height := uint64(1); height <= toHeight; height++ {
+		db.messagesByHeight[height] = testBlockMessages(height)
+	}
+	return db
+}
+
+func receive(t *testing.T, sub *Subscription, cnt int) []*types.StreamMessage {
+	var res []*types.StreamMessage
+	for i := 0; i < cnt; i++ {
+		select {
+		case message := <-sub.Messages():
+			res = append(res, message)
+		case <-time.After(time.Second):
+			require.FailNow(t, "message not received", "received %v of %v", len(res), cnt)
+		}
+	}
+	return res
+}
+
+func requireNoMessages(t *testing.T, sub *Subscription) {
+	select {
+	case message := <-sub.Messages():
+		require.FailNow(t, "unexpected message", "%v at %v", message.Type, message.Height)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func heights(messages []*types.StreamMessage) []uint64 {
+	var res []uint64
+	for _, message := range messages {
+		res = append(res, message.Height)
+	}
+	return res
+}
+
+func Test_HubLive(t *testing.T) {
+	hub := NewHub(newTestDb(0), func() uint64 { return 0 }, 10, 0, log.New())
+	filter, err := NewFilter([]string{MessageTypeBlock}, nil, nil, nil)
+	require.Nil(t, err)
+	sub, err := hub.Subscribe(filter, nil)
+	require.Nil(t, err)
+
+	hub.Publish(testBlockMessages(1))
+	hub.Publish(testBlockMessages(2))
+
+	messages := receive(t, sub, 2)
+	require.Equal(t, []uint64{1, 2}, heights(messages))
+	require.Equal(t, MessageTypeBlock, messages[0].Type)
+	requireNoMessages(t, sub)
+
+	hub.Unsubscribe(sub)
+	<-sub.Done()
+	require.Nil(t, sub.Err())
+}
+
+func Test_HubReplayToLive(t *testing.T) {
+	db := newTestDb(5)
+	db.wait = make(chan struct{})
+	hub := NewHub(db, func() uint64 { return 5 }, 10, 0, log.New())
+	filter, err := NewFilter([]string{MessageTypeBlock}, nil, nil, nil)
+	require.Nil(t, err)
+	fromHeight := uint64(3)
+	sub, err := hub.Subscribe(filter, &fromHeight)
+	require.Nil(t, err)
+
+	// live blocks saved during the replay are queued until it completes
+	hub.Publish(testBlockMessages(6))
+	requireNoMessages(t, sub)
+
+	close(db.wait)
+	require.Equal(t, []uint64{3, 4, 5, 6}, heights(receive(t, sub, 4)))
+
+	hub.Publish(testBlockMessages(7))
+	require.Equal(t, []uint64{7}, heights(receive(t, sub, 1)))
+	requireNoMessages(t, sub)
+}
+
+func Test_HubReplayFromPublishedHeight(t *testing.T) {
+	hub := NewHub(newTestDb(3), func() uint64 { return 2 }, 10, 0, log.New())
+	hub.Publish(testBlockMessages(3))
+	fromHeight := uint64(2)
+	sub, err := hub.Subscribe(nil, &fromHeight)
+	require.Nil(t, err)
+	require.Equal(t, []uint64{2, 2, 3, 3}, heights(receive(t, sub, 4)))
+	requireNoMessages(t, sub)
+}
+
+func Test_HubReplayLimits(t *testing.T) {
+	db := newTestDb(100)
+	db.minReplayHeight = 20
+	hub := NewHub(db, func() uint64 { return 100 }, 10, 0, log.New())
+
+	fromHeight := uint64(19)
+	_, err := hub.Subscribe(nil, &fromHeight)
+	require.NotNil(t, err)
+
+	fromHeight = 20
+	_, err = hub.Subscribe(nil, &fromHeight)
+	require.Nil(t, err)
+
+	hub = NewHub(db, func() uint64 { return 100 }, 10, 50, log.New())
+
+	fromHeight = 50
+	_, err = hub.Subscribe(nil, &fromHeight)
+	require.NotNil(t, err)
+
+	fromHeight = 51
+	_, err = hub.Subscribe(nil, &fromHeight)
+	require.Nil(t, err)
+
+	// future height means live messages only
+	fromHeight = 200
+	sub, err := hub.Subscribe(nil, &fromHeight)
+	require.Nil(t, err)
+	requireNoMessages(t, sub)
+}
+
+func Test_HubSlowSubscriber(t *testing.T) {
+	hub := NewHub(newTestDb(0), func() uint64 { return 0 }, 1, 0, log.New())
+	sub, err := hub.Subscribe(nil, nil)
+	require.Nil(t, err)
+
+	hub.Publish(testBlockMessages(1))
+
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		require.FailNow(t, "slow subscriber is not disconnected")
+	}
+	require.Equal(t, errTooSlow, sub.Err())
+	require.Empty(t, hub.subscribers)
+}
+
+func Test_HubSlowSubscriberDuringReplay(t *testing.T) {
+	db := newTestDb(1)
+	db.wait = make(chan struct{})
+	defer close(db.wait)
+	hub := NewHub(db, func() uint64 { return 1 }, 2, 0, log.New())
+	fromHeight := uint64(1)
+	sub, err := hub.Subscribe(nil, &fromHeight)
+	require.Nil(t, err)
+
+	hub.Publish(testBlockMessages(2))
+	require.False(t, isDone(sub))
+	hub.Publish(testBlockMessages(3))
+	require.True(t, isDone(sub))
+	require.Equal(t, errTooSlow, sub.Err())
+}
+
+func isDone(sub *Subscription) bool {
+	select {
+	case <-sub.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func Test_HubReset(t *testing.T) {
+	hub := NewHub(newTestDb(0), func() uint64 { return 0 }, 10, 0, log.New())
+	filter, err := NewFilter([]string{MessageTypeBlock}, nil, nil, nil)
+	require.Nil(t, err)
+	sub, err := hub.Subscribe(filter, nil)
+	require.Nil(t, err)
+
+	hub.Publish(testBlockMessages(5))
+	hub.Reset(4)
+	hub.Publish(testBlockMessages(5))
+
+	messages := receive(t, sub, 3)
+	require.Equal(t, []uint64{5, 4, 5}, heights(messages))
+	require.Equal(t, MessageTypeReset, messages[1].Type)
+	require.Equal(t, uint64(4), messages[1].Reset.Height)
+}
+
+func Test_HubResetDuringReplay(t *testing.T) {
+	db := newTestDb(10)
+	db.wait = make(chan struct{})
+	hub := NewHub(db, func() uint64 { return 10 }, 20, 0, log.New())
+	filter, err := NewFilter([]string{MessageTypeBlock}, nil, nil, nil)
+	require.Nil(t, err)
+	fromHeight := uint64(1)
+	sub, err := hub.Subscribe(filter, &fromHeight)
+	require.Nil(t, err)
+
+	hub.Reset(3)
+	hub.Publish(testBlockMessages(4))
+	close(db.wait)
+
+	messages := receive(t, sub, 5)
+	require.Equal(t, []uint64{1, 2, 3, 3, 4}, heights(messages))
+	require.Equal(t, MessageTypeReset, messages[3].Type)
+	requireNoMessages(t, sub)
+
+	// later subscribers replay up to the reset height
+	fromHeight = 3
+	sub2, err := hub.Subscribe(filter, &fromHeight)
+	require.Nil(t, err)
+	require.Equal(t, []uint64{3, 4}, heights(receive(t, sub2, 2)))
+}
+
+func Test_HubStop(t *testing.T) {
+	hub := NewHub(newTestDb(0), func() uint64 { return 0 }, 10, 0, log.New())
+	sub, err := hub.Subscribe(nil, nil)
+	require.Nil(t, err)
+	hub.Stop()
+	<-sub.Done()
+	require.Equal(t, errShutdown, sub.Err())
+	_, err = hub.Subscribe(nil, nil)
+	require.Equal(t, errShutdown, err)
+}
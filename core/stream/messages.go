@@ -0,0 +1,125 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"sort"
+	"strings"
+	"time"
+)
+
+const validationFinishedFlag = "ValidationFinished"
+
+var balanceUpdateReasonNames = map[db.BalanceUpdateReason]string{
+	db.TxReason:                          "Tx",
+	db.VerifiedStakeTransferReason:       "VerifiedStake",
+	db.ProposerRewardReason:              "ProposerReward",
+	db.CommitteeRewardReason:             "CommitteeReward",
+	db.EpochRewardReason:                 "EpochReward",
+	db.FailedValidationReason:            "FailedValidation",
+	db.PenaltyReason:                     "Penalty",
+	db.EpochPenaltyResetReason:           "EpochPenaltyReset",
+	db.DustClearingReason:                "DustClearing",
+	db.ContractReason:                    "Contract",
+	db.EmbeddedContractTerminationReason: "EmbeddedContractTerm",
+	db.DelegatorEpochRewardReason:        "DelegatorEpochReward",
+	db.DelegateeEpochRewardReason:        "DelegateeEpochReward",
+	db.IdentityClearingReason:            "IdentityClearing",
+}
+
+// BlockMessages converts saved block data to stream messages in the order they are sent to subscribers:
+// block, its transactions, balance updates and epoch transition if the block finishes validation. Committee reward
+// balance updates go after the other ones ordered by address, the same order is used to replay saved blocks.
+func BlockMessages(data *db.Data) []*types.StreamMessage {
+	height := data.Block.Height
+	res := make([]*types.StreamMessage, 0, 1+len(data.Block.Transactions)+len(data.BalanceUpdates)+1)
+	block := &types.StreamBlock{
+		Height:             height,
+		Hash:               data.Block.Hash,
+		Epoch:              data.Epoch,
+		Timestamp:          time.Unix(data.Block.Time, 0).UTC(),
+		Proposer:           data.Block.Proposer,
+		TxCount:            len(data.Block.Transactions),
+		ValidationFinished: data.Block.ValidationFinished,
+	}
+	res = append(res, &types.StreamMessage{
+		Type:   MessageTypeBlock,
+		Height: height,
+		Block:  block,
+	})
+	for _, tx := range data.Block.Transactions {
+		res = append(res, &types.StreamMessage{
+			Type:   MessageTypeTransaction,
+			Height: height,
+			Transaction: &types.StreamTransaction{
+				Hash:   tx.Hash,
+				Type:   conversion.ConvertTxType(tx.Type),
+				From:   tx.From,
+				To:     tx.To,
+				Amount: tx.Amount,
+				Tips:   tx.Tips,
+				MaxFee: tx.MaxFee,
+				Fee:    tx.Fee,
+				Nonce:  tx.Nonce,
+			},
+		})
+	}
+	var committeeRewardUpdates []*types.StreamMessage
+	for _, balanceUpdate := range data.BalanceUpdates {
+		message := &types.StreamBalanceUpdate{
+			Address:    conversion.ConvertAddress(balanceUpdate.Address),
+			BalanceOld: blockchain.ConvertToFloat(balanceUpdate.BalanceOld),
+			StakeOld:   blockchain.ConvertToFloat(balanceUpdate.StakeOld),
+			BalanceNew: blockchain.ConvertToFloat(balanceUpdate.BalanceNew),
+			StakeNew:   blockchain.ConvertToFloat(balanceUpdate.StakeNew),
+			Reason:     balanceUpdateReasonNames[balanceUpdate.Reason],
+		}
+		if balanceUpdate.TxHash != nil {
+			message.TxHash = conversion.ConvertHash(*balanceUpdate.TxHash)
+		}
+		if balanceUpdate.ContractAddress != nil {
+			message.ContractAddress = conversion.ConvertAddress(*balanceUpdate.ContractAddress)
+		}
+		streamMessage := &types.StreamMessage{
+			Type:          MessageTypeBalanceUpdate,
+			Height:        height,
+			BalanceUpdate: message,
+		}
+		if balanceUpdate.Reason == db.CommitteeRewardReason {
+			committeeRewardUpdates = append(committeeRewardUpdates, streamMessage)
+			continue
+		}
+		res = append(res, streamMessage)
+	}
+	sort.SliceStable(committeeRewardUpdates, func(i, j int) bool {
+		return strings.ToLower(committeeRewardUpdates[i].BalanceUpdate.Address) < strings.ToLower(committeeRewardUpdates[j].BalanceUpdate.Address)
+	})
+	res = append(res, committeeRewardUpdates...)
+	if block.ValidationFinished {
+		res = append(res, epochMessage(height, data.Epoch+1))
+	}
+	return res
+}
+
+func ResetMessage(height uint64) *types.StreamMessage {
+	return &types.StreamMessage{
+		Type:   MessageTypeReset,
+		Height: height,
+		Reset: &types.StreamReset{
+			Height: height,
+		},
+	}
+}
+
+func epochMessage(height, epoch uint64) *types.StreamMessage {
+	return &types.StreamMessage{
+		Type:   MessageTypeEpoch,
+		Height: height,
+		Epoch: &types.StreamEpoch{
+			Epoch:       epoch,
+			EpochHeight: height,
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func testBalanceUpdate(address common.Address, reason db.BalanceUpdateReason) *db.BalanceUpdate {
+	return &db.BalanceUpdate{
+		Address:    address,
+		BalanceOld: big.NewInt(0),
+		StakeOld:   big.NewInt(0),
+		BalanceNew: new(big.Int).Mul(big.NewInt(1), common.DnaBase),
+		StakeNew:   big.NewInt(0),
+		Reason:     reason,
+	}
+}
+
+func Test_BlockMessages(t *testing.T) {
+	contract := common.Address{0x5}
+	contractBalanceUpdate := testBalanceUpdate(common.Address{0x4}, db.ContractReason)
+	contractBalanceUpdate.ContractAddress = &contract
+	data := &db.Data{
+		Epoch: 3,
+		Block: db.Block{
+			Height:   10,
+			Hash:     "0x10",
+			Time:     1600000000,
+			Proposer: "0xProposer",
+			Transactions: []db.Transaction{
+				{Hash: "0x1", From: "0xA", To: "0xB", Amount: decimal.NewFromInt(1)},
+				{Hash: "0x2", From: "0xC"},
+			},
+		},
+		BalanceUpdates: []*db.BalanceUpdate{
+			testBalanceUpdate(common.Address{0x3}, db.CommitteeRewardReason),
+			testBalanceUpdate(common.Address{0x2}, db.TxReason),
+			testBalanceUpdate(common.Address{0x1}, db.CommitteeRewardReason),
+			contractBalanceUpdate,
+		},
+	}
+
+	messages := BlockMessages(data)
+
+	require.Len(t, messages, 7)
+	for _, message := range messages {
+		require.Equal(t, uint64(10), message.Height)
+	}
+	require.Equal(t, MessageTypeBlock, messages[0].Type)
+	require.Equal(t, "0x10", messages[0].Block.Hash)
+	require.Equal(t, uint64(3), messages[0].Block.Epoch)
+	require.Equal(t, int64(1600000000), messages[0].Block.Timestamp.Unix())
+	require.Equal(t, 2, messages[0].Block.TxCount)
+
+	require.Equal(t, MessageTypeTransaction, messages[1].Type)
+	require.Equal(t, "0x1", messages[1].Transaction.Hash)
+	require.Equal(t, "0xB", messages[1].Transaction.To)
+	require.Equal(t, "0x2", messages[2].Transaction.Hash)
+
+	// committee rewards go last ordered by address to match replay of saved blocks
+	require.Equal(t, MessageTypeBalanceUpdate, messages[3].Type)
+	require.Equal(t, conversion.ConvertAddress(common.Address{0x2}), messages[3].BalanceUpdate.Address)
+	require.Equal(t, "Tx", messages[3].BalanceUpdate.Reason)
+	require.Equal(t, "1", messages[3].BalanceUpdate.BalanceNew.String())
+	require.Equal(t, conversion.ConvertAddress(contract), messages[4].BalanceUpdate.ContractAddress)
+	require.Equal(t, conversion.ConvertAddress(common.Address{0x1}), messages[5].BalanceUpdate.Address)
+	require.Equal(t, "CommitteeReward", messages[5].BalanceUpdate.Reason)
+	require.Equal(t, conversion.ConvertAddress(common.Address{0x3}), messages[6].BalanceUpdate.Address)
+}
+
+func Test_BlockMessagesValidationFinished(t *testing.T) {
+	data := &db.Data{
+		Epoch: 3,
+		Block: db.Block{
+			Height:             10,
+			ValidationFinished: true,
+		},
+	}
+
+	messages := BlockMessages(data)
+
+	require.Len(t, messages, 2)
+	require.True(t, messages[0].Block.ValidationFinished)
+	require.Equal(t, MessageTypeEpoch, messages[1].Type)
+	require.Equal(t, uint64(4), messages[1].Epoch.Epoch)
+	require.Equal(t, uint64(10), messages[1].Epoch.EpochHeight)
+}
@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"sync"
+)
+
+// Subscription receives stream messages matching its filter until Done is closed
+type Subscription struct {
+	filter   *Filter
+	messages chan *types.StreamMessage
+	done     chan struct{}
+
+	mutex     sync.Mutex
+	replayTo  uint64
+	replaying bool
+	pending   []*types.StreamMessage
+	closed    bool
+	err       error
+}
+
+func newSubscription(filter *Filter, bufferSize int) *Subscription {
+	return &Subscription{
+		filter:   filter,
+		messages: make(chan *types.StreamMessage, bufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *Subscription) Messages() <-chan *types.StreamMessage {
+	return s.messages
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the subscription was closed by the hub, nil if it was closed by the subscriber
+func (s *Subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// push sends live messages without blocking and returns false if the subscription is closed
+func (s *Subscription) push(messages []*types.StreamMessage) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	for _, message := range messages {
+		if !s.filter.Match(message) {
+			continue
+		}
+		if s.replaying {
+			if len(s.pending) >= cap(s.messages) {
+				s.closeLocked(errTooSlow)
+				return false
+			}
+			s.pending = append(s.pending, message)
+			continue
+		}
+		if !s.trySendLocked(message) {
+			return false
+		}
+	}
+	return true
+}
+
+// completeReplay sends live messages received during the replay and returns false if the subscription is closed
+func (s *Subscription) completeReplay() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	pending := s.pending
+	replayTo := s.replayTo
+	s.pending, s.replayTo, s.replaying = nil, 0, false
+	for _, message := range pending {
+		if message.Height <= replayTo && message.Type != MessageTypeReset {
+			continue
+		}
+		if !s.trySendLocked(message) {
+			return false
+		}
+	}
+	return true
+}
+
+// replayLimit returns max height of saved blocks to replay
+func (s *Subscription) replayLimit() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.replayTo
+}
+
+// limitReplay prevents replaying saved blocks above height since their data has been reverted
+func (s *Subscription) limitReplay(height uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.replaying && height < s.replayTo {
+		s.replayTo = height
+	}
+}
+
+func (s *Subscription) trySendLocked(message *types.StreamMessage) bool {
+	select {
+	case s.messages <- message:
+		return true
+	default:
+		s.closeLocked(errTooSlow)
+		return false
+	}
+}
+
+func (s *Subscription) close(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closeLocked(err)
+}
+
+func (s *Subscription) closeLocked(err error) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = err
+	s.pending = nil
+	close(s.done)
+}
@@ -0,0 +1,16 @@
+package text
+
+// Truncate returns s cut to at most maxLength characters, a multi-byte character is never split
+func Truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	var length int
+	for i := range s {
+		if length == maxLength {
+			return s[:i]
+		}
+		length++
+	}
+	return s
+}
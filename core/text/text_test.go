@@ -0,0 +1,16 @@
+package text
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Truncate(t *testing.T) {
+	require.Equal(t, "", Truncate("", 3))
+	require.Equal(t, "abc", Truncate("abc", 3))
+	require.Equal(t, "ab", Truncate("abc", 2))
+	require.Equal(t, "", Truncate("abc", 0))
+	require.Equal(t, "привет", Truncate("привет", 6))
+	require.Equal(t, "при", Truncate("привет", 3))
+	require.Equal(t, "a€", Truncate("a€b", 2))
+}
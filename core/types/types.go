@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"github.com/idena-network/idena-go/common/hexutil"
 	"github.com/shopspring/decimal"
 	"time"
 )
@@ -48,6 +50,9 @@ type TransactionSummary struct {
 	// Deprecated
 	Transfer *decimal.Decimal `json:"transfer,omitempty"`
 	Data     interface{}      `json:"data,omitempty"`
+	// BlockHeight and Timestamp are empty for mempool transactions
+	BlockHeight uint64     `json:"blockHeight,omitempty"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
 
 	TxReceipt *TxReceipt `json:"txReceipt,omitempty"`
 }
@@ -69,8 +74,12 @@ type TransactionDetail struct {
 	// Deprecated
 	Transfer *decimal.Decimal `json:"transfer,omitempty"`
 	Data     interface{}      `json:"data,omitempty"`
+	// Timestamp is empty for mempool transactions
+	Timestamp *time.Time `json:"timestamp,omitempty"`
 
 	TxReceipt *TxReceipt `json:"txReceipt,omitempty"`
+	// Decoded is set for contract calls if the ABI of the contract or ABIs of contracts emitted the events are uploaded
+	Decoded *DecodedContractCall `json:"decoded,omitempty"`
 }
 
 type TxReceipt struct {
@@ -97,3 +106,452 @@ type Staking struct {
 	AverageMinerWeight float64 `json:"averageMinerWeight"`
 	MaxMinerWeight     float64 `json:"maxMinerWeight"`
 }
+
+type Health struct {
+	Healthy              bool              `json:"healthy"`
+	Ready                bool              `json:"ready"`
+	LastIndexedHeight    uint64            `json:"lastIndexedHeight"`
+	NodeHeadHeight       uint64            `json:"nodeHeadHeight"`
+	SyncLag              uint64            `json:"syncLag"`
+	Paused               bool              `json:"paused"`
+	Restoring            bool              `json:"restoring"`
+	LastBlockProcessedAt *time.Time        `json:"lastBlockProcessedAt,omitempty"`
+	LastBlockAgeSec      *float64          `json:"lastBlockAgeSec,omitempty"`
+	Postgres             ComponentHealth   `json:"postgres"`
+	Components           []ComponentHealth `json:"components,omitempty"`
+}
+
+type ComponentHealth struct {
+	Name          string     `json:"name"`
+	Healthy       bool       `json:"healthy"`
+	LastActivity  *time.Time `json:"lastActivity,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+type StreamMessage struct {
+	Type          string               `json:"type" enums:"block,transaction,balanceUpdate,epoch,reset"`
+	Height        uint64               `json:"height"`
+	Block         *StreamBlock         `json:"block,omitempty"`
+	Transaction   *StreamTransaction   `json:"transaction,omitempty"`
+	BalanceUpdate *StreamBalanceUpdate `json:"balanceUpdate,omitempty"`
+	Epoch         *StreamEpoch         `json:"epoch,omitempty"`
+	Reset         *StreamReset         `json:"reset,omitempty"`
+}
+
+type StreamBlock struct {
+	Height             uint64    `json:"height"`
+	Hash               string    `json:"hash"`
+	Epoch              uint64    `json:"epoch"`
+	Timestamp          time.Time `json:"timestamp"`
+	Proposer           string    `json:"proposer,omitempty"`
+	TxCount            int       `json:"txCount"`
+	ValidationFinished bool      `json:"validationFinished,omitempty"`
+}
+
+type StreamTransaction struct {
+	Hash   string          `json:"hash"`
+	Type   string          `json:"type"`
+	From   string          `json:"from"`
+	To     string          `json:"to,omitempty"`
+	Amount decimal.Decimal `json:"amount" swaggertype:"string"`
+	Tips   decimal.Decimal `json:"tips" swaggertype:"string"`
+	MaxFee decimal.Decimal `json:"maxFee" swaggertype:"string"`
+	Fee    decimal.Decimal `json:"fee" swaggertype:"string"`
+	Nonce  uint32          `json:"nonce"`
+}
+
+type StreamBalanceUpdate struct {
+	Address         string          `json:"address"`
+	BalanceOld      decimal.Decimal `json:"balanceOld" swaggertype:"string"`
+	StakeOld        decimal.Decimal `json:"stakeOld" swaggertype:"string"`
+	BalanceNew      decimal.Decimal `json:"balanceNew" swaggertype:"string"`
+	StakeNew        decimal.Decimal `json:"stakeNew" swaggertype:"string"`
+	Reason          string          `json:"reason"`
+	TxHash          string          `json:"txHash,omitempty"`
+	ContractAddress string          `json:"contractAddress,omitempty"`
+}
+
+type StreamEpoch struct {
+	Epoch       uint64 `json:"epoch"`
+	EpochHeight uint64 `json:"epochHeight"`
+}
+
+// StreamReset means that all the data of blocks above Height has been reverted and the blocks will be indexed again
+type StreamReset struct {
+	Height uint64 `json:"height"`
+}
+
+type Webhook struct {
+	Id        uint64        `json:"id"`
+	Url       string        `json:"url"`
+	Secret    string        `json:"secret,omitempty"`
+	Filter    WebhookFilter `json:"filter"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+type WebhookFilter struct {
+	Events    []string `json:"events,omitempty" enums:"transaction,identityStateChange,penalty,oracleVotingStateChange"`
+	Addresses []string `json:"addresses,omitempty"`
+	TxTypes   []string `json:"txTypes,omitempty"`
+	Contracts []string `json:"contracts,omitempty"`
+	// Finalized delays events until their blocks are final, such webhooks never get reverted events unless a final
+	// block is reset
+	Finalized bool `json:"finalized,omitempty"`
+}
+
+// WebhookMessage is the body of webhook requests, Data depends on Type
+type WebhookMessage struct {
+	Id     uint64      `json:"id"`
+	Type   string      `json:"type" enums:"transaction,identityStateChange,penalty,oracleVotingStateChange,reverted"`
+	Height uint64      `json:"height"`
+	Data   interface{} `json:"data"`
+}
+
+type WebhookIdentityStateChange struct {
+	Address   string `json:"address"`
+	PrevState string `json:"prevState"`
+	State     string `json:"state"`
+	TxHash    string `json:"txHash,omitempty"`
+}
+
+type WebhookPenalty struct {
+	Address       string          `json:"address"`
+	Penalty       decimal.Decimal `json:"penalty" swaggertype:"string"`
+	Seconds       uint16          `json:"seconds"`
+	InheritedFrom string          `json:"inheritedFrom,omitempty"`
+}
+
+type WebhookOracleVotingStateChange struct {
+	Contract string `json:"contract"`
+	Action   string `json:"action" enums:"deploy,start,prolong,finish,terminate"`
+	TxHash   string `json:"txHash"`
+	State    *byte  `json:"state,omitempty"`
+	Result   *byte  `json:"result,omitempty"`
+}
+
+// WebhookReverted means that events EventIds previously sent to the webhook belong to blocks above Height which have
+// been reverted
+type WebhookReverted struct {
+	Height   uint64   `json:"height"`
+	EventIds []uint64 `json:"eventIds"`
+}
+
+type WebhookDeadLetter struct {
+	Id        uint64         `json:"id"`
+	Message   WebhookMessage `json:"message"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"lastError,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+type Reorg struct {
+	Id                    uint64    `json:"id"`
+	Timestamp             time.Time `json:"timestamp"`
+	ResetHeight           uint64    `json:"resetHeight"`
+	OldTipHeight          uint64    `json:"oldTipHeight"`
+	OldTipHash            string    `json:"oldTipHash,omitempty"`
+	NewTipHash            string    `json:"newTipHash"`
+	Depth                 uint64    `json:"depth"`
+	RemovedTxs            uint64    `json:"removedTxs"`
+	RemovedBalanceUpdates uint64    `json:"removedBalanceUpdates"`
+	RemovedRewards        uint64    `json:"removedRewards"`
+}
+
+type ReorgTx struct {
+	Hash string `json:"hash"`
+	// ReincludedBlockHeight is the height of the block which includes the removed transaction again, nil if the
+	// transaction has been dropped
+	ReincludedBlockHeight *uint64 `json:"reincludedBlockHeight,omitempty"`
+}
+
+type Finality struct {
+	LastIndexedHeight uint64 `json:"lastIndexedHeight"`
+	// FinalizedHeight is the max height of blocks which are considered safe from resets
+	FinalizedHeight uint64 `json:"finalizedHeight"`
+	Confirmations   uint64 `json:"confirmations"`
+}
+
+type AuditRun struct {
+	Id            uint64    `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	BlockHeight   uint64    `json:"blockHeight"`
+	Discrepancies uint64    `json:"discrepancies"`
+	// Incomplete is true if some checks failed to run
+	Incomplete bool `json:"incomplete"`
+	// Repair is true if the audit triggered restoring db data from the node state
+	Repair bool `json:"repair"`
+}
+
+type AuditDiscrepancy struct {
+	Check      string `json:"check"`
+	Severity   string `json:"severity"`
+	Address    string `json:"address,omitempty"`
+	Contract   string `json:"contract,omitempty"`
+	StateValue string `json:"stateValue"`
+	DbValue    string `json:"dbValue"`
+}
+
+type JobTypeStats struct {
+	Type   string `json:"type"`
+	Paused bool   `json:"paused"`
+	// Pending is the number of queued jobs including the ones being retried
+	Pending     uint64     `json:"pending"`
+	Retrying    uint64     `json:"retrying"`
+	DeadLetters uint64     `json:"deadLetters"`
+	OldestJobAt *time.Time `json:"oldestJobAt,omitempty"`
+}
+
+type JobAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error"`
+}
+
+type JobDeadLetter struct {
+	Id        uint64          `json:"id"`
+	JobId     uint64          `json:"jobId"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type AdminAction struct {
+	Id         uint64          `json:"id"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Action     string          `json:"action"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	RemoteAddr string          `json:"remoteAddr,omitempty"`
+	// CompletedAt is empty if the action is in progress or the indexer stopped before the action was completed
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type Block struct {
+	Height               uint64          `json:"height"`
+	Hash                 string          `json:"hash"`
+	Epoch                uint64          `json:"epoch"`
+	Timestamp            time.Time       `json:"timestamp"`
+	TxCount              uint32          `json:"txCount"`
+	Proposer             string          `json:"proposer,omitempty"`
+	IsEmpty              bool            `json:"isEmpty"`
+	ValidatorsCount      uint32          `json:"validatorsCount"`
+	PoolValidatorsCount  uint32          `json:"poolValidatorsCount"`
+	BodySize             uint32          `json:"bodySize"`
+	FullSize             uint32          `json:"fullSize"`
+	VrfProposerThreshold float64         `json:"vrfProposerThreshold"`
+	FeeRate              decimal.Decimal `json:"feeRate" swaggertype:"string"`
+	Flags                []string        `json:"flags,omitempty"`
+	Upgrade              *uint32         `json:"upgrade,omitempty"`
+	OfflineAddress       string          `json:"offlineAddress,omitempty"`
+	GasUsed              uint64          `json:"gasUsed"`
+}
+
+type BalanceUpdate struct {
+	BlockHeight     uint64           `json:"blockHeight"`
+	Timestamp       time.Time        `json:"timestamp"`
+	Reason          string           `json:"reason"`
+	TxHash          string           `json:"txHash,omitempty"`
+	ContractAddress string           `json:"contractAddress,omitempty"`
+	BalanceOld      decimal.Decimal  `json:"balanceOld" swaggertype:"string"`
+	StakeOld        decimal.Decimal  `json:"stakeOld" swaggertype:"string"`
+	PenaltyOld      *decimal.Decimal `json:"penaltyOld,omitempty" swaggertype:"string"`
+	BalanceNew      decimal.Decimal  `json:"balanceNew" swaggertype:"string"`
+	StakeNew        decimal.Decimal  `json:"stakeNew" swaggertype:"string"`
+	PenaltyNew      *decimal.Decimal `json:"penaltyNew,omitempty" swaggertype:"string"`
+}
+
+type EpochRewards struct {
+	Epoch             uint64          `json:"epoch"`
+	Total             decimal.Decimal `json:"total" swaggertype:"string"`
+	Validation        decimal.Decimal `json:"validation" swaggertype:"string"`
+	Staking           decimal.Decimal `json:"staking" swaggertype:"string"`
+	Candidate         decimal.Decimal `json:"candidate" swaggertype:"string"`
+	Flips             decimal.Decimal `json:"flips" swaggertype:"string"`
+	FlipsExtra        decimal.Decimal `json:"flipsExtra" swaggertype:"string"`
+	Reports           decimal.Decimal `json:"reports" swaggertype:"string"`
+	Invitations       decimal.Decimal `json:"invitations" swaggertype:"string"`
+	FoundationPayouts decimal.Decimal `json:"foundationPayouts" swaggertype:"string"`
+	ZeroWalletFund    decimal.Decimal `json:"zeroWalletFund" swaggertype:"string"`
+}
+
+type AddressReward struct {
+	Type    string          `json:"type"`
+	Balance decimal.Decimal `json:"balance" swaggertype:"string"`
+	Stake   decimal.Decimal `json:"stake" swaggertype:"string"`
+}
+
+type EpochSummary struct {
+	Epoch             uint64          `json:"epoch"`
+	ValidationTime    time.Time       `json:"validationTime"`
+	ValidatedCount    uint32          `json:"validatedCount"`
+	CandidateCount    uint64          `json:"candidateCount"`
+	BlockCount        uint64          `json:"blockCount"`
+	EmptyBlockCount   uint64          `json:"emptyBlockCount"`
+	TxCount           uint64          `json:"txCount"`
+	InviteCount       uint64          `json:"inviteCount"`
+	FlipCount         uint32          `json:"flipCount"`
+	ReportedFlips     uint32          `json:"reportedFlips"`
+	Burnt             decimal.Decimal `json:"burnt" swaggertype:"string"`
+	Minted            decimal.Decimal `json:"minted" swaggertype:"string"`
+	TotalBalance      decimal.Decimal `json:"totalBalance" swaggertype:"string"`
+	TotalStake        decimal.Decimal `json:"totalStake" swaggertype:"string"`
+	MinScoreForInvite float32         `json:"minScoreForInvite"`
+}
+
+type HistoricalBalance struct {
+	Address string          `json:"address"`
+	Height  uint64          `json:"height"`
+	Balance decimal.Decimal `json:"balance" swaggertype:"string"`
+	Stake   decimal.Decimal `json:"stake" swaggertype:"string"`
+	// Source is node if the balance has been read from the node state since indexed balance updates are not enough
+	Source string `json:"source" enums:"db,node"`
+}
+
+type EpochIdentity struct {
+	Address             string           `json:"address"`
+	Epoch               uint64           `json:"epoch"`
+	State               string           `json:"state" enums:"Undefined,Invite,Candidate,Verified,Suspended,Killed,Zombie,Newbie,Human"`
+	Stake               decimal.Decimal  `json:"stake" swaggertype:"string"`
+	ReplenishedStake    *decimal.Decimal `json:"replenishedStake,omitempty" swaggertype:"string"`
+	LockedStake         *decimal.Decimal `json:"lockedStake,omitempty" swaggertype:"string"`
+	Invites             uint8            `json:"invites"`
+	Birthday            uint16           `json:"birthday"`
+	Generation          uint32           `json:"generation"`
+	RequiredFlips       uint8            `json:"requiredFlips"`
+	Flips               []string         `json:"flips,omitempty"`
+	QualifiedFlips      uint32           `json:"qualifiedFlips"`
+	ShortFlipPoints     float32          `json:"shortFlipPoints"`
+	Inviter             *string          `json:"inviter,omitempty"`
+	Invitees            []string         `json:"invitees,omitempty"`
+	Delegatee           *string          `json:"delegatee,omitempty"`
+	PendingUndelegation *string          `json:"pendingUndelegation,omitempty"`
+	UndelegationEpoch   uint16           `json:"undelegationEpoch,omitempty"`
+	Penalty             *decimal.Decimal `json:"penalty,omitempty" swaggertype:"string"`
+	PenaltySeconds      uint16           `json:"penaltySeconds,omitempty"`
+	Proof               *hexutil.Bytes   `json:"proof,omitempty" swaggertype:"string"`
+}
+
+type EpochAccount struct {
+	Address          string           `json:"address"`
+	Epoch            uint64           `json:"epoch"`
+	Balance          decimal.Decimal  `json:"balance" swaggertype:"string"`
+	Nonce            uint32           `json:"nonce"`
+	NonceEpoch       uint16           `json:"nonceEpoch"`
+	ContractCodeHash *string          `json:"contractCodeHash,omitempty"`
+	ContractStake    *decimal.Decimal `json:"contractStake,omitempty" swaggertype:"string"`
+	Proof            *hexutil.Bytes   `json:"proof,omitempty" swaggertype:"string"`
+}
+
+type EpochContractValue struct {
+	Address string         `json:"address"`
+	Epoch   uint64         `json:"epoch"`
+	Key     string         `json:"key"`
+	Value   *hexutil.Bytes `json:"value" swaggertype:"string"`
+	Proof   *hexutil.Bytes `json:"proof,omitempty" swaggertype:"string"`
+}
+
+type IdentityChange struct {
+	Address string `json:"address"`
+	// Fields contains json names of the changed identity fields
+	Fields []string       `json:"fields"`
+	Old    *EpochIdentity `json:"old,omitempty"`
+	New    *EpochIdentity `json:"new,omitempty"`
+}
+
+// ManifestEvent is a contract event decoded by a manifest, args are keyed by names declared in the manifest
+type ManifestEvent struct {
+	BlockHeight uint64                 `json:"blockHeight"`
+	TxHash      string                 `json:"txHash"`
+	Contract    string                 `json:"contract"`
+	Args        map[string]interface{} `json:"args"`
+}
+
+// DecodedContractCall holds the call args and the result decoded by the contract ABI and the events decoded by ABIs of
+// contracts emitted them, methods and events missing in the ABIs are not decoded
+type DecodedContractCall struct {
+	Method string          `json:"method"`
+	Args   []*DecodedValue `json:"args,omitempty"`
+	Result *DecodedValue   `json:"result,omitempty"`
+	Events []*DecodedEvent `json:"events,omitempty"`
+}
+
+type DecodedValue struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+	// Error is set instead of the value if the data cannot be decoded as the type
+	Error string `json:"error,omitempty"`
+}
+
+type DecodedEvent struct {
+	Contract string          `json:"contract"`
+	Name     string          `json:"name"`
+	Args     []*DecodedValue `json:"args"`
+}
+
+// ContractCode is the introspection of the deployed wasm code, Module is nil if the code cannot be parsed
+type ContractCode struct {
+	Address    string      `json:"address"`
+	CodeHash   string      `json:"codeHash"`
+	Size       uint64      `json:"size"`
+	Module     *WasmModule `json:"module,omitempty"`
+	ParseError string      `json:"parseError,omitempty"`
+	// Clones is the number of other contracts deployed with the same code
+	Clones       uint64                `json:"clones"`
+	Verification *ContractVerification `json:"verification,omitempty"`
+}
+
+type WasmModule struct {
+	// Exports holds names of exported functions
+	Exports []string `json:"exports"`
+	// Imports holds host functions imported by the module
+	Imports        []*WasmImport        `json:"imports"`
+	Memory         *WasmMemory          `json:"memory,omitempty"`
+	CustomSections []*WasmCustomSection `json:"customSections,omitempty"`
+	Functions      uint32               `json:"functions"`
+	// CodeSize is the size of the code section holding function bodies
+	CodeSize uint32 `json:"codeSize"`
+}
+
+type WasmImport struct {
+	Module string `json:"module"`
+	Name   string `json:"name"`
+}
+
+// WasmMemory limits are in 64KiB pages
+type WasmMemory struct {
+	Min      uint32  `json:"min"`
+	Max      *uint32 `json:"max,omitempty"`
+	Imported bool    `json:"imported,omitempty"`
+}
+
+type WasmCustomSection struct {
+	Name string `json:"name"`
+	Size uint32 `json:"size"`
+}
+
+type ContractVerification struct {
+	State           string    `json:"state" enums:"Pending,Verified,Failed"`
+	StateTimestamp  time.Time `json:"stateTimestamp"`
+	FileName        string    `json:"fileName,omitempty"`
+	ErrorMessage    string    `json:"errorMessage,omitempty"`
+	CompilerVersion string    `json:"compilerVersion,omitempty"`
+	BuildFlags      string    `json:"buildFlags,omitempty"`
+	// BuildLog is the tail of the compiler output, it is returned by the verification endpoint only
+	BuildLog string `json:"buildLog,omitempty"`
+}
+
+type ContractSourceFile struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+type ContractClone struct {
+	Address   string    `json:"address"`
+	TxHash    string    `json:"txHash"`
+	Timestamp time.Time `json:"timestamp"`
+}
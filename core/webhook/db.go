@@ -0,0 +1,329 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	deliveryStatePending   = 0
+	deliveryStateDelivered = 1
+)
+
+type Db interface {
+	SaveWebhook(url, secret string, filter *Filter, createdAt time.Time) (uint64, error)
+	// DeleteWebhook deletes the webhook with its deliveries and dead letters and returns false if it is not found
+	DeleteWebhook(id uint64) (bool, error)
+	Webhooks() ([]*types.Webhook, error)
+	DeadLetters(webhookId uint64, limit int) ([]*types.WebhookDeadLetter, error)
+
+	// EventsToFanOut returns saved events which have not been assigned to webhooks yet ordered by id
+	EventsToFanOut(limit int) ([]*Event, error)
+	// SaveDeliveries saves deliveries of fanned out events and marks the events up to lastEventId as fanned out
+	SaveDeliveries(lastEventId uint64, deliveries []NewDelivery) error
+
+	// ClaimDeliveries returns pending deliveries which are due at now, increments their attempts and postpones next
+	// attempts until leaseUntil so that the deliveries are retried if the indexer stops before they are completed.
+	// Deliveries of finalized webhooks are not returned until their blocks are final.
+	ClaimDeliveries(now, leaseUntil time.Time, limit int) ([]*Delivery, error)
+	CompleteDelivery(id uint64, deliveredAt time.Time) error
+	RetryDelivery(id uint64, nextAttemptAt time.Time, lastError string) error
+	MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error
+	DeleteDelivered(deliveredBefore time.Time) error
+}
+
+type Event struct {
+	Id        uint64
+	Height    uint64
+	Type      string
+	Addresses []string
+	TxType    string
+	Contract  string
+	Payload   json.RawMessage
+}
+
+type NewDelivery struct {
+	WebhookId uint64
+	EventId   uint64
+}
+
+type Delivery struct {
+	Id       uint64
+	Attempts int
+	Url      string
+	Secret   string
+	Event    *Event
+}
+
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(connStr string) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db: dbAccessor,
+	}
+}
+
+func (p *Postgres) SaveWebhook(url, secret string, filter *Filter, createdAt time.Time) (uint64, error) {
+	const query = `INSERT INTO webhooks (url, secret, event_types, addresses, tx_types, contracts, finalized, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id`
+	var id uint64
+	err := p.db.QueryRow(query,
+		url,
+		secret,
+		pq.Array(keys(filter.events)),
+		pq.Array(keys(filter.addresses)),
+		pq.Array(keys(filter.txTypes)),
+		pq.Array(keys(filter.contracts)),
+		filter.finalized,
+		createdAt.Unix(),
+	).Scan(&id)
+	return id, err
+}
+
+func (p *Postgres) DeleteWebhook(id uint64) (bool, error) {
+	res, err := p.db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+func (p *Postgres) Webhooks() ([]*types.Webhook, error) {
+	const query = `SELECT id, url, event_types, addresses, tx_types, contracts, finalized, created_at
+FROM webhooks
+ORDER BY id`
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.Webhook
+	for rows.Next() {
+		item := &types.Webhook{}
+		var createdAt int64
+		if err := rows.Scan(
+			&item.Id,
+			&item.Url,
+			pq.Array(&item.Filter.Events),
+			pq.Array(&item.Filter.Addresses),
+			pq.Array(&item.Filter.TxTypes),
+			pq.Array(&item.Filter.Contracts),
+			&item.Filter.Finalized,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = time.Unix(createdAt, 0).UTC()
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) DeadLetters(webhookId uint64, limit int) ([]*types.WebhookDeadLetter, error) {
+	const query = `SELECT id, event_id, block_height, "type", payload, attempts, coalesce(last_error, ''), created_at
+FROM webhook_dead_letters
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2`
+	rows, err := p.db.Query(query, webhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*types.WebhookDeadLetter
+	for rows.Next() {
+		item := &types.WebhookDeadLetter{}
+		var payload []byte
+		var createdAt int64
+		if err := rows.Scan(
+			&item.Id,
+			&item.Message.Id,
+			&item.Message.Height,
+			&item.Message.Type,
+			&payload,
+			&item.Attempts,
+			&item.LastError,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		item.Message.Data = json.RawMessage(payload)
+		item.CreatedAt = time.Unix(createdAt, 0).UTC()
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) EventsToFanOut(limit int) ([]*Event, error) {
+	const query = `SELECT e.id, e.block_height, e."type", e.addresses, coalesce(e.tx_type, ''), coalesce(e.contract, ''), e.payload
+FROM webhook_events e
+WHERE e.id > (SELECT last_event_id FROM webhook_fan_out_state)
+ORDER BY e.id
+LIMIT $1`
+	rows, err := p.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Event
+	for rows.Next() {
+		item := &Event{}
+		if err := rows.Scan(
+			&item.Id,
+			&item.Height,
+			&item.Type,
+			pq.Array(&item.Addresses),
+			&item.TxType,
+			&item.Contract,
+			(*[]byte)(&item.Payload),
+		); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) SaveDeliveries(lastEventId uint64, deliveries []NewDelivery) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE webhook_fan_out_state SET last_event_id = $1", lastEventId); err != nil {
+		return errors.Wrap(err, "failed to update fan out state")
+	}
+	const query = `INSERT INTO webhook_deliveries (webhook_id, event_id, state, next_attempt_at)
+VALUES ($1, $2, $3, 0)`
+	for _, delivery := range deliveries {
+		if _, err := tx.Exec(query, delivery.WebhookId, delivery.EventId, deliveryStatePending); err != nil {
+			return errors.Wrap(err, "failed to insert delivery")
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) ClaimDeliveries(now, leaseUntil time.Time, limit int) ([]*Delivery, error) {
+	const query = `WITH claimed AS (
+    UPDATE webhook_deliveries
+        SET attempts = attempts + 1, next_attempt_at = $2
+        WHERE id IN (SELECT d.id
+                     FROM webhook_deliveries d
+                              JOIN webhooks w ON w.id = d.webhook_id
+                              JOIN webhook_events e ON e.id = d.event_id
+                     WHERE d.state = $3
+                       AND d.next_attempt_at <= $1
+                       AND (NOT w.finalized OR e.block_height <= finalized_height())
+                     ORDER BY d.id
+                     LIMIT $4 FOR UPDATE OF d SKIP LOCKED)
+        RETURNING id, webhook_id, event_id, attempts)
+SELECT c.id, c.attempts, w.url, w.secret, e.id, e.block_height, e."type", e.payload
+FROM claimed c
+         JOIN webhooks w ON w.id = c.webhook_id
+         JOIN webhook_events e ON e.id = c.event_id
+ORDER BY c.id`
+	rows, err := p.db.Query(query, now.Unix(), leaseUntil.Unix(), deliveryStatePending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []*Delivery
+	for rows.Next() {
+		item := &Delivery{
+			Event: &Event{},
+		}
+		if err := rows.Scan(
+			&item.Id,
+			&item.Attempts,
+			&item.Url,
+			&item.Secret,
+			&item.Event.Id,
+			&item.Event.Height,
+			&item.Event.Type,
+			(*[]byte)(&item.Event.Payload),
+		); err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (p *Postgres) CompleteDelivery(id uint64, deliveredAt time.Time) error {
+	const query = `UPDATE webhook_deliveries
+SET state        = $2,
+    delivered_at = $3,
+    last_error   = null
+WHERE id = $1`
+	_, err := p.db.Exec(query, id, deliveryStateDelivered, deliveredAt.Unix())
+	return err
+}
+
+func (p *Postgres) RetryDelivery(id uint64, nextAttemptAt time.Time, lastError string) error {
+	const query = `UPDATE webhook_deliveries
+SET next_attempt_at = $2,
+    last_error      = $3
+WHERE id = $1`
+	_, err := p.db.Exec(query, id, nextAttemptAt.Unix(), lastError)
+	return err
+}
+
+func (p *Postgres) MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	const insertQuery = `INSERT INTO webhook_dead_letters (webhook_id, event_id, block_height, "type", payload, attempts,
+                                  last_error, created_at)
+SELECT d.webhook_id, e.id, e.block_height, e."type", e.payload, d.attempts, $2, $3
+FROM webhook_deliveries d
+         JOIN webhook_events e ON e.id = d.event_id
+WHERE d.id = $1`
+	if _, err := tx.Exec(insertQuery, id, lastError, createdAt.Unix()); err != nil {
+		return errors.Wrap(err, "failed to insert dead letter")
+	}
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE id = $1", id); err != nil {
+		return errors.Wrap(err, "failed to delete delivery")
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) DeleteDelivered(deliveredBefore time.Time) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE state = $1 AND delivered_at < $2",
+		deliveryStateDelivered, deliveredBefore.Unix()); err != nil {
+		return errors.Wrap(err, "failed to delete deliveries")
+	}
+	// fanned out events without deliveries are not needed to compensate reverted blocks anymore
+	const deleteEventsQuery = `DELETE
+FROM webhook_events e
+WHERE e.id <= (SELECT last_event_id FROM webhook_fan_out_state)
+  AND NOT exists(SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id)`
+	if _, err := tx.Exec(deleteEventsQuery); err != nil {
+		return errors.Wrap(err, "failed to delete events")
+	}
+	return tx.Commit()
+}
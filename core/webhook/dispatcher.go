@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/idena-network/idena-indexer/core/health"
+	"github.com/idena-network/idena-indexer/core/text"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventIdHeader   = "X-Webhook-Event-Id"
+
+	maxUrlLength       = 500
+	maxLastErrorLength = 200
+	cleanUpInterval    = time.Hour
+)
+
+type Config struct {
+	// BatchSize is the max number of events to fan out and deliveries to send at a time
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which the delivery is moved to dead letters
+	MaxAttempts int
+	// RetryInterval is the delay before the first retry, it is doubled for each next one up to MaxRetryInterval
+	RetryInterval      time.Duration
+	MaxRetryInterval   time.Duration
+	RequestTimeout     time.Duration
+	DeliveredRetention time.Duration
+}
+
+// Dispatcher sends signed events saved along with indexed blocks to registered webhooks
+type Dispatcher struct {
+	db     Db
+	conf   Config
+	client *http.Client
+	logger log.Logger
+	health *health.Component
+	now    func() time.Time
+
+	lastCleanUp time.Time
+}
+
+func NewDispatcher(db Db, conf Config, logger log.Logger, health *health.Component) *Dispatcher {
+	return &Dispatcher{
+		db:   db,
+		conf: conf,
+		client: &http.Client{
+			Timeout: conf.RequestTimeout,
+		},
+		logger: logger,
+		health: health,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+func (d *Dispatcher) Start() {
+	go d.loop()
+}
+
+// Register saves a new webhook, the returned webhook contains the secret to verify request signatures, it is not
+// available later
+func (d *Dispatcher) Register(webhookUrl string, webhookFilter types.WebhookFilter) (res *types.Webhook, usrErr, err error) {
+	if usrErr = validateUrl(webhookUrl); usrErr != nil {
+		return nil, usrErr, nil
+	}
+	filter, usrErr := NewFilter(webhookFilter)
+	if usrErr != nil {
+		return nil, usrErr, nil
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate secret")
+	}
+	createdAt := d.now().Truncate(time.Second)
+	id, err := d.db.SaveWebhook(webhookUrl, secret, filter, createdAt)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to save webhook")
+	}
+	return &types.Webhook{
+		Id:     id,
+		Url:    webhookUrl,
+		Secret: secret,
+		Filter: types.WebhookFilter{
+			Events:    keys(filter.events),
+			Addresses: keys(filter.addresses),
+			TxTypes:   keys(filter.txTypes),
+			Contracts: keys(filter.contracts),
+			Finalized: filter.finalized,
+		},
+		CreatedAt: createdAt,
+	}, nil, nil
+}
+
+func (d *Dispatcher) Delete(id uint64) (usrErr, err error) {
+	deleted, err := d.db.DeleteWebhook(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete webhook")
+	}
+	if !deleted {
+		return errors.Errorf("webhook %v not found", id), nil
+	}
+	return nil, nil
+}
+
+func (d *Dispatcher) Webhooks() ([]*types.Webhook, error) {
+	return d.db.Webhooks()
+}
+
+func (d *Dispatcher) DeadLetters(webhookId uint64, limit int) ([]*types.WebhookDeadLetter, error) {
+	return d.db.DeadLetters(webhookId, limit)
+}
+
+func validateUrl(webhookUrl string) error {
+	if len(webhookUrl) > maxUrlLength {
+		return errors.Errorf("too long url, max allowed length is %v", maxUrlLength)
+	}
+	u, err := url.Parse(webhookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.Errorf("wrong url %v", webhookUrl)
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign returns signature of the request body sent at timestamp, receivers should compare it with SignatureHeader value
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) loop() {
+	for {
+		fannedOut, sent, err := d.dispatch()
+		if err != nil {
+			d.logger.Warn(fmt.Sprintf("failed to dispatch webhook events: %v", err))
+			d.health.Fail(err)
+		} else {
+			d.health.Ok()
+		}
+		if err != nil || fannedOut < d.conf.BatchSize && sent < d.conf.BatchSize {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch() (fannedOut, sent int, err error) {
+	if fannedOut, err = d.fanOut(); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to fan out events")
+	}
+	if sent, err = d.deliver(); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to deliver events")
+	}
+	if time.Since(d.lastCleanUp) >= cleanUpInterval {
+		if err = d.db.DeleteDelivered(d.now().Add(-d.conf.DeliveredRetention)); err != nil {
+			return 0, 0, errors.Wrap(err, "failed to delete delivered events")
+		}
+		d.lastCleanUp = time.Now()
+	}
+	return fannedOut, sent, nil
+}
+
+func (d *Dispatcher) fanOut() (int, error) {
+	events, err := d.db.EventsToFanOut(d.conf.BatchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get events")
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	webhooks, err := d.db.Webhooks()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get webhooks")
+	}
+	filters := make([]*Filter, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		filter, err := NewFilter(webhook.Filter)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to load webhook %v filter", webhook.Id)
+		}
+		filters = append(filters, filter)
+	}
+	var deliveries []NewDelivery
+	for _, event := range events {
+		for i, filter := range filters {
+			if filter.Match(event) {
+				deliveries = append(deliveries, NewDelivery{
+					WebhookId: webhooks[i].Id,
+					EventId:   event.Id,
+				})
+			}
+		}
+	}
+	if err := d.db.SaveDeliveries(events[len(events)-1].Id, deliveries); err != nil {
+		return 0, errors.Wrap(err, "failed to save deliveries")
+	}
+	return len(events), nil
+}
+
+func (d *Dispatcher) deliver() (int, error) {
+	now := d.now()
+	deliveries, err := d.db.ClaimDeliveries(now, now.Add(d.conf.RequestTimeout*2), d.conf.BatchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to claim deliveries")
+	}
+	sendErrs := make([]error, len(deliveries))
+	wg := sync.WaitGroup{}
+	wg.Add(len(deliveries))
+	for i, delivery := range deliveries {
+		go func(i int, delivery *Delivery) {
+			defer wg.Done()
+			sendErrs[i] = d.send(delivery)
+		}(i, delivery)
+	}
+	wg.Wait()
+	for i, delivery := range deliveries {
+		if err := d.completeDelivery(delivery, sendErrs[i]); err != nil {
+			return 0, errors.Wrapf(err, "failed to complete delivery %v", delivery.Id)
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) completeDelivery(delivery *Delivery, sendErr error) error {
+	now := d.now()
+	if sendErr == nil {
+		return d.db.CompleteDelivery(delivery.Id, now)
+	}
+	lastError := text.Truncate(sendErr.Error(), maxLastErrorLength)
+	if delivery.Attempts >= d.conf.MaxAttempts {
+		d.logger.Warn(fmt.Sprintf("webhook event %v moved to dead letters after %v attempts: %v", delivery.Event.Id,
+			delivery.Attempts, lastError))
+		return d.db.MoveToDeadLetters(delivery.Id, lastError, now)
+	}
+	d.logger.Debug(fmt.Sprintf("failed to send webhook event %v, attempt %v: %v", delivery.Event.Id, delivery.Attempts,
+		lastError))
+	return d.db.RetryDelivery(delivery.Id, now.Add(d.retryDelay(delivery.Attempts)), lastError)
+}
+
+func (d *Dispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.conf.RetryInterval
+	for i := 1; i < attempts && delay < d.conf.MaxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > d.conf.MaxRetryInterval {
+		delay = d.conf.MaxRetryInterval
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(delivery *Delivery) error {
+	body, err := json.Marshal(types.WebhookMessage{
+		Id:     delivery.Event.Id,
+		Type:   delivery.Event.Type,
+		Height: delivery.Event.Height,
+		Data:   delivery.Event.Payload,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal message")
+	}
+	req, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIdHeader, strconv.FormatUint(delivery.Event.Id, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected response status %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testDb struct {
+	webhooks    []*types.Webhook
+	events      []*Event
+	lastEventId uint64
+	deliveries  []NewDelivery
+	claimed     []*Delivery
+	completed   []uint64
+	retried     map[uint64]time.Time
+	deadLetters []uint64
+}
+
+func (db *testDb) SaveWebhook(url, secret string, filter *Filter, createdAt time.Time) (uint64, error) {
+	db.webhooks = append(db.webhooks, &types.Webhook{Id: uint64(len(db.webhooks) + 1), Url: url})
+	return uint64(len(db.webhooks)), nil
+}
+
+func (db *testDb) DeleteWebhook(id uint64) (bool, error) {
+	return id <= uint64(len(db.webhooks)), nil
+}
+
+func (db *testDb) Webhooks() ([]*types.Webhook, error) {
+	return db.webhooks, nil
+}
+
+func (db *testDb) DeadLetters(webhookId uint64, limit int) ([]*types.WebhookDeadLetter, error) {
+	return nil, nil
+}
+
+func (db *testDb) EventsToFanOut(limit int) ([]*Event, error) {
+	var res []*Event
+	for _, event := range db.events {
+		if event.Id > db.lastEventId && len(res) < limit {
+			res = append(res, event)
+		}
+	}
+	return res, nil
+}
+
+func (db *testDb) SaveDeliveries(lastEventId uint64, deliveries []NewDelivery) error {
+	db.lastEventId = lastEventId
+	db.deliveries = append(db.deliveries, deliveries...)
+	return nil
+}
+
+func (db *testDb) ClaimDeliveries(now, leaseUntil time.Time, limit int) ([]*Delivery, error) {
+	res := db.claimed
+	db.claimed = nil
+	return res, nil
+}
+
+func (db *testDb) CompleteDelivery(id uint64, deliveredAt time.Time) error {
+	db.completed = append(db.completed, id)
+	return nil
+}
+
+func (db *testDb) RetryDelivery(id uint64, nextAttemptAt time.Time, lastError string) error {
+	db.retried[id] = nextAttemptAt
+	return nil
+}
+
+func (db *testDb) MoveToDeadLetters(id uint64, lastError string, createdAt time.Time) error {
+	db.deadLetters = append(db.deadLetters, id)
+	return nil
+}
+
+func (db *testDb) DeleteDelivered(deliveredBefore time.Time) error {
+	return nil
+}
+
+func newTestDispatcher(db Db) *Dispatcher {
+	dispatcher := NewDispatcher(db, Config{
+		BatchSize:        2,
+		MaxAttempts:      3,
+		RetryInterval:    time.Second * 10,
+		MaxRetryInterval: time.Second * 30,
+		RequestTimeout:   time.Second,
+	}, log.New(), nil)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time {
+		return now
+	}
+	return dispatcher
+}
+
+func Test_DispatcherRegister(t *testing.T) {
+	dispatcher := newTestDispatcher(&testDb{})
+
+	_, usrErr, err := dispatcher.Register("ftp://example.com", types.WebhookFilter{})
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+
+	_, usrErr, err = dispatcher.Register("https://example.com/hook", types.WebhookFilter{Events: []string{"unknown"}})
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+
+	webhook, usrErr, err := dispatcher.Register("https://example.com/hook", types.WebhookFilter{Addresses: []string{"0xABC"}})
+	require.Nil(t, err)
+	require.Nil(t, usrErr)
+	require.Equal(t, uint64(1), webhook.Id)
+	require.Len(t, webhook.Secret, 64)
+	require.Equal(t, []string{"0xabc"}, webhook.Filter.Addresses)
+
+	usrErr, err = dispatcher.Delete(2)
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+}
+
+func Test_DispatcherFanOut(t *testing.T) {
+	webhookDb := &testDb{
+		webhooks: []*types.Webhook{
+			{Id: 1, Filter: types.WebhookFilter{Addresses: []string{"0xAAA"}}},
+			{Id: 2, Filter: types.WebhookFilter{Events: []string{db.WebhookEventPenalty}}},
+		},
+		events: []*Event{
+			{Id: 1, Type: db.WebhookEventTransaction, Addresses: []string{"0xaaa"}},
+			{Id: 2, Type: db.WebhookEventPenalty, Addresses: []string{"0xaaa"}},
+			{Id: 5, Type: db.WebhookEventReverted},
+		},
+	}
+	dispatcher := newTestDispatcher(webhookDb)
+
+	cnt, err := dispatcher.fanOut()
+	require.Nil(t, err)
+	require.Equal(t, 2, cnt)
+	require.Equal(t, uint64(2), webhookDb.lastEventId)
+	require.Equal(t, []NewDelivery{{1, 1}, {1, 2}, {2, 2}}, webhookDb.deliveries)
+
+	cnt, err = dispatcher.fanOut()
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	require.Equal(t, uint64(5), webhookDb.lastEventId)
+	require.Len(t, webhookDb.deliveries, 3)
+
+	cnt, err = dispatcher.fanOut()
+	require.Nil(t, err)
+	require.Zero(t, cnt)
+}
+
+func Test_DispatcherDeliver(t *testing.T) {
+	var mutex sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	newDelivery := func(id uint64, attempts int, path string) *Delivery {
+		return &Delivery{
+			Id:       id,
+			Attempts: attempts,
+			Url:      server.URL + path,
+			Secret:   "secret",
+			Event: &Event{
+				Id:      id * 10,
+				Height:  100,
+				Type:    db.WebhookEventPenalty,
+				Payload: json.RawMessage(`{"address":"0xaaa"}`),
+			},
+		}
+	}
+	webhookDb := &testDb{
+		retried: make(map[uint64]time.Time),
+	}
+	dispatcher := newTestDispatcher(webhookDb)
+	dispatcher.client = server.Client()
+
+	webhookDb.claimed = []*Delivery{newDelivery(1, 1, "/ok")}
+	cnt, err := dispatcher.deliver()
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	require.Equal(t, []uint64{1}, webhookDb.completed)
+
+	require.Len(t, received, 1)
+	require.Equal(t, "10", received[0].Header.Get(EventIdHeader))
+	timestamp, err := strconv.ParseInt(received[0].Header.Get(TimestampHeader), 10, 64)
+	require.Nil(t, err)
+	require.Equal(t, Sign("secret", timestamp, bodies[0]), received[0].Header.Get(SignatureHeader))
+	var message struct {
+		Id     uint64          `json:"id"`
+		Type   string          `json:"type"`
+		Height uint64          `json:"height"`
+		Data   json.RawMessage `json:"data"`
+	}
+	require.Nil(t, json.Unmarshal(bodies[0], &message))
+	require.Equal(t, uint64(10), message.Id)
+	require.Equal(t, db.WebhookEventPenalty, message.Type)
+	require.Equal(t, uint64(100), message.Height)
+	require.JSONEq(t, `{"address":"0xaaa"}`, string(message.Data))
+
+	webhookDb.claimed = []*Delivery{newDelivery(2, 1, "/fail"), newDelivery(3, 2, "/fail"), newDelivery(4, 3, "/fail")}
+	_, err = dispatcher.deliver()
+	require.Nil(t, err)
+	now := dispatcher.now()
+	require.Equal(t, map[uint64]time.Time{
+		2: now.Add(time.Second * 10),
+		3: now.Add(time.Second * 20),
+	}, webhookDb.retried)
+	require.Equal(t, []uint64{4}, webhookDb.deadLetters)
+	require.Equal(t, []uint64{1}, webhookDb.completed)
+}
+
+func Test_DispatcherRetryDelay(t *testing.T) {
+	dispatcher := newTestDispatcher(&testDb{})
+	require.Equal(t, time.Second*10, dispatcher.retryDelay(1))
+	require.Equal(t, time.Second*20, dispatcher.retryDelay(2))
+	require.Equal(t, time.Second*30, dispatcher.retryDelay(3))
+	require.Equal(t, time.Second*30, dispatcher.retryDelay(100))
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const maxFilterValues = 100
+
+var eventTypes = map[string]struct{}{
+	db.WebhookEventTransaction:             {},
+	db.WebhookEventIdentityStateChange:     {},
+	db.WebhookEventPenalty:                 {},
+	db.WebhookEventOracleVotingStateChange: {},
+}
+
+// Filter selects events to send to a webhook. Empty criteria match everything, values within a criterion are combined
+// with OR and different criteria are combined with AND. Tx type criterion is applied to transactions only, events
+// without a contract don't match contract criterion.
+type Filter struct {
+	events    map[string]struct{}
+	addresses map[string]struct{}
+	txTypes   map[string]struct{}
+	contracts map[string]struct{}
+	// finalized is applied when deliveries are claimed since events are fanned out before their blocks are final
+	finalized bool
+}
+
+func NewFilter(filter types.WebhookFilter) (*Filter, error) {
+	for _, values := range [][]string{filter.Events, filter.Addresses, filter.TxTypes, filter.Contracts} {
+		if len(values) > maxFilterValues {
+			return nil, errors.Errorf("too many filter values, max allowed count is %v", maxFilterValues)
+		}
+	}
+	res := &Filter{
+		events:    toSet(filter.Events, false),
+		addresses: toSet(filter.Addresses, true),
+		txTypes:   toSet(filter.TxTypes, false),
+		contracts: toSet(filter.Contracts, true),
+		finalized: filter.Finalized,
+	}
+	for event := range res.events {
+		if _, ok := eventTypes[event]; !ok {
+			return nil, errors.Errorf("unknown event %v", event)
+		}
+	}
+	return res, nil
+}
+
+func toSet(values []string, lower bool) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+		if lower {
+			value = strings.ToLower(value)
+		}
+		res[value] = struct{}{}
+	}
+	return res
+}
+
+func keys(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(set))
+	for value := range set {
+		res = append(res, value)
+	}
+	return res
+}
+
+func (f *Filter) Match(event *Event) bool {
+	// reverted events are addressed to webhooks when they are created
+	if event.Type == db.WebhookEventReverted {
+		return false
+	}
+	if len(f.events) > 0 {
+		if _, ok := f.events[event.Type]; !ok {
+			return false
+		}
+	}
+	if len(f.addresses) > 0 {
+		matched := false
+		for _, address := range event.Addresses {
+			if _, ok := f.addresses[strings.ToLower(address)]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.txTypes) > 0 && event.Type == db.WebhookEventTransaction {
+		if _, ok := f.txTypes[event.TxType]; !ok {
+			return false
+		}
+	}
+	if len(f.contracts) > 0 {
+		if _, ok := f.contracts[strings.ToLower(event.Contract)]; !ok {
+			return false
+		}
+	}
+	return true
+}
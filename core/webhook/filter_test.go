@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_NewFilter(t *testing.T) {
+	_, err := NewFilter(types.WebhookFilter{Events: []string{db.WebhookEventTransaction, db.WebhookEventReverted}})
+	require.NotNil(t, err)
+
+	_, err = NewFilter(types.WebhookFilter{Addresses: make([]string, maxFilterValues+1)})
+	require.NotNil(t, err)
+
+	filter, err := NewFilter(types.WebhookFilter{Addresses: []string{" 0xAbC", ""}})
+	require.Nil(t, err)
+	require.Equal(t, []string{"0xabc"}, keys(filter.addresses))
+	require.Nil(t, filter.events)
+}
+
+func Test_FilterMatch(t *testing.T) {
+	sendTx := &Event{Type: db.WebhookEventTransaction, Addresses: []string{"0xAAA", "0xBBB"}, TxType: "SendTx"}
+	callTx := &Event{Type: db.WebhookEventTransaction, Addresses: []string{"0xCCC", "0xDDD"}, TxType: "CallContract", Contract: "0xDDD"}
+	identityStateChange := &Event{Type: db.WebhookEventIdentityStateChange, Addresses: []string{"0xAAA"}}
+	penalty := &Event{Type: db.WebhookEventPenalty, Addresses: []string{"0xCCC"}}
+	oracleVoting := &Event{Type: db.WebhookEventOracleVotingStateChange, Addresses: []string{"0xCCC"}, Contract: "0xDDD"}
+	reverted := &Event{Type: db.WebhookEventReverted}
+	all := []*Event{sendTx, callTx, identityStateChange, penalty, oracleVoting, reverted}
+
+	for _, tc := range []struct {
+		name     string
+		filter   types.WebhookFilter
+		expected []*Event
+	}{
+		{
+			name:     "empty",
+			expected: []*Event{sendTx, callTx, identityStateChange, penalty, oracleVoting},
+		},
+		{
+			name:     "events",
+			filter:   types.WebhookFilter{Events: []string{db.WebhookEventPenalty, db.WebhookEventIdentityStateChange}},
+			expected: []*Event{identityStateChange, penalty},
+		},
+		{
+			name:     "address",
+			filter:   types.WebhookFilter{Addresses: []string{"0xaaa"}},
+			expected: []*Event{sendTx, identityStateChange},
+		},
+		{
+			name:     "tx type",
+			filter:   types.WebhookFilter{TxTypes: []string{"CallContract"}},
+			expected: []*Event{callTx, identityStateChange, penalty, oracleVoting},
+		},
+		{
+			name:     "contract",
+			filter:   types.WebhookFilter{Contracts: []string{"0xddd"}},
+			expected: []*Event{callTx, oracleVoting},
+		},
+		{
+			name:     "address and event",
+			filter:   types.WebhookFilter{Events: []string{db.WebhookEventTransaction}, Addresses: []string{"0xccc", "0xbbb"}},
+			expected: []*Event{sendTx, callTx},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewFilter(tc.filter)
+			require.Nil(t, err)
+			var matched []*Event
+			for _, event := range all {
+				if filter.Match(event) {
+					matched = append(matched, event)
+				}
+			}
+			require.Equal(t, tc.expected, matched)
+		})
+	}
+}
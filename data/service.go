@@ -4,16 +4,24 @@ import (
 	"fmt"
 	"github.com/idena-network/idena-go/common/eventbus"
 	state2 "github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/core/health"
 	"github.com/idena-network/idena-indexer/events"
 	"github.com/idena-network/idena-indexer/log"
 	"github.com/pkg/errors"
+	"sync"
 	"time"
 )
 
-func StartDataService(eventBus eventbus.Bus, dbAccessor DbAccessor, logger log.Logger) {
-	service := serviceImpl{
+type Service interface {
+	// Refresh refreshes the data immediately keeping its next scheduled refresh
+	Refresh(name string) (usrErr, err error)
+}
+
+func StartDataService(eventBus eventbus.Bus, dbAccessor DbAccessor, logger log.Logger, health *health.Component) Service {
+	service := &serviceImpl{
 		dbAccessor: dbAccessor,
 		logger:     logger,
+		health:     health,
 	}
 	eventBus.Subscribe(events.CurrentEpochEventId, func(e eventbus.Event) {
 		currentEpochEvent := e.(*events.CurrentEpochEvent)
@@ -35,12 +43,16 @@ func StartDataService(eventBus eventbus.Bus, dbAccessor DbAccessor, logger log.L
 		service.state.epochPeriod = newBlockEvent.EpochPeriod
 	})
 	go service.observe()
+	return service
 }
 
 type serviceImpl struct {
 	dbAccessor DbAccessor
 	configFile string
 	logger     log.Logger
+	health     *health.Component
+	// refreshMutex prevents refreshes requested by admins from running concurrently with scheduled ones
+	refreshMutex sync.Mutex
 
 	state *state
 }
@@ -62,13 +74,18 @@ func (service *serviceImpl) observe() {
 		}
 		if service.state == nil {
 			service.logger.Info("State not initialized yet to check data list")
+			service.health.Ok()
 			continue
 		}
+		service.refreshMutex.Lock()
 		dataList, err := service.dbAccessor.GetDataList()
 		if err != nil {
+			service.refreshMutex.Unlock()
 			service.logger.Warn(errors.Wrap(err, "unable to get data list").Error())
+			service.health.Fail(err)
 			continue
 		}
+		service.health.Ok()
 		service.logger.Debug("Checking data list")
 		now := time.Now().UTC()
 		for _, dataItem := range dataList {
@@ -144,5 +161,31 @@ func (service *serviceImpl) observe() {
 			}
 			service.logger.Error(fmt.Sprintf("Unknown refresh period: %v", *dataItem.RefreshPeriod))
 		}
+		service.refreshMutex.Unlock()
+	}
+}
+
+func (service *serviceImpl) Refresh(name string) (usrErr, err error) {
+	service.refreshMutex.Lock()
+	defer service.refreshMutex.Unlock()
+	dataList, err := service.dbAccessor.GetDataList()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get data list")
+	}
+	for _, dataItem := range dataList {
+		if dataItem.Name != name {
+			continue
+		}
+		if dataItem.RefreshProcedure == nil {
+			return errors.Errorf("data %v has no refresh procedure", name), nil
+		}
+		start := time.Now()
+		if err := service.dbAccessor.Refresh(dataItem.Name, *dataItem.RefreshProcedure, time.Now().UTC(),
+			dataItem.RefreshTime, dataItem.RefreshEpoch); err != nil {
+			return nil, errors.Wrapf(err, "unable to refresh %v", name)
+		}
+		service.logger.Info(fmt.Sprintf("Refreshed on request, name: %v", dataItem.Name), "d", time.Since(start))
+		return nil, nil
 	}
+	return errors.Errorf("data %v not found", name), nil
 }
@@ -1,8 +1,10 @@
 package db
 
 import (
+	gocontext "context"
 	"github.com/idena-network/idena-go/common"
 	data2 "github.com/idena-network/idena-indexer/data"
+	"github.com/idena-network/idena-indexer/monitoring"
 	"math/big"
 	"time"
 )
@@ -12,6 +14,8 @@ type Accessor interface {
 
 	GetLastHeight() (uint64, error)
 	Save(data *Data) error
+	// SaveBatch saves data of consecutive blocks in a single transaction reporting sections to the passed monitor
+	SaveBatch(data []*Data, pm monitoring.PerformanceMonitor) error
 	SaveRestoredData(data *RestoredData) error
 	SaveMemPoolData(data *MemPoolData) error
 
@@ -39,6 +43,9 @@ type Accessor interface {
 	BalanceUpdateGapCnt() (int, error)
 	BurntCoinsInconsistencyCnt() (int, error)
 
+	Ping(ctx gocontext.Context) error
 	Destroy()
 	ResetTo(height uint64) error
+	// ResetToWithHistory resets data like ResetTo and records the reset in the reorg history
+	ResetToWithHistory(height uint64, newTipHash string, timestamp time.Time) (*Reorg, error)
 }
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/core/appstate"
+	"github.com/pkg/errors"
+)
+
+// Plugin processes indexed blocks within the transactions saving the block data, so tables of the plugin are always
+// consistent with the indexer ones. An error returned by the plugin fails saving of the block which is retried until
+// succeeded.
+type Plugin interface {
+	// Name identifies the plugin in logs and errors
+	Name() string
+	// Init creates or migrates tables of the plugin, it is called on start once the indexer schema is initialized
+	Init(tx *sql.Tx) error
+	// ProcessBlock is called once the block data is saved, data.Source holds the incoming block and the states. Raws of
+	// txs and proposer vrf scores of blocks saved in batches in catch-up mode are not in the db yet at the moment.
+	ProcessBlock(tx *sql.Tx, data *Data) error
+	// ResetTo deletes the plugin data of blocks with heights greater than the height
+	ResetTo(tx *sql.Tx, height uint64) error
+}
+
+// BlockSource holds the incoming block and the states the block data is converted from
+type BlockSource struct {
+	Block     *types.Block
+	PrevState *appstate.AppState
+	NewState  *appstate.AppState
+}
+
+func (a *postgresAccessor) initPlugins() error {
+	if len(a.plugins) == 0 {
+		return nil
+	}
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, plugin := range a.plugins {
+		if err := plugin.Init(tx); err != nil {
+			return errors.Wrapf(err, "unable to init plugin %v", plugin.Name())
+		}
+	}
+	return tx.Commit()
+}
+
+func (a *postgresAccessor) processPlugins(tx *sql.Tx, data *Data) error {
+	for _, plugin := range a.plugins {
+		if err := plugin.ProcessBlock(tx, data); err != nil {
+			return errors.Wrapf(err, "plugin %v failed to process block", plugin.Name())
+		}
+	}
+	return nil
+}
+
+func (a *postgresAccessor) resetPlugins(tx *sql.Tx, height uint64) error {
+	for _, plugin := range a.plugins {
+		if err := plugin.ResetTo(tx, height); err != nil {
+			return errors.Wrapf(err, "plugin %v failed to reset", plugin.Name())
+		}
+	}
+	return nil
+}
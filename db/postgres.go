@@ -1,6 +1,7 @@
 package db
 
 import (
+	gocontext "context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
@@ -23,7 +24,11 @@ type postgresAccessor struct {
 	mutex                     sync.Mutex
 	changesHistoryBlocksCount int
 	miningRewards             bool
+	webhooks                  bool
+	finalityConfirmations     uint64
+	balanceCheckpointInterval uint64
 	dataTable, dataStateTable string
+	plugins                   []Plugin
 }
 
 const (
@@ -42,6 +47,12 @@ const (
 	insertCoinsQuery                    = "insertCoins.sql"
 	insertPenaltyQuery                  = "insertPenalty.sql"
 	insertMiningRewardsQuery            = "insertMiningRewards.sql"
+	saveWebhookEventsQuery              = "saveWebhookEvents.sql"
+	saveReorgQuery                      = "saveReorg.sql"
+	selectReorgQuery                    = "selectReorg.sql"
+	saveReincludedTxsQuery              = "saveReincludedTxs.sql"
+	saveFinalizedHeightQuery            = "saveFinalizedHeight.sql"
+	saveBalanceCheckpointQuery          = "saveBalanceCheckpoint.sql"
 	insertBurntCoinsQuery               = "insertBurntCoins.sql"
 	saveEpochResultQuery                = "saveEpochResult.sql"
 	saveFlipsWordsQuery                 = "saveFlipsWords.sql"
@@ -64,8 +75,18 @@ func (a *postgresAccessor) GetLastHeight() (uint64, error) {
 }
 
 func (a *postgresAccessor) ResetTo(height uint64) error {
-	_, err := a.db.Exec(a.getQuery(resetToBlockQuery), height)
-	return getResultError(err)
+	tx, err := a.db.Begin()
+	if err != nil {
+		return getResultError(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(a.getQuery(resetToBlockQuery), height); err != nil {
+		return getResultError(err)
+	}
+	if err = a.resetPlugins(tx, height); err != nil {
+		return getResultError(err)
+	}
+	return getResultError(tx.Commit())
 }
 
 func (a *postgresAccessor) Save(data *Data) error {
@@ -81,25 +102,75 @@ func (a *postgresAccessor) Save(data *Data) error {
 
 	a.pm.Complete("InitTx")
 	a.pm.Start("RunTx")
+	if err = a.saveData(tx, data, nil, a.pm); err != nil {
+		return getResultError(err)
+	}
+	a.pm.Complete("RunTx")
+	a.pm.Start("CommitTx")
+	defer a.pm.Complete("CommitTx")
+	return tx.Commit()
+}
+
+// SaveBatch saves data of several consecutive blocks in a single transaction, append-only tables are loaded with COPY
+// after all blocks are processed, sections are reported to pm since batches are saved by another goroutine than the one
+// the accessor monitor tracks
+func (a *postgresAccessor) SaveBatch(data []*Data, pm monitoring.PerformanceMonitor) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	pm.Start("InitTx")
+	tx, err := a.db.Begin()
+	if err != nil {
+		return getResultError(err)
+	}
+	defer tx.Rollback()
+
+	pm.Complete("InitTx")
+	pm.Start("RunTx")
+	bulk := &bulkCopy{}
+	for _, blockData := range data {
+		if err = a.saveData(tx, blockData, bulk, pm); err != nil {
+			return getResultError(errors.Wrapf(err, "unable to save block %v", blockData.Block.Height))
+		}
+	}
+	pm.Start("copy")
+	if err = bulk.copy(tx); err != nil {
+		return getResultError(err)
+	}
+	pm.Complete("copy")
+	pm.Complete("RunTx")
+	pm.Start("CommitTx")
+	defer pm.Complete("CommitTx")
+	return tx.Commit()
+}
+
+// saveData saves block data within the transaction, if bulk is not nil then rows of append-only tables are collected
+// to be copied later
+func (a *postgresAccessor) saveData(tx *sql.Tx, data *Data, bulk *bulkCopy, pm monitoring.PerformanceMonitor) error {
+	var err error
 	ctx := newContext(a, tx, data.Epoch, data.Block.Height)
+	txs := data.Block.Transactions
+	if bulk != nil {
+		txs = withoutRaws(txs)
+	}
 
-	a.pm.Start("saveEpoch")
+	pm.Start("saveEpoch")
 	if err = a.saveEpoch(ctx, data.Epoch, data.ValidationTime, data.PrevStateRoot, data.DiscriminationStakeThreshold); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveEpoch")
+	pm.Complete("saveEpoch")
 
-	a.pm.Start("saveBlock")
+	pm.Start("saveBlock")
 	if err = a.saveBlock(ctx, data.Block); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveBlock")
+	pm.Complete("saveBlock")
 
-	a.pm.Start("saveAddressesAndTransactions")
+	pm.Start("saveAddressesAndTransactions")
 	if ctx.txIdsPerHash, err = a.saveAddressesAndTransactions(
 		ctx,
 		data.Addresses,
-		data.Block.Transactions,
+		txs,
 		data.ActivationTxTransfers,
 		data.KillTxTransfers,
 		data.KillInviteeTxTransfers,
@@ -148,82 +219,124 @@ func (a *postgresAccessor) Save(data *Data) error {
 		data.TokenBalanceUpdates,
 		data.DelegationHistoryUpdates,
 	); err != nil {
-		return getResultError(err)
+		return err
+	}
+	pm.Complete("saveAddressesAndTransactions")
+
+	pm.Start("saveReincludedTxs")
+	if err = a.saveReincludedTxs(ctx, data.Block.Transactions); err != nil {
+		return err
+	}
+	pm.Complete("saveReincludedTxs")
+	if bulk != nil {
+		if err = bulk.addTxRaws(ctx.txIdsPerHash, data.Block.Transactions); err != nil {
+			return err
+		}
 	}
-	a.pm.Complete("saveAddressesAndTransactions")
 
-	a.pm.Start("saveProposer")
+	pm.Start("saveProposer")
 	if err = a.saveProposer(ctx, data.Block.Proposer); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveProposer")
+	pm.Complete("saveProposer")
 
-	a.pm.Start("saveProposerVrfScore")
-	if err = a.saveProposerVrfScore(ctx, data.Block.ProposerVrfScore); err != nil {
-		return getResultError(err)
+	if bulk != nil {
+		bulk.addProposerVrfScore(ctx.blockHeight, data.Block.ProposerVrfScore)
+	} else {
+		pm.Start("saveProposerVrfScore")
+		if err = a.saveProposerVrfScore(ctx, data.Block.ProposerVrfScore); err != nil {
+			return err
+		}
+		pm.Complete("saveProposerVrfScore")
 	}
-	a.pm.Complete("saveProposerVrfScore")
 
-	a.pm.Start("saveCoins")
+	pm.Start("saveCoins")
 	if err := a.saveCoins(ctx, data.Coins); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveCoins")
+	pm.Complete("saveCoins")
 
-	a.pm.Start("saveBalances")
+	pm.Start("saveBalances")
 	if err := a.saveBalances(ctx.tx, ctx.blockHeight, data.ChangedBalances, data.BalanceUpdates, data.CommitteeRewardShare); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveBalances")
+	pm.Complete("saveBalances")
 
-	a.pm.Start("saveSubmittedFlips")
+	pm.Start("saveSubmittedFlips")
 	if err := a.saveSubmittedFlips(ctx, data.SubmittedFlips); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveSubmittedFlips")
+	pm.Complete("saveSubmittedFlips")
 
-	a.pm.Start("saveFlipKeys")
+	pm.Start("saveFlipKeys")
 	if err := a.saveFlipKeys(ctx, data.FlipKeys); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveFlipKeys")
+	pm.Complete("saveFlipKeys")
 
-	a.pm.Start("saveFlipsWords")
+	pm.Start("saveFlipsWords")
 	if err := a.saveFlipsWords(ctx, data.FlipsWords); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveFlipsWords")
+	pm.Complete("saveFlipsWords")
 
-	a.pm.Start("savePenalties")
+	pm.Start("savePenalties")
 	if err = a.savePenalties(ctx, data.Penalties); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("savePenalties")
+	pm.Complete("savePenalties")
 
 	if a.miningRewards {
-		a.pm.Start("saveMiningRewards")
+		pm.Start("saveMiningRewards")
 		if err = a.saveMiningRewards(ctx, data.MiningRewards); err != nil {
-			return getResultError(err)
+			return err
 		}
-		a.pm.Complete("saveMiningRewards")
+		pm.Complete("saveMiningRewards")
 	}
 
-	a.pm.Start("saveBurntCoins")
+	pm.Start("saveBurntCoins")
 	if err = a.saveBurntCoins(ctx, data.BurntCoinsPerAddr); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveBurntCoins")
+	pm.Complete("saveBurntCoins")
 
-	a.pm.Start("saveEpochResult")
+	pm.Start("saveEpochResult")
 	if err = a.saveEpochResult(ctx.tx, ctx.epoch, ctx.blockHeight, data.EpochResult); err != nil {
-		return getResultError(err)
+		return err
 	}
-	a.pm.Complete("saveEpochResult")
+	pm.Complete("saveEpochResult")
 
-	a.pm.Complete("RunTx")
-	a.pm.Start("CommitTx")
-	defer a.pm.Complete("CommitTx")
-	return tx.Commit()
+	if a.webhooks {
+		pm.Start("saveWebhookEvents")
+		if err = a.saveWebhookEvents(ctx, data); err != nil {
+			return err
+		}
+		pm.Complete("saveWebhookEvents")
+	}
+
+	if a.balanceCheckpointInterval > 0 && ctx.blockHeight%a.balanceCheckpointInterval == 0 {
+		pm.Start("saveBalanceCheckpoint")
+		if _, err = ctx.tx.Exec(a.getQuery(saveBalanceCheckpointQuery), ctx.blockHeight); err != nil {
+			return errors.Wrap(err, "unable to save balance checkpoint")
+		}
+		pm.Complete("saveBalanceCheckpoint")
+	}
+
+	if len(a.plugins) > 0 {
+		pm.Start("processPlugins")
+		if err = a.processPlugins(ctx.tx, data); err != nil {
+			return err
+		}
+		pm.Complete("processPlugins")
+	}
+
+	pm.Start("saveFinalizedHeight")
+	if _, err = ctx.tx.Exec(a.getQuery(saveFinalizedHeightQuery), ctx.blockHeight, a.finalityConfirmations); err != nil {
+		return errors.Wrap(err, "unable to save finalized height")
+	}
+	pm.Complete("saveFinalizedHeight")
+
+	return nil
 }
 
 func (a *postgresAccessor) saveEpochResult(
@@ -578,6 +691,10 @@ func (a *postgresAccessor) saveBurntCoins(ctx *context, burntCoinsByAddr map[com
 	return nil
 }
 
+func (a *postgresAccessor) Ping(ctx gocontext.Context) error {
+	return a.db.PingContext(ctx)
+}
+
 func (a *postgresAccessor) Destroy() {
 	err := a.db.Close()
 	if err != nil {
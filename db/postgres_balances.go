@@ -3,7 +3,7 @@ package db
 import "database/sql"
 
 func (a *postgresAccessor) Balances() ([]Balance, error) {
-	rows, err := a.db.Query("select a.address, b.balance from balances b join addresses a on a.id = b.address_id")
+	rows, err := a.db.Query("select a.address, coalesce(b.balance, 0), coalesce(b.stake, 0) from balances b join addresses a on a.id = b.address_id")
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,7 @@ func (a *postgresAccessor) Balances() ([]Balance, error) {
 	var result []Balance
 	for rows.Next() {
 		var item Balance
-		err = rows.Scan(&item.Address, &item.Balance)
+		err = rows.Scan(&item.Address, &item.Balance, &item.Stake)
 		if err != nil {
 			return nil, err
 		}
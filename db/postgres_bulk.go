@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// bulkCopy collects rows of append-only tables of several blocks to load them with COPY
+type bulkCopy struct {
+	txIds             []int64
+	txRaws            [][]byte
+	vrfScoreHeights   []uint64
+	proposerVrfScores []float64
+}
+
+// withoutRaws returns copies of transactions without raw data which is copied separately
+func withoutRaws(txs []Transaction) []Transaction {
+	res := make([]Transaction, len(txs))
+	for i, tx := range txs {
+		res[i] = tx
+		res[i].Raw = ""
+	}
+	return res
+}
+
+func (b *bulkCopy) addTxRaws(txIdsPerHash map[string]int64, txs []Transaction) error {
+	for _, tx := range txs {
+		txId, present := txIdsPerHash[tx.Hash]
+		if !present {
+			return errors.Errorf("tx id not found, hash %v", tx.Hash)
+		}
+		raw, err := hex.DecodeString(tx.Raw)
+		if err != nil {
+			return errors.Wrapf(err, "unable to decode raw tx %v", tx.Hash)
+		}
+		b.txIds = append(b.txIds, txId)
+		b.txRaws = append(b.txRaws, raw)
+	}
+	return nil
+}
+
+func (b *bulkCopy) addProposerVrfScore(height uint64, vrfScore float64) {
+	if vrfScore == 0 {
+		return
+	}
+	b.vrfScoreHeights = append(b.vrfScoreHeights, height)
+	b.proposerVrfScores = append(b.proposerVrfScores, vrfScore)
+}
+
+func (b *bulkCopy) copy(tx *sql.Tx) error {
+	if err := copyRows(tx, "transaction_raws", []string{"tx_id", "raw"}, len(b.txIds), func(i int) []interface{} {
+		return []interface{}{b.txIds[i], b.txRaws[i]}
+	}); err != nil {
+		return errors.Wrap(err, "unable to copy tx raws")
+	}
+	if err := copyRows(tx, "block_proposer_vrf_scores", []string{"block_height", "vrf_score"}, len(b.vrfScoreHeights), func(i int) []interface{} {
+		return []interface{}{b.vrfScoreHeights[i], b.proposerVrfScores[i]}
+	}); err != nil {
+		return errors.Wrap(err, "unable to copy proposer vrf scores")
+	}
+	return nil
+}
+
+func copyRows(tx *sql.Tx, table string, columns []string, count int, row func(i int) []interface{}) error {
+	if count == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i := 0; i < count; i++ {
+		if _, err := stmt.Exec(row(i)...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.Exec()
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/idena-network/idena-indexer/import/words"
 	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/migration/schema"
 	"github.com/idena-network/idena-indexer/monitoring"
 	"github.com/pkg/errors"
 	"time"
@@ -12,12 +13,18 @@ import (
 func NewPostgresAccessor(
 	connStr string,
 	scriptsDirPath string,
+	migrationsDirPath string,
+	migrationsBaseline string,
 	wordsLoader words.Loader,
 	pm monitoring.PerformanceMonitor,
 	changesHistoryBlocksCount int,
 	miningRewards bool,
+	webhooks bool,
+	finalityConfirmations uint64,
+	balanceCheckpointInterval uint64,
 	dataTable string,
 	dataStateTable string,
+	plugins []Plugin,
 ) Accessor {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -30,11 +37,19 @@ func NewPostgresAccessor(
 		queries:                   ReadQueries(scriptsDirPath),
 		changesHistoryBlocksCount: changesHistoryBlocksCount,
 		miningRewards:             miningRewards,
+		webhooks:                  webhooks,
+		finalityConfirmations:     finalityConfirmations,
+		balanceCheckpointInterval: balanceCheckpointInterval,
 		dataTable:                 dataTable,
 		dataStateTable:            dataStateTable,
+		plugins:                   plugins,
 	}
+	migrator := schema.NewMigrator(db, migrationsDirPath, migrationsBaseline, log.New("component", "migrator"))
 	for {
-		if err := a.init(wordsLoader); err != nil {
+		if err := a.init(wordsLoader, migrator); err != nil {
+			if schema.IsFatal(err) {
+				panic(err)
+			}
 			log.Error("Unable to initialize postgres connection", "err", err)
 			time.Sleep(time.Second * 10)
 			continue
@@ -44,7 +59,7 @@ func NewPostgresAccessor(
 	return a
 }
 
-func (a *postgresAccessor) init(wordsLoader words.Loader) error {
+func (a *postgresAccessor) init(wordsLoader words.Loader, migrator *schema.Migrator) error {
 	if err := a.db.Ping(); err != nil {
 		return err
 	}
@@ -54,6 +69,11 @@ func (a *postgresAccessor) init(wordsLoader words.Loader) error {
 	}
 	defer tx.Rollback()
 
+	var freshSchema bool
+	if err := tx.QueryRow("SELECT to_regclass('blocks') IS NULL").Scan(&freshSchema); err != nil {
+		return err
+	}
+
 	if _, err := tx.Exec(a.getQuery(initQuery)); err != nil {
 		return err
 	}
@@ -62,7 +82,14 @@ func (a *postgresAccessor) init(wordsLoader words.Loader) error {
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if _, err := migrator.Up(freshSchema); err != nil {
+		return errors.Wrap(err, "unable to apply migrations")
+	}
+	return a.initPlugins()
 }
 
 func initWords(tx *sql.Tx, loader words.Loader) error {
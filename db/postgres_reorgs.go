@@ -0,0 +1,58 @@
+package db
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func (a *postgresAccessor) ResetToWithHistory(height uint64, newTipHash string, timestamp time.Time) (*Reorg, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, getResultError(err)
+	}
+	defer tx.Rollback()
+
+	var id uint64
+	if err := tx.QueryRow(a.getQuery(saveReorgQuery), height, newTipHash, timestamp.Unix()).Scan(&id); err != nil {
+		return nil, getResultError(errors.Wrap(err, "unable to save reorg"))
+	}
+	reorg := &Reorg{}
+	if err := tx.QueryRow(a.getQuery(selectReorgQuery), id).Scan(
+		&reorg.Id,
+		&reorg.Timestamp,
+		&reorg.ResetHeight,
+		&reorg.OldTipHeight,
+		&reorg.OldTipHash,
+		&reorg.NewTipHash,
+		&reorg.Depth,
+		&reorg.RemovedTxs,
+		&reorg.RemovedBalanceUpdates,
+		&reorg.RemovedRewards,
+	); err != nil {
+		return nil, getResultError(errors.Wrap(err, "unable to load saved reorg"))
+	}
+	if _, err := tx.Exec(a.getQuery(resetToBlockQuery), height); err != nil {
+		return nil, getResultError(err)
+	}
+	if err := a.resetPlugins(tx, height); err != nil {
+		return nil, getResultError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, getResultError(err)
+	}
+	return reorg, nil
+}
+
+// saveReincludedTxs marks transactions removed by previous resets as included again
+func (a *postgresAccessor) saveReincludedTxs(ctx *context, txs []Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	_, err := ctx.tx.Exec(a.getQuery(saveReincludedTxsQuery), ctx.blockHeight, pq.Array(hashes))
+	return errors.Wrap(err, "unable to save reincluded txs")
+}
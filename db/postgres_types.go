@@ -10,6 +10,7 @@ import (
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-indexer/core/conversion"
+	types2 "github.com/idena-network/idena-indexer/core/types"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
@@ -1033,9 +1034,13 @@ type txReceipt struct {
 }
 
 type contract struct {
-	ContractAddress string `json:"contractAddress"`
-	TxHash          string `json:"txHash"`
-	Code            bytes  `json:"code"`
+	ContractAddress string             `json:"contractAddress"`
+	TxHash          string             `json:"txHash"`
+	Code            bytes              `json:"code"`
+	CodeHash        string             `json:"codeHash,omitempty"`
+	CodeSize        int                `json:"codeSize,omitempty"`
+	Module          *types2.WasmModule `json:"module,omitempty"`
+	ParseError      string             `json:"parseError,omitempty"`
 }
 
 type txEvent struct {
@@ -1151,11 +1156,18 @@ func convertTxReceipt(v *types.TxReceipt) txReceipt {
 }
 
 func convertContract(v *Contract) contract {
-	return contract{
+	res := contract{
 		ContractAddress: conversion.ConvertAddress(v.ContractAddress),
 		TxHash:          conversion.ConvertHash(v.TxHash),
 		Code:            v.Code,
 	}
+	if v.Wasm != nil {
+		res.CodeHash = conversion.ConvertHash(v.Wasm.CodeHash)
+		res.CodeSize = v.Wasm.Size
+		res.Module = v.Wasm.Module
+		res.ParseError = v.Wasm.Error
+	}
+	return res
 }
 
 func convertTxEvents(events []*types.TxEvent) []txEvent {
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/core/conversion"
+	types2 "github.com/idena-network/idena-indexer/core/types"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const (
+	WebhookEventTransaction             = "transaction"
+	WebhookEventIdentityStateChange     = "identityStateChange"
+	WebhookEventPenalty                 = "penalty"
+	WebhookEventOracleVotingStateChange = "oracleVotingStateChange"
+	// WebhookEventReverted is created by reset_webhooks_to procedure only
+	WebhookEventReverted = "reverted"
+)
+
+const (
+	oracleVotingActionDeploy    = "deploy"
+	oracleVotingActionStart     = "start"
+	oracleVotingActionProlong   = "prolong"
+	oracleVotingActionFinish    = "finish"
+	oracleVotingActionTerminate = "terminate"
+)
+
+type webhookEvent struct {
+	Type      string      `json:"type"`
+	Addresses []string    `json:"addresses,omitempty"`
+	TxType    string      `json:"txType,omitempty"`
+	Contract  string      `json:"contract,omitempty"`
+	Payload   interface{} `json:"payload"`
+}
+
+func (v *webhookEvent) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+// getWebhookEvents converts block data to events for webhooks, the events are saved within the block transaction so
+// that none of them are lost if the indexer stops right after the block is saved
+func getWebhookEvents(data *Data) []*webhookEvent {
+	var res []*webhookEvent
+	contractsByTxHash := make(map[string]string, len(data.TxReceipts))
+	for _, receipt := range data.TxReceipts {
+		contractsByTxHash[strings.ToLower(conversion.ConvertHash(receipt.TxHash))] = conversion.ConvertAddress(receipt.ContractAddress)
+	}
+	txsByHash := make(map[string]*Transaction, len(data.Block.Transactions))
+	for i := range data.Block.Transactions {
+		tx := &data.Block.Transactions[i]
+		txHash := strings.ToLower(tx.Hash)
+		txsByHash[txHash] = tx
+		txType := conversion.ConvertTxType(tx.Type)
+		res = append(res, &webhookEvent{
+			Type:      WebhookEventTransaction,
+			Addresses: nonEmpty(tx.From, tx.To),
+			TxType:    txType,
+			Contract:  contractsByTxHash[txHash],
+			Payload: &types2.StreamTransaction{
+				Hash:   tx.Hash,
+				Type:   txType,
+				From:   tx.From,
+				To:     tx.To,
+				Amount: tx.Amount,
+				Tips:   tx.Tips,
+				MaxFee: tx.MaxFee,
+				Fee:    tx.Fee,
+				Nonce:  tx.Nonce,
+			},
+		})
+	}
+
+	for _, address := range data.Addresses {
+		for _, change := range address.StateChanges {
+			res = append(res, &webhookEvent{
+				Type:      WebhookEventIdentityStateChange,
+				Addresses: []string{address.Address},
+				Payload: &types2.WebhookIdentityStateChange{
+					Address:   address.Address,
+					PrevState: conversion.ConvertIdentityState(change.PrevState),
+					State:     conversion.ConvertIdentityState(change.NewState),
+					TxHash:    change.TxHash,
+				},
+			})
+		}
+	}
+
+	for _, penalty := range data.Penalties {
+		res = append(res, &webhookEvent{
+			Type:      WebhookEventPenalty,
+			Addresses: []string{penalty.Address},
+			Payload: &types2.WebhookPenalty{
+				Address:       penalty.Address,
+				Penalty:       penalty.Penalty,
+				Seconds:       penalty.Seconds,
+				InheritedFrom: penalty.InheritedFrom,
+			},
+		})
+	}
+
+	oracleVotingEvent := func(txHash common.Hash, contract, action string, state, result *byte) *webhookEvent {
+		hash := conversion.ConvertHash(txHash)
+		var addresses []string
+		if tx, ok := txsByHash[strings.ToLower(hash)]; ok {
+			addresses = nonEmpty(tx.From)
+			if len(contract) == 0 {
+				contract = tx.To
+			}
+		}
+		return &webhookEvent{
+			Type:      WebhookEventOracleVotingStateChange,
+			Addresses: addresses,
+			Contract:  contract,
+			Payload: &types2.WebhookOracleVotingStateChange{
+				Contract: contract,
+				Action:   action,
+				TxHash:   hash,
+				State:    state,
+				Result:   result,
+			},
+		}
+	}
+	for _, item := range data.OracleVotingContracts {
+		state := item.State
+		res = append(res, oracleVotingEvent(item.TxHash, conversion.ConvertAddress(item.ContractAddress), oracleVotingActionDeploy, &state, nil))
+	}
+	for _, item := range data.OracleVotingContractCallStarts {
+		state := item.State
+		res = append(res, oracleVotingEvent(item.TxHash, "", oracleVotingActionStart, &state, nil))
+	}
+	for _, item := range data.OracleVotingContractCallProlongations {
+		res = append(res, oracleVotingEvent(item.TxHash, "", oracleVotingActionProlong, nil, nil))
+	}
+	for _, item := range data.OracleVotingContractCallFinishes {
+		state := item.State
+		res = append(res, oracleVotingEvent(item.TxHash, "", oracleVotingActionFinish, &state, item.Result))
+	}
+	for _, item := range data.OracleVotingContractTerminations {
+		res = append(res, oracleVotingEvent(item.TxHash, "", oracleVotingActionTerminate, nil, nil))
+	}
+	return res
+}
+
+func nonEmpty(values ...string) []string {
+	var res []string
+	for _, value := range values {
+		if len(value) > 0 {
+			res = append(res, value)
+		}
+	}
+	return res
+}
+
+func (a *postgresAccessor) saveWebhookEvents(ctx *context, data *Data) error {
+	events := getWebhookEvents(data)
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := ctx.tx.Exec(a.getQuery(saveWebhookEventsQuery), ctx.blockHeight, pq.Array(events))
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	types2 "github.com/idena-network/idena-go/stats/types"
+	"github.com/idena-network/idena-indexer/contract/wasm"
 	"github.com/shopspring/decimal"
 	"math/big"
 )
@@ -156,6 +157,8 @@ type Data struct {
 	Tokens                                   []Token
 	TokenBalanceUpdates                      []TokenBalance
 	DelegationHistoryUpdates                 []DelegationHistoryUpdate
+	// Source is passed to plugins and is not saved
+	Source *BlockSource
 }
 
 type EpochRewards struct {
@@ -269,7 +272,7 @@ type Transaction struct {
 	MaxFee  decimal.Decimal `json:"maxFee"`
 	Fee     decimal.Decimal `json:"fee"`
 	Size    int             `json:"size"`
-	Raw     string          `json:"raw"`
+	Raw     string          `json:"raw,omitempty"`
 	Nonce   uint32          `json:"nonce"`
 	UsedGas uint64          `json:"usedGas"`
 
@@ -497,6 +500,7 @@ type Contract struct {
 	TxHash          common.Hash
 	ContractAddress common.Address
 	Code            []byte
+	Wasm            *wasm.Info
 }
 
 type OracleVotingContract struct {
@@ -897,3 +901,17 @@ type PoolSizeChange struct {
 	Old     PoolSize       `json:"old"`
 	New     PoolSize       `json:"new"`
 }
+
+// Reorg describes the reset of indexed blocks above ResetHeight
+type Reorg struct {
+	Id                    uint64
+	Timestamp             int64
+	ResetHeight           uint64
+	OldTipHeight          uint64
+	OldTipHash            string
+	NewTipHash            string
+	Depth                 uint64
+	RemovedTxs            uint64
+	RemovedBalanceUpdates uint64
+	RemovedRewards        uint64
+}
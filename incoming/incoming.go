@@ -15,6 +15,9 @@ import (
 	"github.com/idena-network/idena-indexer/monitoring"
 )
 
+// Listener provides blocks of the embedded node. Blocks are handled synchronously while the node applies them since
+// the indexer reads stats collected during block application, app states of recent heights and node internals
+// (pending proofs, flipper, keys pool) which are not available over the node RPC.
 type Listener interface {
 	Listen(handleBlock func(block *types.Block), expectedHeadHeight uint64)
 	AppStateReadonly(height uint64) (*appstate.AppState, error)
@@ -0,0 +1,131 @@
+package indexer
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"math/big"
+	"sync/atomic"
+)
+
+type StateDump struct {
+	LastIndexedHeight   uint64   `json:"lastIndexedHeight"`
+	FirstBlockHeight    uint64   `json:"firstBlockHeight"`
+	TotalBalance        *big.Int `json:"totalBalance,omitempty"`
+	TotalStake          *big.Int `json:"totalStake,omitempty"`
+	ActualOracleVotings int      `json:"actualOracleVotings"`
+	Paused              bool     `json:"paused"`
+	Restoring           bool     `json:"restoring"`
+	// RestorePending is true if db data is restored from the node state before indexing the next block
+	RestorePending bool `json:"restorePending"`
+	// SecondaryStorage is true while runtime migration is in progress
+	SecondaryStorage bool `json:"secondaryStorage"`
+}
+
+// Pause makes the indexer wait before indexing the next block until Resume is called, the node does not apply new
+// blocks while indexing is paused
+func (indexer *Indexer) Pause() {
+	indexer.pauseMutex.Lock()
+	defer indexer.pauseMutex.Unlock()
+	if indexer.resumed == nil {
+		indexer.resumed = make(chan struct{})
+	}
+}
+
+func (indexer *Indexer) Resume() {
+	indexer.pauseMutex.Lock()
+	defer indexer.pauseMutex.Unlock()
+	if indexer.resumed != nil {
+		close(indexer.resumed)
+		indexer.resumed = nil
+	}
+}
+
+func (indexer *Indexer) waitForResume() {
+	indexer.pauseMutex.Lock()
+	resumed := indexer.resumed
+	indexer.pauseMutex.Unlock()
+	if resumed == nil {
+		return
+	}
+	atomic.StoreInt32(&indexer.paused, 1)
+	log.Warn("Indexing is paused")
+	select {
+	case <-resumed:
+		log.Info("Indexing is resumed")
+	case <-indexer.ctx.Done():
+	}
+	atomic.StoreInt32(&indexer.paused, 0)
+}
+
+// RequestRestore makes the indexer restore db data from the node state before indexing the next block
+func (indexer *Indexer) RequestRestore() {
+	indexer.blockMutex.Lock()
+	defer indexer.blockMutex.Unlock()
+	indexer.restore = true
+}
+
+// ResetTo removes indexed data above the height and stops indexing, the process has to be restarted since the node is
+// reset to the last indexed height only on start
+func (indexer *Indexer) ResetTo(height uint64) (usrErr, err error) {
+	indexer.blockMutex.Lock()
+	defer indexer.blockMutex.Unlock()
+	if indexer.stopped {
+		return errors.New("indexer is stopped"), nil
+	}
+	if indexer.catchUp != nil {
+		indexer.catchUp.drain()
+	}
+	lastIndexedHeight := indexer.getHeightToIndex() - 1
+	if height >= lastIndexedHeight {
+		return errors.Errorf("height to reset should be less than last indexed height %v", lastIndexedHeight), nil
+	}
+	if height < indexer.firstBlockHeight {
+		return errors.Errorf("height to reset should not be less than first block height %v", indexer.firstBlockHeight), nil
+	}
+	newTip := indexer.listener.NodeCtx().Blockchain.GetBlockByHeight(height + 1)
+	if newTip == nil {
+		return nil, errors.Errorf("unable to get block %v", height+1)
+	}
+	if err := indexer.resetTo(height, newTip); err != nil {
+		return nil, errors.Wrapf(err, "unable to reset to height %v", height)
+	}
+	log.Info(fmt.Sprintf("Indexer db has been reset to height=%d, restart is required to reset the node", height))
+	indexer.stopped = true
+	close(indexer.restartRequested)
+	return nil, nil
+}
+
+// RestartRequested is closed when indexing is stopped until the process is restarted
+func (indexer *Indexer) RestartRequested() <-chan struct{} {
+	return indexer.restartRequested
+}
+
+func (indexer *Indexer) StateDump() *StateDump {
+	indexer.blockMutex.Lock()
+	defer indexer.blockMutex.Unlock()
+	if indexer.catchUp != nil {
+		indexer.catchUp.drain()
+	}
+	res := &StateDump{
+		FirstBlockHeight: indexer.firstBlockHeight,
+		Paused:           indexer.Paused(),
+		Restoring:        indexer.Restoring(),
+		RestorePending:   indexer.restore,
+		SecondaryStorage: indexer.secondaryStorage != nil,
+	}
+	if indexer.state == nil {
+		return res
+	}
+	res.LastIndexedHeight = indexer.state.lastIndexedHeight
+	if indexer.state.totalBalance != nil {
+		res.TotalBalance = new(big.Int).Set(indexer.state.totalBalance)
+	}
+	if indexer.state.totalStake != nil {
+		res.TotalStake = new(big.Int).Set(indexer.state.totalStake)
+	}
+	if indexer.state.actualOracleVotingHolder != nil {
+		res.ActualOracleVotings = len(indexer.state.actualOracleVotingHolder.contracts)
+	}
+	return res
+}
@@ -0,0 +1,273 @@
+package indexer
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/events"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/monitoring"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// the node keeps a limited number of recent states which are needed to convert blocks
+const maxCatchUpBlocksInFlight = state.MaxSavedStatesCount / 2
+
+type CatchUpConfig struct {
+	// Workers is the number of blocks converted concurrently, 0 disables catch-up mode
+	Workers int
+	// MaxBlocksInFlight is the max number of blocks converted or waiting to be saved
+	MaxBlocksInFlight int
+	// MinBlockAge is the min age of incoming blocks to be indexed in catch-up mode
+	MinBlockAge time.Duration
+	// SaveBatchSize is the max number of converted blocks saved in a single db transaction, blocks are saved one by one
+	// if the value is less than 2
+	SaveBatchSize int
+}
+
+// catchUpPipeline converts blocks already applied by the node in several workers while the node applies next ones,
+// converted blocks are saved strictly in order of heights by a single goroutine
+type catchUpPipeline struct {
+	indexer       *Indexer
+	workers       int
+	minBlockAge   time.Duration
+	saveBatchSize int
+	jobs          chan *catchUpJob
+	queue         chan *catchUpJob
+	inFlight      sync.WaitGroup
+	// pm tracks sections of the saving goroutine, the indexer monitor is used by the listener goroutine concurrently
+	pm monitoring.PerformanceMonitor
+	// nextHeight is the height of the block expected to be submitted, 0 if there are no blocks in flight
+	nextHeight uint64
+	// failed holds the block which failed to be converted and next ones, they are left to the listener goroutine to be
+	// converted again and saved one by one
+	failed      []*catchUpJob
+	failedMutex sync.Mutex
+}
+
+type catchUpJob struct {
+	input       *blockInput
+	res         *result
+	err         error
+	done        chan struct{}
+	epochPeriod state.ValidationPeriod
+}
+
+func newCatchUpPipeline(indexer *Indexer, conf CatchUpConfig) *catchUpPipeline {
+	maxBlocksInFlight := conf.MaxBlocksInFlight
+	if maxBlocksInFlight < conf.Workers {
+		maxBlocksInFlight = conf.Workers
+	}
+	if maxBlocksInFlight > maxCatchUpBlocksInFlight {
+		maxBlocksInFlight = maxCatchUpBlocksInFlight
+	}
+	saveBatchSize := conf.SaveBatchSize
+	if saveBatchSize > maxBlocksInFlight {
+		saveBatchSize = maxBlocksInFlight
+	}
+	return &catchUpPipeline{
+		indexer:       indexer,
+		workers:       conf.Workers,
+		minBlockAge:   conf.MinBlockAge,
+		saveBatchSize: saveBatchSize,
+		jobs:          make(chan *catchUpJob, maxBlocksInFlight),
+		queue:         make(chan *catchUpJob, maxBlocksInFlight),
+		pm:            monitoring.NewEmptyPerformanceMonitor(),
+	}
+}
+
+func (p *catchUpPipeline) start() {
+	for i := 0; i < p.workers; i++ {
+		go p.loopConvert()
+	}
+	go p.loopSave()
+}
+
+// accepts returns true if the block is far enough from the head and its conversion depends on the block data only
+func (p *catchUpPipeline) accepts(block *types.Block) bool {
+	if time.Since(time.Unix(block.Header.Time(), 0)) < p.minBlockAge {
+		return false
+	}
+	// epoch results use node's mem pool keys and flip words use the current ceremony
+	if block.Header.Flags().HasFlag(types.ValidationFinished) {
+		return false
+	}
+	for _, tx := range block.Body.Transactions {
+		if tx.Type == types.SubmitLongAnswersTx {
+			return false
+		}
+	}
+	return true
+}
+
+// submit queues the block for conversion and blocks if the max number of blocks are in flight
+func (p *catchUpPipeline) submit(input *blockInput) {
+	job := &catchUpJob{
+		input: input,
+		done:  make(chan struct{}),
+	}
+	p.inFlight.Add(1)
+	p.nextHeight = input.block.Height() + 1
+	p.queue <- job
+	p.jobs <- job
+}
+
+// drain waits for all submitted blocks to be saved, blocks left after a failed conversion are converted again and saved
+// one by one
+func (p *catchUpPipeline) drain() {
+	if p.nextHeight == 0 {
+		return
+	}
+	p.inFlight.Wait()
+	p.nextHeight = 0
+	for _, job := range p.takeFailed() {
+		if !p.retry(job) {
+			break
+		}
+	}
+	log.Info("Switched to live indexing")
+}
+
+func (p *catchUpPipeline) hasFailed() bool {
+	p.failedMutex.Lock()
+	defer p.failedMutex.Unlock()
+	return len(p.failed) > 0
+}
+
+func (p *catchUpPipeline) addFailed(job *catchUpJob) {
+	p.failedMutex.Lock()
+	defer p.failedMutex.Unlock()
+	p.failed = append(p.failed, job)
+}
+
+func (p *catchUpPipeline) takeFailed() []*catchUpJob {
+	p.failedMutex.Lock()
+	defer p.failedMutex.Unlock()
+	res := p.failed
+	p.failed = nil
+	return res
+}
+
+// retry converts the block again until it succeeds and saves it, it returns false if the indexer is stopped before
+// the block is saved, the block is indexed again after restart in this case
+func (p *catchUpPipeline) retry(job *catchUpJob) bool {
+	for job.err != nil {
+		if p.indexer.stopped {
+			log.Warn(fmt.Sprintf("Skipped block %d which failed to be converted since the indexer is stopped", job.input.block.Height()))
+			return false
+		}
+		p.indexer.waitForRetry()
+		if job.res, job.err = p.indexer.convertBlockData(job.input, p.pm); job.err != nil {
+			log.Error(fmt.Sprintf("Unable to convert block %d: %v", job.input.block.Height(), job.err))
+		}
+	}
+	p.complete(job)
+	p.indexer.saveConverted([]*catchUpJob{job}, p.pm)
+	return true
+}
+
+// complete has to be called in order of block heights
+func (p *catchUpPipeline) complete(job *catchUpJob) {
+	p.indexer.completeConversion(job.res)
+	p.indexer.applyConversionOnState(job.res)
+	// the node can drop the block state before the batch is saved
+	job.epochPeriod = job.res.pending.ctx.newStateReadOnly.State.ValidationPeriod()
+}
+
+func (p *catchUpPipeline) loopConvert() {
+	for job := range p.jobs {
+		job.res, job.err = p.indexer.convertBlockData(job.input, monitoring.NewEmptyPerformanceMonitor())
+		close(job.done)
+	}
+}
+
+// loopSave completes conversion of blocks in order of heights and saves them, blocks are accumulated in a batch while
+// next converted blocks are ready to be saved and the batch is not full, once a block fails to be converted it and
+// all next blocks are left to drain since blocks are saved in order of heights
+func (p *catchUpPipeline) loopSave() {
+	var batch []*catchUpJob
+	save := func() {
+		if len(batch) == 0 {
+			return
+		}
+		p.indexer.saveConverted(batch, p.pm)
+		for range batch {
+			p.inFlight.Done()
+		}
+		batch = nil
+	}
+	for job := range p.queue {
+		<-job.done
+		if job.err != nil || p.hasFailed() {
+			if job.err != nil {
+				log.Error(fmt.Sprintf("Unable to convert block %d in catch-up mode: %v", job.input.block.Height(), job.err))
+			}
+			save()
+			p.addFailed(job)
+			p.inFlight.Done()
+			continue
+		}
+		p.complete(job)
+		batch = append(batch, job)
+		if len(batch) < p.saveBatchSize && len(p.queue) > 0 {
+			continue
+		}
+		save()
+	}
+}
+
+// indexBlockAhead submits the block to the catch-up pipeline and returns true if the block can be indexed in catch-up
+// mode, otherwise it waits for blocks in flight to be saved and returns false to index the block one by one
+func (indexer *Indexer) indexBlockAhead(block *types.Block) bool {
+	p := indexer.catchUp
+	if !p.accepts(block) {
+		p.drain()
+		return false
+	}
+	if p.nextHeight == 0 {
+		// there are no blocks in flight, so the indexer state can be read here
+		if !indexer.canIndexAhead(block) {
+			return false
+		}
+		log.Info(fmt.Sprintf("Switched to catch-up indexing with %v workers", p.workers))
+	} else if block.Height() != p.nextHeight || p.hasFailed() {
+		p.drain()
+		return false
+	}
+	p.submit(indexer.newBlockInput(block))
+	return true
+}
+
+func (indexer *Indexer) canIndexAhead(block *types.Block) bool {
+	return block.Height() == indexer.getHeightToIndex() &&
+		!indexer.isFirstBlock(block) &&
+		indexer.state.totalBalance != nil && indexer.state.totalStake != nil &&
+		!indexer.restore &&
+		indexer.secondaryStorage == nil
+}
+
+// saveConverted saves blocks converted in catch-up mode, several blocks are saved in a single db transaction
+func (indexer *Indexer) saveConverted(jobs []*catchUpJob, pm monitoring.PerformanceMonitor) {
+	data := make([]*db.Data, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, job.res.dbData)
+	}
+	indexer.saveBatch(data, pm)
+	for _, job := range jobs {
+		res := job.res
+		height := res.dbData.Block.Height
+		indexer.eventBus.Publish(&SavedBlockEvent{Data: res.dbData})
+		indexer.loadFlips(res.resData.flipTxs)
+		indexer.setLastIndexedHeight(height)
+		indexer.eventBus.Publish(&events.NewBlockEvent{Height: height, EpochPeriod: job.epochPeriod})
+
+		monitoring.IndexedBlocks.Inc()
+		atomic.StoreInt64(&indexer.lastBlockProcessedAt, time.Now().UnixNano())
+		log.Info(fmt.Sprintf("Processed block %d", height))
+
+		indexer.refreshUpgradeVotingHistorySummaries(res.dbData.UpgradesVotes, height)
+	}
+}
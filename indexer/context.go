@@ -4,6 +4,7 @@ import (
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common"
 	"github.com/idena-network/idena-go/core/appstate"
+	"github.com/idena-network/idena-indexer/core/stats"
 	"github.com/idena-network/idena-indexer/db"
 )
 
@@ -24,6 +25,22 @@ type conversionContext struct {
 	blockHeight       uint64
 	prevStateReadOnly *appstate.AppState
 	newStateReadOnly  *appstate.AppState
+	stats             *stats.Stats
+	proposerVrfHash   *common.Hash
+}
+
+// blockInput holds the incoming block data which is available only while the node handles the block
+type blockInput struct {
+	block           *types.Block
+	stats           *stats.Stats
+	proposerVrfHash *common.Hash
+	upgradesVotes   []*db.UpgradeVotes
+}
+
+type pendingConversion struct {
+	block *types.Block
+	ctx   *conversionContext
+	diff  balanceDiff
 }
 
 type conversionCollector struct {
@@ -0,0 +1,39 @@
+package indexer
+
+import (
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-indexer/db"
+)
+
+const (
+	SavedBlockEventId = eventbus.EventID("saved-block")
+	ResetEventId      = eventbus.EventID("reset")
+	ReorgEventId      = eventbus.EventID("reorg")
+)
+
+// SavedBlockEvent is published right after block data is committed to the db
+type SavedBlockEvent struct {
+	Data *db.Data
+}
+
+func (e *SavedBlockEvent) EventID() eventbus.EventID {
+	return SavedBlockEventId
+}
+
+// ResetEvent is published right after data of blocks above Height is deleted from the db
+type ResetEvent struct {
+	Height uint64
+}
+
+func (e *ResetEvent) EventID() eventbus.EventID {
+	return ResetEventId
+}
+
+// ReorgEvent is published right after ResetEvent with the reset details recorded in the reorg history
+type ReorgEvent struct {
+	Reorg *db.Reorg
+}
+
+func (e *ReorgEvent) EventID() eventbus.EventID {
+	return ReorgEventId
+}
@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -12,10 +13,10 @@ import (
 	"github.com/idena-network/idena-go/core/appstate"
 	"github.com/idena-network/idena-go/core/ceremony"
 	"github.com/idena-network/idena-go/core/state"
-	"github.com/idena-network/idena-go/core/validators"
 	"github.com/idena-network/idena-go/crypto"
 	"github.com/idena-network/idena-go/crypto/vrf"
 	statsTypes "github.com/idena-network/idena-go/stats/types"
+	"github.com/idena-network/idena-indexer/core/audit"
 	"github.com/idena-network/idena-indexer/core/conversion"
 	"github.com/idena-network/idena-indexer/core/flip"
 	"github.com/idena-network/idena-indexer/core/holder/upgrade"
@@ -33,6 +34,8 @@ import (
 	"github.com/pkg/errors"
 	_ "image/png"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -56,7 +59,6 @@ var (
 )
 
 type Indexer struct {
-	enabled                       bool
 	listener                      incoming.Listener
 	memPoolIndexer                *mempool.Indexer
 	db                            db.Accessor
@@ -71,10 +73,24 @@ type Indexer struct {
 	upgradeVotingHistoryCtx       *upgradeVotingHistoryCtx
 	eventBus                      eventbus.Bus
 	treeSnapshotDir               string
+	treeSnapshotsToKeep           int
 	actualOracleVotingsLoader     voting.ActualOracleVotingsLoader
 	oracleVotingToProlongDetector OracleVotingToProlongDetector
-	checkBalances                 bool
+	auditor                       *audit.Auditor
 	disableDelegationHistory      bool
+	catchUp                       *catchUpPipeline
+	lastIndexedHeight             uint64 // accessed atomically, copy of state.lastIndexedHeight for other goroutines
+	lastBlockProcessedAt          int64  // accessed atomically, unix nano
+	paused                        int32  // accessed atomically
+	restoring                     int32  // accessed atomically
+	blockMutex                    sync.Mutex
+	stopped                       bool // guarded by blockMutex
+	ctx                           context.Context
+	cancel                        context.CancelFunc
+	loops                         sync.WaitGroup
+	pauseMutex                    sync.Mutex
+	resumed                       chan struct{} // guarded by pauseMutex, not nil while indexing is paused
+	restartRequested              chan struct{}
 }
 
 type upgradeVotingHistoryCtx struct {
@@ -87,6 +103,7 @@ type upgradeVotingHistoryCtx struct {
 type result struct {
 	dbData  *db.Data
 	resData *resultData
+	pending *pendingConversion
 }
 
 type resultData struct {
@@ -123,13 +140,14 @@ func NewIndexer(
 	upgradeVotingShortHistoryMinShift int,
 	eventBus eventbus.Bus,
 	treeSnapshotDir string,
+	treeSnapshotsToKeep int,
 	actualOracleVotingsLoader voting.ActualOracleVotingsLoader,
 	oracleVotingToProlongDetector OracleVotingToProlongDetector,
-	checkBalances bool,
+	auditor *audit.Auditor,
 	disableDelegationHistory bool,
+	catchUpConf CatchUpConfig,
 ) *Indexer {
-	return &Indexer{
-		enabled:                       enabled,
+	indexer := &Indexer{
 		listener:                      listener,
 		memPoolIndexer:                mempoolIndexer,
 		db:                            dbAccessor,
@@ -140,6 +158,7 @@ func NewIndexer(
 		flipLoader:                    flipLoader,
 		eventBus:                      eventBus,
 		treeSnapshotDir:               treeSnapshotDir,
+		treeSnapshotsToKeep:           treeSnapshotsToKeep,
 		actualOracleVotingsLoader:     actualOracleVotingsLoader,
 		oracleVotingToProlongDetector: oracleVotingToProlongDetector,
 		upgradeVotingHistoryCtx: &upgradeVotingHistoryCtx{
@@ -148,22 +167,70 @@ func NewIndexer(
 			shortHistoryMinShift: upgradeVotingShortHistoryMinShift,
 			queue:                make(chan *upgradesVotesWrapper, 5),
 		},
-		checkBalances:            checkBalances,
+		auditor:                  auditor,
 		disableDelegationHistory: disableDelegationHistory,
 	}
+	indexer.ctx, indexer.cancel = context.WithCancel(context.Background())
+	indexer.restartRequested = make(chan struct{})
+	if !enabled {
+		indexer.resumed = make(chan struct{})
+	}
+	if catchUpConf.Workers > 0 {
+		indexer.catchUp = newCatchUpPipeline(indexer, catchUpConf)
+	}
+	return indexer
 }
 
 func (indexer *Indexer) Start() {
 	indexer.memPoolIndexer.Initialize(indexer.listener.NodeEventBus())
+	indexer.loops.Add(1)
 	go indexer.loopRefreshUpgradeVotingHistorySummaries()
 	go indexer.updateUpgradesInfo()
+	if indexer.catchUp != nil {
+		indexer.catchUp.start()
+	}
 	indexer.listener.Listen(indexer.indexBlock, indexer.getHeightToIndex()-1)
 }
 
+// LastIndexedHeight returns height of the last saved block and can be called from any goroutine
+func (indexer *Indexer) LastIndexedHeight() uint64 {
+	return atomic.LoadUint64(&indexer.lastIndexedHeight)
+}
+
+func (indexer *Indexer) LastBlockProcessedAt() time.Time {
+	v := atomic.LoadInt64(&indexer.lastBlockProcessedAt)
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v).UTC()
+}
+
+// Paused returns true if indexing is paused or the indexer is waiting to retry a failed operation
+func (indexer *Indexer) Paused() bool {
+	return atomic.LoadInt32(&indexer.paused) == 1
+}
+
+func (indexer *Indexer) Restoring() bool {
+	return atomic.LoadInt32(&indexer.restoring) == 1
+}
+
 func (indexer *Indexer) WaitForNodeStop() {
 	indexer.listener.WaitForStop()
 }
 
+// Stop waits for the block being indexed and blocks in flight to be saved and stops background loops, blocks received
+// afterwards are skipped since the node is reset to the last indexed height on the next start
+func (indexer *Indexer) Stop() {
+	indexer.blockMutex.Lock()
+	indexer.stopped = true
+	if indexer.catchUp != nil {
+		indexer.catchUp.drain()
+	}
+	indexer.blockMutex.Unlock()
+	indexer.cancel()
+	indexer.loops.Wait()
+}
+
 func (indexer *Indexer) Destroy() {
 	indexer.listener.Destroy()
 	indexer.memPoolIndexer.Destroy()
@@ -176,15 +243,20 @@ func (indexer *Indexer) statsHolder() stats.StatsHolder {
 
 func (indexer *Indexer) indexBlock(block *types.Block) {
 
-	if !indexer.enabled {
-		for {
-			log.Warn("Indexing is disabled")
-			time.Sleep(time.Minute)
-		}
+	indexer.waitForResume()
+
+	indexer.blockMutex.Lock()
+	defer indexer.blockMutex.Unlock()
+	if indexer.stopped {
+		return
 	}
 
 	indexer.initFirstBlockHeight()
 
+	if indexer.catchUp != nil && indexer.indexBlockAhead(block) {
+		return
+	}
+
 	var genesisBlock *types.Block
 	for {
 		heightToIndex := indexer.getHeightToIndex()
@@ -216,10 +288,11 @@ func (indexer *Indexer) indexBlock(block *types.Block) {
 					continue
 				}
 			}
-			if err := indexer.resetTo(heightToReset); err != nil {
+			if err := indexer.resetTo(heightToReset, block); err != nil {
 				log.Error(fmt.Sprintf("Unable to reset to height=%d", heightToReset), "err", err)
 				indexer.waitForRetry()
 			} else {
+				monitoring.Resets.Inc()
 				log.Info(fmt.Sprintf("Indexer db has been reset to height=%d", heightToReset))
 				indexer.restore = indexer.restore || !indexer.isFirstBlockHeight(block.Height())
 			}
@@ -245,7 +318,10 @@ func (indexer *Indexer) indexBlock(block *types.Block) {
 
 		if indexer.restore {
 			log.Info("Start restoring DB data...")
+			atomic.StoreInt32(&indexer.restoring, 1)
 			indexer.restorer.Restore()
+			atomic.StoreInt32(&indexer.restoring, 0)
+			monitoring.RestoreRuns.Inc()
 			log.Info("DB data has been restored")
 			indexer.restore = false
 		}
@@ -267,6 +343,7 @@ func (indexer *Indexer) indexBlock(block *types.Block) {
 		indexer.pm.Start("Save")
 		indexer.saveData(res.dbData)
 		indexer.pm.Complete("Save")
+		indexer.eventBus.Publish(&SavedBlockEvent{Data: res.dbData})
 
 		indexer.pm.Start("Flips")
 		indexer.loadFlips(res.resData.flipTxs)
@@ -285,6 +362,8 @@ func (indexer *Indexer) indexBlock(block *types.Block) {
 		}
 		indexer.eventBus.Publish(&events.NewBlockEvent{Height: block.Height(), EpochPeriod: indexer.listener.NodeCtx().AppState.State.ValidationPeriod()})
 
+		monitoring.IndexedBlocks.Inc()
+		atomic.StoreInt64(&indexer.lastBlockProcessedAt, time.Now().UnixNano())
 		log.Info(fmt.Sprintf("Processed block %d", block.Height()))
 
 		indexer.refreshUpgradeVotingHistorySummaries(res.dbData.UpgradesVotes, block.Height())
@@ -292,8 +371,9 @@ func (indexer *Indexer) indexBlock(block *types.Block) {
 			log.Warn(errors.Wrap(err, "failed to make epoch tree snapshot").Error())
 		}
 
-		if indexer.checkBalances {
-			checkBalances(indexer.listener.AppState(), indexer.db)
+		if indexer.auditor != nil && indexer.auditor.Audit(block.Height(), indexer.listener.AppState()) {
+			// Data is restored from the state of the audited block before indexing the next one
+			indexer.restore = true
 		}
 
 		return
@@ -324,12 +404,17 @@ func (indexer *Indexer) getGenesisBlock() *types.Block {
 	)
 }
 
-func (indexer *Indexer) resetTo(height uint64) error {
-	err := indexer.db.ResetTo(height)
+func (indexer *Indexer) resetTo(height uint64, newTip *types.Block) error {
+	reorg, err := indexer.db.ResetToWithHistory(height, newTip.Hash().Hex(), time.Now())
 	if err != nil {
 		return err
 	}
+	log.Info(fmt.Sprintf("Reorg %d: removed %d blocks, %d txs, %d balance updates, %d rewards", reorg.Id, reorg.Depth,
+		reorg.RemovedTxs, reorg.RemovedBalanceUpdates, reorg.RemovedRewards))
+	indexer.eventBus.Publish(&ResetEvent{Height: height})
+	indexer.eventBus.Publish(&ReorgEvent{Reorg: reorg})
 	indexer.state = indexer.loadState()
+	atomic.StoreUint64(&indexer.lastIndexedHeight, indexer.state.lastIndexedHeight)
 	indexer.firstBlockHeightInitialized = false
 	indexer.initFirstBlockHeight()
 	return nil
@@ -338,6 +423,7 @@ func (indexer *Indexer) resetTo(height uint64) error {
 func (indexer *Indexer) getHeightToIndex() uint64 {
 	if indexer.state == nil {
 		indexer.state = indexer.loadState()
+		atomic.StoreUint64(&indexer.lastIndexedHeight, indexer.state.lastIndexedHeight)
 	}
 	return indexer.state.lastIndexedHeight + 1
 }
@@ -390,15 +476,36 @@ func (indexer *Indexer) initializeStateIfNeeded(block *types.Block) error {
 }
 
 func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result, error) {
-	indexer.pm.Start("InitCtx")
-	isGenesisBlock := incomingBlock.Hash() == indexer.listener.NodeCtx().Blockchain.GenesisInfo().Genesis.Hash()
-	var prevState *appstate.AppState
-	var err error
-	if isGenesisBlock {
-		prevState, err = indexer.listener.AppStateReadonly(incomingBlock.Height())
-	} else {
-		prevState, err = indexer.listener.AppStateReadonly(incomingBlock.Height() - 1)
+	res, err := indexer.convertBlockData(indexer.newBlockInput(incomingBlock), indexer.pm)
+	if err != nil {
+		return nil, err
+	}
+	indexer.completeConversion(res)
+	return res, nil
+}
+
+func (indexer *Indexer) newBlockInput(incomingBlock *types.Block) *blockInput {
+	input := &blockInput{
+		block:         incomingBlock,
+		stats:         indexer.statsHolder().GetStats(),
+		upgradesVotes: detectUpgradeVotes(indexer.upgradeVotingHistoryCtx.holder.Get(), indexer.listener.Config().Consensus.Version),
 	}
+	if indexer.secondaryStorage == nil {
+		input.proposerVrfHash = getProposerVrfHash(
+			incomingBlock,
+			indexer.listener.NodeCtx().ProposerByRound,
+			indexer.listener.NodeCtx().PendingProofs,
+		)
+	}
+	return input
+}
+
+// convertBlockData converts the block data that does not depend on the indexer state and can be called for several
+// blocks concurrently, the result is to be completed by completeConversion in order of block heights
+func (indexer *Indexer) convertBlockData(input *blockInput, pm monitoring.PerformanceMonitor) (*result, error) {
+	incomingBlock := input.block
+	pm.Start("InitCtx")
+	prevState, err := indexer.prevStateReadonly(incomingBlock)
 	if err != nil {
 		return nil, err
 	}
@@ -406,16 +513,18 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 	if err != nil {
 		return nil, err
 	}
+	collectorStats := input.stats
 	ctx := &conversionContext{
 		blockHeight:       incomingBlock.Height(),
 		prevStateReadOnly: prevState,
 		newStateReadOnly:  newState,
+		stats:             collectorStats,
+		proposerVrfHash:   input.proposerVrfHash,
 	}
 	collector := &conversionCollector{
 		addresses:   make(map[string]*db.Address),
 		killedAddrs: make(map[common.Address]killedInfo),
 	}
-	collectorStats := indexer.statsHolder().GetStats()
 	for killed := range collectorStats.KilledInactiveIdentities {
 		collector.killedAddrs[killed] = killedInfo{
 			reason: killedReasonInactiveIdentity,
@@ -424,14 +533,14 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 
 	epoch := uint64(prevState.State.Epoch())
 
-	indexer.pm.Complete("InitCtx")
-	indexer.pm.Start("ConvertBlock")
+	pm.Complete("InitCtx")
+	pm.Start("ConvertBlock")
 	isFirstEpochBlock := incomingBlock.Height() == prevState.State.EpochBlock()+1
-	block, err := indexer.convertBlock(incomingBlock, ctx, collector, prevState.ValidatorsCache)
+	block, err := indexer.convertBlock(incomingBlock, ctx, collector)
 	if err != nil {
 		return nil, err
 	}
-	indexer.pm.Complete("ConvertBlock")
+	pm.Complete("ConvertBlock")
 	epochResult := indexer.detectEpochResult(incomingBlock, ctx, collector)
 
 	firstAddresses := indexer.detectFirstAddresses(incomingBlock, ctx)
@@ -448,8 +557,6 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 		ctx.prevStateReadOnly,
 		ctx.newStateReadOnly)
 
-	coins, totalBalance, totalStake := indexer.getCoins(indexer.isFirstBlock(incomingBlock), diff)
-
 	delegationSwitches, delegationHistoryUpdates := detectDelegationSwitches(incomingBlock, ctx.prevStateReadOnly, ctx.newStateReadOnly, collector.killedAddrs, collector.switchDelegationTxs)
 
 	for _, removedTransitiveDelegation := range collectorStats.RemovedTransitiveDelegations {
@@ -469,8 +576,6 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 
 	}
 
-	upgradesVotes := detectUpgradeVotes(indexer.upgradeVotingHistoryCtx.holder.Get(), indexer.listener.Config().Consensus.Version)
-
 	poolSizes := detectPoolSizeUpdates(delegationSwitches, collector.getAddresses(), func() []db.EpochIdentity {
 		if epochResult == nil {
 			return nil
@@ -478,8 +583,6 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 		return epochResult.Identities
 	}(), ctx.prevStateReadOnly, ctx.newStateReadOnly)
 
-	oracleVotingsToProlong := detectOracleVotingsToProlong(indexer.oracleVotingToProlongDetector, indexer.state.actualOracleVotingHolder.contracts, ctx.newStateReadOnly, incomingBlock.Header, indexer.listener.NodeCtx().Blockchain.Config())
-
 	dbData := &db.Data{
 		Epoch:                                    epoch,
 		PrevStateRoot:                            conversion.ConvertHash(prevState.State.Root()),
@@ -499,7 +602,6 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 		FlipsWords:                               collector.flipsWords,
 		Addresses:                                collector.getAddresses(),
 		ChangedBalances:                          balanceUpdates,
-		Coins:                                    coins,
 		Penalties:                                convertChargedPenalties(collectorStats.ChargedPenaltiesByAddr, collectorStats.ChargedPenaltySecondsByAddr),
 		MiningRewards:                            convertMiningRewards(collectorStats.MiningRewards),
 		BurntCoinsPerAddr:                        collectorStats.BurntCoinsByAddr,
@@ -536,36 +638,59 @@ func (indexer *Indexer) convertIncomingData(incomingBlock *types.Block) (*result
 		ContractTxsBalanceUpdates:                collectorStats.ContractTxsBalanceUpdates,
 		EpochResult:                              epochResult,
 		DelegationSwitches:                       delegationSwitches,
-		UpgradesVotes:                            upgradesVotes,
+		UpgradesVotes:                            input.upgradesVotes,
 		PoolSizes:                                poolSizes,
 		MinersHistoryItem:                        detectMinersHistoryItem(ctx.prevStateReadOnly, ctx.newStateReadOnly),
 		RemovedTransitiveDelegations:             collectorStats.RemovedTransitiveDelegations,
 		EpochSummaryUpdate:                       collectorStats.EpochSummaryUpdate,
-		OracleVotingContractsToProlong:           oracleVotingsToProlong,
 		Tokens:                                   collectorStats.Tokens,
 		TokenBalanceUpdates:                      collectorStats.TokenBalanceUpdates,
+		Source: &db.BlockSource{
+			Block:     incomingBlock,
+			PrevState: ctx.prevStateReadOnly,
+			NewState:  ctx.newStateReadOnly,
+		},
 	}
 	if !indexer.disableDelegationHistory {
 		dbData.DelegationHistoryUpdates = append(collectorStats.DelegationHistoryUpdates, delegationHistoryUpdates...)
 	}
 	resData := &resultData{
-		totalBalance: totalBalance,
-		totalStake:   totalStake,
-		flipTxs:      collector.flipTxs,
+		flipTxs: collector.flipTxs,
 	}
 	resData.newActualOracleVotings, resData.newNotActualOracleVotings = collectorStats.NewActualOracleVotingContracts, collectorStats.NewNotActualOracleVotingContracts
 	return &result{
 		dbData:  dbData,
 		resData: resData,
+		pending: &pendingConversion{
+			block: incomingBlock,
+			ctx:   ctx,
+			diff:  diff,
+		},
 	}, nil
 }
 
+// completeConversion fills the block data that depends on the indexer state, it has to be called in order of block
+// heights after the state has been updated with the results of previous blocks
+func (indexer *Indexer) completeConversion(res *result) {
+	pending := res.pending
+	res.dbData.Coins, res.resData.totalBalance, res.resData.totalStake = indexer.getCoins(indexer.isFirstBlock(pending.block), pending.diff, pending.ctx.stats)
+	res.dbData.OracleVotingContractsToProlong = detectOracleVotingsToProlong(indexer.oracleVotingToProlongDetector, indexer.state.actualOracleVotingHolder.contracts, pending.ctx.newStateReadOnly, pending.block.Header, indexer.listener.NodeCtx().Blockchain.Config())
+}
+
+func (indexer *Indexer) prevStateReadonly(incomingBlock *types.Block) (*appstate.AppState, error) {
+	if incomingBlock.Hash() == indexer.listener.NodeCtx().Blockchain.GenesisInfo().Genesis.Hash() {
+		return indexer.listener.AppStateReadonly(incomingBlock.Height())
+	}
+	return indexer.listener.AppStateReadonly(incomingBlock.Height() - 1)
+}
+
 func (indexer *Indexer) getCoins(
 	isFirstBlock bool,
 	diff balanceDiff,
+	collectorStats *stats.Stats,
 ) (dbCoins db.Coins, totalBalance, totalStake *big.Int) {
 
-	minted := indexer.statsHolder().GetStats().MintedCoins
+	minted := collectorStats.MintedCoins
 	// Genesis minted coins
 	if isFirstBlock {
 		if minted == nil {
@@ -578,7 +703,7 @@ func (indexer *Indexer) getCoins(
 	totalStake = new(big.Int).Add(indexer.state.totalStake, diff.stake)
 	dbCoins = db.Coins{
 		Minted:       blockchain.ConvertToFloat(minted),
-		Burnt:        blockchain.ConvertToFloat(indexer.statsHolder().GetStats().BurntCoins),
+		Burnt:        blockchain.ConvertToFloat(collectorStats.BurntCoins),
 		TotalBalance: blockchain.ConvertToFloat(totalBalance),
 		TotalStake:   blockchain.ConvertToFloat(totalStake),
 	}
@@ -655,7 +780,6 @@ func (indexer *Indexer) convertBlock(
 	incomingBlock *types.Block,
 	ctx *conversionContext,
 	collector *conversionCollector,
-	prevValidatorsCache *validators.ValidatorsCache,
 ) (db.Block, error) {
 	var txs []db.Transaction
 	if len(incomingBlock.Body.Transactions) > 0 {
@@ -665,10 +789,9 @@ func (indexer *Indexer) convertBlock(
 	incomingBlock.Header.Flags()
 	proposerVrfScore, _ := getProposerVrfScore(
 		incomingBlock,
-		indexer.listener.NodeCtx().ProposerByRound,
-		indexer.listener.NodeCtx().PendingProofs,
+		ctx.proposerVrfHash,
 		indexer.secondaryStorage,
-		prevValidatorsCache,
+		ctx.prevStateReadOnly.ValidatorsCache,
 	)
 	encodedBlock, _ := incomingBlock.ToBytes()
 	var upgrade *uint32
@@ -691,14 +814,14 @@ func (indexer *Indexer) convertBlock(
 		IsEmpty:                 incomingBlock.IsEmpty(),
 		BodySize:                len(incomingBlock.Body.ToBytes()),
 		FullSize:                len(encodedBlock),
-		OriginalValidatorsCount: len(indexer.statsHolder().GetStats().OriginalFinalCommittee),
-		PoolValidatorsCount:     len(indexer.statsHolder().GetStats().PoolFinalCommittee),
+		OriginalValidatorsCount: len(ctx.stats.OriginalFinalCommittee),
+		PoolValidatorsCount:     len(ctx.stats.PoolFinalCommittee),
 		VrfProposerThreshold:    ctx.prevStateReadOnly.State.VrfProposerThreshold(),
 		ProposerVrfScore:        proposerVrfScore,
 		FeeRate:                 blockchain.ConvertToFloat(ctx.prevStateReadOnly.State.FeePerGas()),
 		Upgrade:                 upgrade,
 		OfflineAddress:          offlineAddress,
-		GasUsed:                 indexer.statsHolder().GetStats().BlockGasUsed,
+		GasUsed:                 ctx.stats.BlockGasUsed,
 	}, nil
 }
 
@@ -767,10 +890,10 @@ func (indexer *Indexer) convertTransaction(
 	}
 
 	getIdentityStateChange := func(address common.Address) *stats.IdentityStateChange {
-		if indexer.statsHolder().GetStats().IdentityStateChangesByTxHashAndAddress == nil {
+		if ctx.stats.IdentityStateChangesByTxHashAndAddress == nil {
 			return nil
 		}
-		txChanges, ok := indexer.statsHolder().GetStats().IdentityStateChangesByTxHashAndAddress[incomingTx.Hash()]
+		txChanges, ok := ctx.stats.IdentityStateChangesByTxHashAndAddress[incomingTx.Hash()]
 		if !ok {
 			return nil
 		}
@@ -846,11 +969,11 @@ func (indexer *Indexer) convertTransaction(
 		Amount:  blockchain.ConvertToFloat(incomingTx.Amount),
 		Tips:    blockchain.ConvertToFloat(incomingTx.Tips),
 		MaxFee:  blockchain.ConvertToFloat(incomingTx.MaxFee),
-		Fee:     blockchain.ConvertToFloat(indexer.statsHolder().GetStats().FeesByTxHash[incomingTx.Hash()]),
+		Fee:     blockchain.ConvertToFloat(ctx.stats.FeesByTxHash[incomingTx.Hash()]),
 		Size:    incomingTx.Size(),
 		Raw:     hex.EncodeToString(txRaw),
 		Nonce:   incomingTx.AccountNonce,
-		UsedGas: indexer.statsHolder().GetStats().UsedGasByTxHash[incomingTx.Hash()],
+		UsedGas: ctx.stats.UsedGasByTxHash[incomingTx.Hash()],
 	}
 
 	var data interface{}
@@ -955,10 +1078,10 @@ func (indexer *Indexer) detectEpochResult(block *types.Block, ctx *conversionCon
 	var poolSizeChanges []*db.PoolSizeChange
 	poolSizeChangeByAddress := make(map[common.Address]*db.PoolSizeChange)
 
-	validationStats := indexer.statsHolder().GetStats().ValidationStats
-	if indexer.statsHolder().GetStats().RewardsStats != nil {
+	validationStats := ctx.stats.ValidationStats
+	if ctx.stats.RewardsStats != nil {
 		vrsCalculator = newValidationRewardSummariesCalculator(
-			indexer.statsHolder().GetStats().RewardsStats,
+			ctx.stats.RewardsStats,
 			validationStats,
 			indexer.listener.Config().Consensus,
 		)
@@ -969,13 +1092,13 @@ func (indexer *Indexer) detectEpochResult(block *types.Block, ctx *conversionCon
 	rewardsBounds := &rewardsBounds{}
 
 	var totalRewardsByAddr map[common.Address]*big.Int
-	if indexer.statsHolder().GetStats().RewardsStats != nil {
-		totalRewardsByAddr = indexer.statsHolder().GetStats().RewardsStats.TotalRewardsByAddr
+	if ctx.stats.RewardsStats != nil {
+		totalRewardsByAddr = ctx.stats.RewardsStats.TotalRewardsByAddr
 	}
 
 	godAddress := ctx.prevStateReadOnly.State.GodAddress()
 	newEpoch := ctx.newStateReadOnly.State.Epoch()
-	epochRewards, validationRewardsAddresses, delegateesEpochRewards := indexer.detectEpochRewards(block)
+	epochRewards, validationRewardsAddresses, delegateesEpochRewards := indexer.detectEpochRewards(block, ctx)
 
 	var flipsStats []*db.FlipStats
 	flipsStatsByCid := make(map[string]*db.FlipStats)
@@ -1009,7 +1132,7 @@ func (indexer *Indexer) detectEpochResult(block *types.Block, ctx *conversionCon
 	}
 
 	isPenalized := func(addr common.Address, shardId common.ShardId) bool {
-		rewardsStats := indexer.statsHolder().GetStats().RewardsStats
+		rewardsStats := ctx.stats.RewardsStats
 		if rewardsStats == nil || rewardsStats.ValidationResults == nil {
 			return false
 		}
@@ -1194,7 +1317,7 @@ func (indexer *Indexer) detectEpochResult(block *types.Block, ctx *conversionCon
 		})
 	}
 
-	collectorStats := indexer.statsHolder().GetStats()
+	collectorStats := ctx.stats
 	var minScoreForInvite float32 = 0
 	if collectorStats.MinScoreForInvite != nil {
 		minScoreForInvite = *collectorStats.MinScoreForInvite
@@ -1398,8 +1521,29 @@ func (indexer *Indexer) saveData(data *db.Data) {
 	}
 }
 
+func (indexer *Indexer) saveBatch(data []*db.Data, pm monitoring.PerformanceMonitor) {
+	for {
+		if err := indexer.db.SaveBatch(data, pm); err != nil {
+			log.Error(fmt.Sprintf("Unable to save blocks %d-%d data: %v", data[0].Block.Height, data[len(data)-1].Block.Height, err))
+			indexer.waitForRetry()
+			continue
+		}
+		return
+	}
+}
+
 func (indexer *Indexer) applyOnState(data *result) {
-	indexer.state.lastIndexedHeight = data.dbData.Block.Height
+	indexer.applyConversionOnState(data)
+	indexer.setLastIndexedHeight(data.dbData.Block.Height)
+}
+
+func (indexer *Indexer) setLastIndexedHeight(height uint64) {
+	indexer.state.lastIndexedHeight = height
+	atomic.StoreUint64(&indexer.lastIndexedHeight, height)
+}
+
+// applyConversionOnState updates the state needed to convert next blocks
+func (indexer *Indexer) applyConversionOnState(data *result) {
 	indexer.state.totalBalance = data.resData.totalBalance
 	indexer.state.totalStake = data.resData.totalStake
 	indexer.state.actualOracleVotingHolder.add(data.resData.newActualOracleVotings)
@@ -1408,5 +1552,7 @@ func (indexer *Indexer) applyOnState(data *result) {
 }
 
 func (indexer *Indexer) waitForRetry() {
+	atomic.StoreInt32(&indexer.paused, 1)
 	time.Sleep(requestRetryInterval)
+	atomic.StoreInt32(&indexer.paused, 0)
 }
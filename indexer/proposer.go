@@ -22,8 +22,7 @@ func getProposer(block *types.Block) string {
 
 func getProposerVrfScore(
 	block *types.Block,
-	proposerByRound pengings.ProposerByRound,
-	pendingProofs *sync.Map,
+	proposerVrfHash *common.Hash,
 	secondaryStorage *runtime.SecondaryStorage,
 	validatorsCache *validators.ValidatorsCache,
 ) (float64, bool) {
@@ -39,20 +38,34 @@ func getProposerVrfScore(
 		}
 		return score, true
 	}
-	var hash common.Hash
-	var ok bool
-	coinbase := block.Header.Coinbase()
-	if hash, ok = getProposerScoreByRound(block.Height(), coinbase, proposerByRound); !ok {
-		if hash, ok = searchProofsByHashVrfScore(block.Height(), coinbase, pendingProofs); !ok {
-			return 0, false
-		}
+	if proposerVrfHash == nil {
+		return 0, false
 	}
-	modifier := int64(validatorsCache.PoolSize(coinbase))
-	q := common.HashToFloat(hash, modifier)
+	modifier := int64(validatorsCache.PoolSize(block.Header.Coinbase()))
+	q := common.HashToFloat(*proposerVrfHash, modifier)
 	f, _ := q.Float64()
 	return f, true
 }
 
+// getProposerVrfHash searches the proposer vrf hash in the node caches which keep it for recent blocks only
+func getProposerVrfHash(
+	block *types.Block,
+	proposerByRound pengings.ProposerByRound,
+	pendingProofs *sync.Map,
+) *common.Hash {
+	if block.Header.ProposedHeader == nil {
+		return nil
+	}
+	coinbase := block.Header.Coinbase()
+	if hash, ok := getProposerScoreByRound(block.Height(), coinbase, proposerByRound); ok {
+		return &hash
+	}
+	if hash, ok := searchProofsByHashVrfScore(block.Height(), coinbase, pendingProofs); ok {
+		return &hash
+	}
+	return nil
+}
+
 func getProposerScoreByRound(round uint64, address common.Address, proposerByRound pengings.ProposerByRound) (common.Hash, bool) {
 	hash, proposerPubKey, ok := proposerByRound(round)
 	if !ok {
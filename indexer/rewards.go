@@ -11,12 +11,12 @@ import (
 	"math/big"
 )
 
-func (indexer *Indexer) detectEpochRewards(block *types.Block) (*db.EpochRewards, map[common.Address]struct{}, *delegateeEpochRewardsWrapper) {
+func (indexer *Indexer) detectEpochRewards(block *types.Block, ctx *conversionContext) (*db.EpochRewards, map[common.Address]struct{}, *delegateeEpochRewardsWrapper) {
 	if !block.Header.Flags().HasFlag(types.ValidationFinished) {
 		return nil, nil, newDelegateeEpochRewardsWrapper(0)
 	}
 
-	rewardsStats := indexer.statsHolder().GetStats().RewardsStats
+	rewardsStats := ctx.stats.RewardsStats
 	if rewardsStats == nil {
 		return nil, nil, newDelegateeEpochRewardsWrapper(0)
 	}
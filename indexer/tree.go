@@ -1,11 +1,11 @@
 package indexer
 
 import (
-	"fmt"
+	stateHolder "github.com/idena-network/idena-indexer/core/holder/state"
 	"github.com/idena-network/idena-indexer/log"
 	"github.com/pkg/errors"
+	"io"
 	"os"
-	"path"
 )
 
 func (indexer *Indexer) makeEpochTreeSnapshot() error {
@@ -25,24 +25,28 @@ func (indexer *Indexer) makeEpochTreeSnapshot() error {
 	if err != nil {
 		return errors.Wrap(err, "failed to create snapshot file")
 	}
-	_, err = indexer.listener.NodeCtx().AppState.State.WriteSnapshot2(epochBlock, snapshotFile)
+	checksum := stateHolder.NewChecksum()
+	_, err = indexer.listener.NodeCtx().AppState.State.WriteSnapshot2(epochBlock, io.MultiWriter(snapshotFile, checksum))
 	snapshotFile.Close()
+	if err == nil {
+		err = stateHolder.WriteChecksum(snapshotFilePath, checksum)
+	}
 	if err != nil {
 		indexer.removeEpochTreeSnapshot()
+		return errors.Wrap(err, "failed to write snapshot")
 	}
 	log.Info("Epoch tree snapshot made")
-	return errors.Wrap(err, "failed to write snapshot")
+	if err := stateHolder.RemoveOutdatedSnapshots(indexer.treeSnapshotDir, indexer.treeSnapshotsToKeep); err != nil {
+		log.Warn(errors.Wrap(err, "failed to remove outdated epoch tree snapshots").Error())
+	}
+	return nil
 }
 
 func (indexer *Indexer) getEpochTreeSnapshotFilePath() string {
 	epoch := indexer.listener.NodeCtx().AppState.State.Epoch()
-	return path.Join(indexer.treeSnapshotDir, fmt.Sprintf("%v.tar", epoch))
+	return stateHolder.SnapshotFilePath(indexer.treeSnapshotDir, uint64(epoch))
 }
 
 func (indexer *Indexer) removeEpochTreeSnapshot() error {
-	snapshotFilePath := indexer.getEpochTreeSnapshotFilePath()
-	if _, err := os.Stat(snapshotFilePath); os.IsNotExist(err) {
-		return nil
-	}
-	return os.Remove(snapshotFilePath)
+	return stateHolder.RemoveSnapshot(indexer.getEpochTreeSnapshotFilePath())
 }
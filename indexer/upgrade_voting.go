@@ -62,12 +62,24 @@ func (indexer *Indexer) refreshUpgradeVotingHistorySummaries(upgradesVotes []*db
 }
 
 func (indexer *Indexer) loopRefreshUpgradeVotingHistorySummaries() {
-	for {
-		upgradeVotesWrapper := <-indexer.upgradeVotingHistoryCtx.queue
+	defer indexer.loops.Done()
+	queue := indexer.upgradeVotingHistoryCtx.queue
+	refresh := func(upgradeVotesWrapper *upgradesVotesWrapper) {
 		for _, upgradeVotes := range upgradeVotesWrapper.upgradesVotes {
 			indexer.refreshUpgradeVotingHistorySummary(upgradeVotes.Upgrade, upgradeVotesWrapper.height)
 		}
 	}
+	for {
+		select {
+		case upgradeVotesWrapper := <-queue:
+			refresh(upgradeVotesWrapper)
+		case <-indexer.ctx.Done():
+			for len(queue) > 0 {
+				refresh(<-queue)
+			}
+			return
+		}
+	}
 }
 
 func (indexer *Indexer) refreshUpgradeVotingHistorySummary(upgrade uint32, height uint64) {
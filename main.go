@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/idena-network/idena-go/blockchain/types"
 	"github.com/idena-network/idena-go/common/eventbus"
@@ -11,19 +12,29 @@ import (
 	nodeLog "github.com/idena-network/idena-go/log"
 	"github.com/idena-network/idena-go/node"
 	"github.com/idena-network/idena-indexer/config"
+	"github.com/idena-network/idena-indexer/contract/abi"
 	"github.com/idena-network/idena-indexer/contract/verification"
+	"github.com/idena-network/idena-indexer/contract/wasm"
+	"github.com/idena-network/idena-indexer/core/admin"
 	"github.com/idena-network/idena-indexer/core/api"
+	"github.com/idena-network/idena-indexer/core/audit"
+	"github.com/idena-network/idena-indexer/core/explorer"
 	"github.com/idena-network/idena-indexer/core/flip"
+	"github.com/idena-network/idena-indexer/core/health"
 	"github.com/idena-network/idena-indexer/core/holder/contract"
 	"github.com/idena-network/idena-indexer/core/holder/online"
 	state2 "github.com/idena-network/idena-indexer/core/holder/state"
 	"github.com/idena-network/idena-indexer/core/holder/transaction"
 	"github.com/idena-network/idena-indexer/core/holder/upgrade"
+	"github.com/idena-network/idena-indexer/core/jobs"
 	logUtil "github.com/idena-network/idena-indexer/core/log"
 	"github.com/idena-network/idena-indexer/core/mempool"
+	"github.com/idena-network/idena-indexer/core/reorg"
 	"github.com/idena-network/idena-indexer/core/restore"
 	"github.com/idena-network/idena-indexer/core/server"
 	"github.com/idena-network/idena-indexer/core/stats"
+	"github.com/idena-network/idena-indexer/core/stream"
+	"github.com/idena-network/idena-indexer/core/webhook"
 	"github.com/idena-network/idena-indexer/data"
 	"github.com/idena-network/idena-indexer/db"
 	"github.com/idena-network/idena-indexer/import/words"
@@ -34,11 +45,20 @@ import (
 	runtimeMigrationDb "github.com/idena-network/idena-indexer/migration/runtime/db"
 	"github.com/idena-network/idena-indexer/migration/tokens"
 	"github.com/idena-network/idena-indexer/monitoring"
+	"github.com/idena-network/idena-indexer/plugins/largetransfers"
+	"github.com/idena-network/idena-indexer/plugins/manifest"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
 	"gopkg.in/urfave/cli.v1"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -56,17 +76,39 @@ func main() {
 		},
 	}
 
-	app.Action = func(context *cli.Context) error {
+	app.Commands = []cli.Command{
+		migrateCommand(),
+	}
+
+	app.Action = func(cliCtx *cli.Context) error {
 
-		conf := config.LoadConfig(context.String("config"))
-		initLog(conf.Verbosity, conf.NodeVerbosity)
+		conf := config.LoadConfig(cliCtx.String("config"))
+		logLevels := initLog(conf.Verbosity, conf.NodeVerbosity)
 		log.Info("Starting app...")
 
 		txMemPool := transaction.NewMemPool(log.New("component", "txMemPool"))
 
+		healthComponents := health.NewComponents()
+		indexerEventBus := eventbus.New()
+
+		// Trackers saving node events in background are stopped on shutdown after the indexer
+		trackersCtx, stopTrackers := context.WithCancel(context.Background())
+		trackers := &sync.WaitGroup{}
+
+		jobRunner := jobs.NewRunner(jobs.NewPostgres(conf.Postgres.ConnStr),
+			time.Second*time.Duration(conf.Jobs.PollIntervalSec), log.New("component", "jobs"),
+			healthComponents.Register("jobs", time.Minute*5))
+
+		manifests := loadManifests(conf.Plugins.Manifests)
+
 		// Indexer
-		indxr, listener, contractsMemPool, upgradesVoting := initIndexer(conf, txMemPool)
-		defer indxr.Destroy()
+		indxr, listener, dbAccessor, contractsMemPool, upgradesVoting, dataService := initIndexer(trackersCtx, trackers,
+			jobRunner, conf, txMemPool, healthComponents, indexerEventBus, manifests)
+
+		var streamHub, finalizedStreamHub *stream.Hub
+		if conf.Stream.Enabled {
+			streamHub, finalizedStreamHub = initStreamHubs(conf, indxr, indexerEventBus)
+		}
 
 		// Start indexer
 		indxr.Start()
@@ -83,16 +125,100 @@ func main() {
 		appStateHolder := state2.NewAppStateHolder(listener.NodeCtx().AppState, listener.NodeCtx().Blockchain)
 		contractHolder := contract.NewHolder(appStateHolder)
 
-		contractVerifier := initContractVerifier(conf.Postgres.ConnStr, conf.WasmInfoUrl)
+		contractVerifier := initContractVerifier(jobRunner, conf)
+		wasm.RegisterBackfill(jobRunner, jobTypeConfig(conf.Jobs.WasmIntrospection),
+			wasm.NewPostgres(conf.Postgres.ConnStr), log.New("component", "wasmIntrospection"))
+		abiRegistry := abi.NewRegistry(abi.NewPostgres(conf.Postgres.ConnStr), conf.ContractAbi.RequireVerified)
+
+		if err := jobRunner.Start(); err != nil {
+			panic(errors.Wrap(err, "failed to start job runner"))
+		}
 
 		indexerApi := api.NewApi(currentOnlineIdentitiesHolder, upgradesVoting, txMemPool, contractsMemPool,
-			state2.NewHolder(conf.TreeSnapshotDir, log.New("component", "stateHolder")), contractHolder, contractVerifier)
+			state2.NewHolder(conf.TreeSnapshotDir, state2.CacheConfig{
+				MaxSize:    int64(conf.TreeSnapshots.CacheSizeMb) << 20,
+				ExtractDir: conf.TreeSnapshots.ExtractDir,
+			}, log.New("component", "stateHolder")), contractHolder, contractVerifier, abiRegistry)
 		ownRi := server.NewRouterInitializer(indexerApi, apiLogger)
+		healthChecker := health.NewChecker(indxr, func() uint64 {
+			head := listener.NodeCtx().Blockchain.Head
+			if head == nil {
+				return 0
+			}
+			return head.Height()
+		}, dbAccessor, healthComponents, conf.Health.MaxSyncLag, time.Second*time.Duration(conf.Health.MaxBlockAgeSec))
+		explorerDb := explorer.NewPostgres(conf.Postgres.ConnStr)
+		balances := explorer.NewBalances(explorerDb, indxr.LastIndexedHeight, func(height uint64) (*state.StateDB, error) {
+			// the state is loaded without AppStateReadonly to keep its cached state used by the indexer
+			return listener.AppState().State.Readonly(int64(height))
+		}, log.New("component", "balances"))
+		routerInitializers := []server.RouterInitializer{
+			ownRi,
+			server.NewHealthRouterInitializer(healthChecker, apiLogger),
+			server.NewReorgsRouterInitializer(reorg.NewPostgres(conf.Postgres.ConnStr), apiLogger),
+			server.NewFinalityRouterInitializer(indxr.LastIndexedHeight, conf.Finality.Confirmations, apiLogger),
+			server.NewJobsRouterInitializer(jobRunner, apiLogger),
+			server.NewExplorerRouterInitializer(explorerDb, balances, abiRegistry, apiLogger),
+		}
+
+		if conf.Audit.Enabled {
+			routerInitializers = append(routerInitializers, server.NewAuditRouterInitializer(audit.NewPostgres(conf.Postgres.ConnStr), apiLogger))
+		}
+
+		if isMetricsPerformanceMonitor(conf.PerformanceMonitor) {
+			initMetricsGauges(indxr, listener, txMemPool, contractsMemPool)
+			if conf.PerformanceMonitor.MetricsPort > 0 {
+				go startMetricsServer(conf.PerformanceMonitor.MetricsPort)
+			} else {
+				routerInitializers = append(routerInitializers, server.NewMetricsRouterInitializer(monitoring.Handler()))
+			}
+		}
+
+		if streamHub != nil {
+			routerInitializers = append(routerInitializers, server.NewStreamRouterInitializer(streamHub, finalizedStreamHub, apiLogger))
+		}
+
+		if len(manifests) > 0 {
+			routerInitializers = append(routerInitializers,
+				server.NewManifestsRouterInitializer(manifest.NewPostgres(conf.Postgres.ConnStr, manifests), apiLogger))
+		}
+
+		if conf.Webhooks.Enabled {
+			webhookDispatcher := initWebhookDispatcher(conf, healthComponents.Register("webhooks", time.Minute*5))
+			routerInitializers = append(routerInitializers, server.NewWebhooksRouterInitializer(webhookDispatcher, apiLogger))
+		}
 
 		apiServer := server.NewServer(conf.Api.Port, apiLogger)
-		go apiServer.Start(ownRi)
+		go apiServer.Start(routerInitializers...)
 
-		indxr.WaitForNodeStop()
+		var adminServer *server.Server
+		if conf.Admin.Enabled {
+			adminServer = initAdminServer(conf.Admin, indxr, dataService, contractVerifier, abiRegistry, logLevels,
+				conf.Postgres.ConnStr, apiLogger)
+		}
+
+		waitForShutdownSignal(indxr)
+
+		shutdown(time.Second*time.Duration(conf.ShutdownTimeoutSec), func(ctx context.Context) {
+			// stream handlers return once their subscriptions are closed, the api server waits for active handlers
+			if streamHub != nil {
+				streamHub.Stop()
+				finalizedStreamHub.Stop()
+			}
+			if err := apiServer.Stop(ctx); err != nil {
+				log.Warn("Unable to stop api server", "err", err)
+			}
+			if adminServer != nil {
+				if err := adminServer.Stop(ctx); err != nil {
+					log.Warn("Unable to stop admin server", "err", err)
+				}
+			}
+			indxr.Stop()
+			jobRunner.Stop()
+			stopTrackers()
+			trackers.Wait()
+			indxr.Destroy()
+		})
 
 		return nil
 	}
@@ -100,19 +226,60 @@ func main() {
 	app.Run(os.Args)
 }
 
-func initLog(verbosity int, nodeVerbosity int) {
+// waitForShutdownSignal blocks until the process gets SIGINT or SIGTERM, the node stops or the indexer requests restart,
+// the next signal terminates the process immediately
+func waitForShutdownSignal(indxr *indexer.Indexer) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	nodeStopped := make(chan struct{})
+	go func() {
+		indxr.WaitForNodeStop()
+		close(nodeStopped)
+	}()
+	select {
+	case sig := <-signals:
+		log.Info("Shutting down...", "signal", sig)
+	case <-nodeStopped:
+		log.Info("Node stopped, shutting down...")
+	case <-indxr.RestartRequested():
+		log.Info("Indexer requested restart, shutting down...")
+	}
+}
+
+// shutdown runs stop and exits the process if stop is not completed within the timeout
+func shutdown(timeout time.Duration, stop func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		stop(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Info("Shutdown completed")
+	case <-ctx.Done():
+		log.Error(fmt.Sprintf("Unable to complete shutdown within %v", timeout))
+		os.Exit(1)
+	}
+}
+
+func initLog(verbosity int, nodeVerbosity int) *logUtil.ComponentLevels {
 	logLvl := log.Lvl(verbosity)
 	nodeLogLvl := nodeLog.Lvl(nodeVerbosity)
+	var logLevels *logUtil.ComponentLevels
 	if runtime.GOOS == "windows" {
-		log.Root().SetHandler(log.LvlFilterHandler(logLvl, log.StreamHandler(os.Stdout, log.LogfmtFormat())))
+		logLevels = logUtil.NewComponentLevels(logLvl, log.StreamHandler(os.Stdout, log.LogfmtFormat()))
 		nodeLog.Root().SetHandler(nodeLog.LvlFilterHandler(nodeLogLvl, nodeLog.StreamHandler(os.Stdout,
 			nodeLog.LogfmtFormat())))
 	} else {
-		log.Root().SetHandler(log.LvlFilterHandler(logLvl, log.StreamHandler(os.Stderr,
-			log.TerminalFormat(true))))
+		logLevels = logUtil.NewComponentLevels(logLvl, log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
 		nodeLog.Root().SetHandler(nodeLog.LvlFilterHandler(nodeLogLvl, nodeLog.StreamHandler(os.Stderr,
 			nodeLog.TerminalFormat(true))))
 	}
+	log.Root().SetHandler(logLevels)
+	return logLevels
 }
 
 const removedMemPoolTxEventId = eventbus.EventID("removed-mem-pool-tx")
@@ -125,8 +292,16 @@ func (removedMemPoolTxEvent) EventID() eventbus.EventID {
 	return removedMemPoolTxEventId
 }
 
-func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer.Indexer, incoming.Listener, mempool.Contracts, upgrade.UpgradesVotingHolder) {
-	indexerEventBus := eventbus.New()
+func initIndexer(
+	trackersCtx context.Context,
+	trackers *sync.WaitGroup,
+	jobRunner *jobs.Runner,
+	config *config.Config,
+	txMemPool transaction.MemPool,
+	healthComponents *health.Components,
+	indexerEventBus eventbus.Bus,
+	manifests []*manifest.Manifest,
+) (*indexer.Indexer, incoming.Listener, db.Accessor, mempool.Contracts, upgrade.UpgradesVotingHolder, data.Service) {
 	contractsMemPoolBus := eventbus.New()
 	statsCollectorEventBus := eventbus.New()
 	statsCollectorEventBus.Subscribe(stats.RemovedMemPoolTxEventID, func(e eventbus.Event) {
@@ -162,8 +337,11 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 	if config.Data != nil && config.Data.Enabled {
 		dataTable, dataStateTable = config.Data.Table, config.Data.StateTable
 	}
-	dbAccessor := db.NewPostgresAccessor(config.Postgres.ConnStr, config.Postgres.ScriptsDir, wordsLoader,
-		performanceMonitor, config.CommitteeRewardBlocksCount, config.MiningRewards, dataTable, dataStateTable)
+	dbAccessor := db.NewPostgresAccessor(config.Postgres.ConnStr, config.Postgres.ScriptsDir,
+		config.Postgres.MigrationsDir, config.Postgres.MigrationsBaseline, wordsLoader,
+		performanceMonitor, config.CommitteeRewardBlocksCount, config.MiningRewards, config.Webhooks.Enabled,
+		config.Finality.Confirmations, config.BalanceHistory.CheckpointIntervalBlocks, dataTable, dataStateTable,
+		initPlugins(config.Plugins, manifests))
 	restorer := restore.NewRestorer(dbAccessor, listener.AppState(), listener.NodeCtx().Blockchain)
 	var secondaryStorage *runtimeMigration.SecondaryStorage
 	if config.RuntimeMigration.Enabled {
@@ -174,24 +352,8 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 
 	memPoolIndexer := mempool.NewIndexer(dbAccessor, log.New("component", "mpi"))
 
-	flipLoaderLogger := log.New("component", "flipLoader")
-	flipLoader := flip.NewLoader(
-		func() uint64 {
-			return uint64(listener.AppState().State.Epoch())
-		},
-		func() bool {
-			head := listener.NodeCtx().Blockchain.Head
-			if head == nil {
-				return false
-			}
-			prevState, err := listener.AppState().Readonly(head.Height() - 1)
-			if err != nil {
-				flipLoaderLogger.Error(fmt.Sprintf("Unable to get app state for height %d, err %v", head.Height()-1, err))
-				return false
-			}
-			return prevState.State.ValidationPeriod() < state.FlipLotteryPeriod
-		},
-		dbAccessor, listener.Flipper(), flipLoaderLogger)
+	flipLoader := flip.NewLoader(jobRunner, jobTypeConfig(config.Jobs.FlipSize), dbAccessor, listener.Flipper(),
+		log.New("component", "flipLoader"))
 
 	flip.StartContentLoader(
 		dbAccessor,
@@ -200,6 +362,7 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 		time.Minute*time.Duration(config.FlipContentLoader.RetryIntervalMin),
 		listener.Flipper(),
 		log.New("component", "flipContentLoader"),
+		healthComponents.Register("flipContentLoader", time.Minute*30),
 	)
 
 	contractsMemPoolLogger := log.New("component", "contractsMemPool")
@@ -216,14 +379,15 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 
 	upgradesVoting := upgrade.NewUpgradesVotingHolder(listener.NodeCtx().Upgrader)
 
-	peersTracker := mempool.NewPeersTracker(dbAccessor, log.New("component", "peersTracker"))
+	peersTracker := mempool.NewPeersTracker(trackersCtx, dbAccessor, log.New("component", "peersTracker"))
 	nodeEventBus.Subscribe(events.PeersEventID, func(e eventbus.Event) {
 		peersEvent := e.(*events.PeersEvent)
 		peersTracker.AddPeersData(peersEvent.PeersData, peersEvent.Time)
 	})
 
 	if config.VoteCounting.Enabled {
-		voteCountingTracker := mempool.NewVoteCountingTracker(dbAccessor, log.New("component", "voteCountingTracker"))
+		voteCountingTracker := mempool.NewVoteCountingTracker(trackersCtx, trackers, dbAccessor, log.New("component", "voteCountingTracker"),
+			healthComponents.Register("voteCountingTracker", time.Minute*5))
 		statsCollectorEventBus.Subscribe(stats.VoteCountingStepResultEventID, func(e eventbus.Event) {
 			voteCountingTracker.SubmitVoteCountingStepResultEvent(e.(*stats.VoteCountingStepResultEvent))
 		})
@@ -238,8 +402,10 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 		})
 	}
 
+	var dataService data.Service
 	if config.Data != nil && config.Data.Enabled {
-		data.StartDataService(indexerEventBus, dbAccessor, log.New("component", "dataEngine"))
+		dataService = data.StartDataService(indexerEventBus, dbAccessor, log.New("component", "dataEngine"),
+			healthComponents.Register("dataEngine", time.Minute*5))
 	}
 
 	func() {
@@ -271,21 +437,155 @@ func initIndexer(config *config.Config, txMemPool transaction.MemPool) (*indexer
 			config.UpgradeVotingShortHistoryMinShift,
 			indexerEventBus,
 			config.TreeSnapshotDir,
+			config.TreeSnapshots.KeepEpochs,
 			dbAccessor,
 			indexer.NewOracleVotingToProlongDetector(),
-			config.CheckBalances,
+			initAuditor(config.Audit, dbAccessor, tokenContractHolder, config.Postgres.ConnStr),
 			config.DisableDelegationHistory,
+			indexer.CatchUpConfig{
+				Workers:           config.CatchUp.Workers,
+				MaxBlocksInFlight: config.CatchUp.MaxBlocksInFlight,
+				MinBlockAge:       time.Second * time.Duration(config.CatchUp.MinBlockAgeSec),
+				SaveBatchSize:     config.CatchUp.SaveBatchSize,
+			},
 		),
-		listener, contractsMemPool, upgradesVoting
+		listener, dbAccessor, contractsMemPool, upgradesVoting, dataService
+}
+
+// initPlugins returns processors of indexed blocks writing their own tables, custom plugins are to be added here
+func initPlugins(conf config.PluginsConfig, manifests []*manifest.Manifest) []db.Plugin {
+	var res []db.Plugin
+	if conf.LargeTransfers.Enabled {
+		res = append(res, largetransfers.New(decimal.NewFromFloat(conf.LargeTransfers.MinAmount)))
+	}
+	if len(manifests) > 0 {
+		res = append(res, manifest.NewPlugin(manifests, log.New("component", "manifests")))
+	}
+	return res
+}
+
+func loadManifests(conf config.ManifestsPluginConfig) []*manifest.Manifest {
+	if len(conf.Dir) == 0 {
+		return nil
+	}
+	manifests, err := manifest.Load(conf.Dir)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load manifests"))
+	}
+	log.Info(fmt.Sprintf("Loaded %v manifests", len(manifests)))
+	return manifests
+}
+
+func initAuditor(conf config.AuditConfig, dbAccessor db.Accessor, tokenHolder audit.TokenHolder, connStr string) *audit.Auditor {
+	if !conf.Enabled {
+		return nil
+	}
+	return audit.NewAuditor(audit.NewPostgres(connStr), dbAccessor, tokenHolder, audit.Config{
+		IntervalBlocks:   conf.IntervalBlocks,
+		Repair:           conf.Repair,
+		MaxTokenBalances: conf.MaxTokenBalances,
+	}, log.New("component", "audit"))
+}
+
+// initStreamHubs returns hubs publishing messages of all saved blocks and of final blocks only
+func initStreamHubs(conf *config.Config, indxr *indexer.Indexer, indexerEventBus eventbus.Bus) (*stream.Hub, *stream.Hub) {
+	streamDb := stream.NewPostgres(conf.Postgres.ConnStr)
+	logger := log.New("component", "stream")
+	hub := stream.NewHub(streamDb, indxr.LastIndexedHeight, conf.Stream.BufferSize, conf.Stream.MaxReplayBlocks, logger)
+	finality := stream.NewFinality(streamDb, conf.Finality.Confirmations)
+	finalizedHub := stream.NewHub(streamDb, func() uint64 {
+		return finality.FinalizedHeight(indxr.LastIndexedHeight())
+	}, conf.Stream.BufferSize, conf.Stream.MaxReplayBlocks, logger)
+	indexerEventBus.Subscribe(indexer.SavedBlockEventId, func(e eventbus.Event) {
+		messages := stream.BlockMessages(e.(*indexer.SavedBlockEvent).Data)
+		hub.Publish(messages)
+		finalMessages, err := finality.Publish(messages)
+		for _, blockMessages := range finalMessages {
+			finalizedHub.Publish(blockMessages)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Unable to publish final blocks: %v", err))
+		}
+	})
+	indexerEventBus.Subscribe(indexer.ResetEventId, func(e eventbus.Event) {
+		height := e.(*indexer.ResetEvent).Height
+		hub.Reset(height)
+		if finality.Reset(height) {
+			finalizedHub.Reset(height)
+		}
+	})
+	return hub, finalizedHub
+}
+
+func initWebhookDispatcher(conf *config.Config, health *health.Component) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(webhook.NewPostgres(conf.Postgres.ConnStr), webhook.Config{
+		BatchSize:          conf.Webhooks.BatchSize,
+		MaxAttempts:        conf.Webhooks.MaxAttempts,
+		RetryInterval:      time.Second * time.Duration(conf.Webhooks.RetryIntervalSec),
+		MaxRetryInterval:   time.Second * time.Duration(conf.Webhooks.MaxRetryIntervalSec),
+		RequestTimeout:     time.Second * time.Duration(conf.Webhooks.RequestTimeoutSec),
+		DeliveredRetention: time.Hour * time.Duration(conf.Webhooks.DeliveredRetentionHours),
+	}, log.New("component", "webhooks"), health)
+	dispatcher.Start()
+	return dispatcher
+}
+
+const performanceMonitorTypeMetrics = "metrics"
+
+func isMetricsPerformanceMonitor(config config.PerformanceMonitorConfig) bool {
+	return config.Enabled && config.Type == performanceMonitorTypeMetrics
 }
 
 func initPerformanceMonitor(config config.PerformanceMonitorConfig) monitoring.PerformanceMonitor {
 	if !config.Enabled {
 		return monitoring.NewEmptyPerformanceMonitor()
 	}
+	if isMetricsPerformanceMonitor(config) {
+		return monitoring.NewMetricsPerformanceMonitor(prometheus.DefaultRegisterer)
+	}
 	return monitoring.NewPerformanceMonitor(config.BlocksToLog, log.New("component", "pm"))
 }
 
+func initMetricsGauges(indxr *indexer.Indexer, listener incoming.Listener, txMemPool transaction.MemPool, contractsMemPool mempool.Contracts) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "idena_indexer_sync_lag_blocks",
+		Help: "Node head height minus last indexed height",
+	}, func() float64 {
+		head := listener.NodeCtx().Blockchain.Head
+		if head == nil {
+			return 0
+		}
+		return float64(head.Height()) - float64(indxr.LastIndexedHeight())
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "idena_indexer_mempool_txs",
+		Help: "Number of transactions in mem pool",
+	}, func() float64 {
+		cnt, _ := txMemPool.GetTransactionsCount()
+		return float64(cnt)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "idena_indexer_contracts_mempool_txs",
+		Help: "Number of contract transactions in contracts mem pool",
+	}, func() float64 {
+		return float64(contractsMemPool.Size().Txs)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "idena_indexer_contracts_mempool_oracle_voting_deploys",
+		Help: "Number of oracle voting deploy authors in contracts mem pool",
+	}, func() float64 {
+		return float64(contractsMemPool.Size().OracleVotingDeploys)
+	})
+}
+
+func startMetricsServer(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", monitoring.Handler())
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil {
+		panic(err)
+	}
+}
+
 func initNodeConfig(nodeConfigFile string) *config2.Config {
 	cfg, err := config2.MakeConfigFromFile(nodeConfigFile)
 	if err != nil {
@@ -326,8 +626,54 @@ func cfgTransform(cfg *config2.Config) {
 	}
 }
 
-func initContractVerifier(postgresConnStr, wasmInfoUrl string) verification.Verifier {
-	verifierDb := verification.NewVerifierPostgres(postgresConnStr)
-	wasmInfo := verification.NewWasmInfo(wasmInfoUrl)
-	return verification.NewVerifier(verifierDb, wasmInfo, log.New("component", "contractVerifier"))
+func initContractVerifier(jobRunner *jobs.Runner, conf *config.Config) verification.Verifier {
+	verifierDb := verification.NewVerifierPostgres(conf.Postgres.ConnStr)
+	backend := verification.NewWasmInfo(conf.WasmInfoUrl)
+	if localConf := conf.LocalCompiler; localConf != nil {
+		var err error
+		backend, err = verification.NewLocalBackend(verification.LocalConfig{
+			Command:        localConf.Command,
+			Output:         localConf.Output,
+			VersionCommand: localConf.VersionCommand,
+			Timeout:        time.Second * time.Duration(localConf.TimeoutSec),
+			TempDir:        localConf.TempDir,
+			MaxSourceSize:  int64(localConf.MaxSourceSizeMb) << 20,
+			MaxLogSize:     localConf.MaxLogSizeKb << 10,
+		})
+		if err != nil {
+			panic(errors.Wrap(err, "invalid local compiler config"))
+		}
+	}
+	return verification.NewVerifier(jobRunner, jobTypeConfig(conf.Jobs.ContractVerification), verifierDb, backend,
+		log.New("component", "contractVerifier"))
+}
+
+func initAdminServer(
+	conf config.AdminConfig,
+	indxr *indexer.Indexer,
+	dataService data.Service,
+	contractVerifier verification.Verifier,
+	abiRegistry *abi.Registry,
+	logLevels *logUtil.ComponentLevels,
+	postgresConnStr string,
+	logger log.Logger,
+) *server.Server {
+	if len(conf.Token) == 0 {
+		panic("admin token is not set")
+	}
+	adminApi := admin.NewAdmin(admin.NewPostgres(postgresConnStr), indxr, dataService, contractVerifier, abiRegistry,
+		logLevels, log.New("component", "admin"))
+	adminServer := server.NewServer(conf.Port, logger)
+	go adminServer.Start(server.NewAdminRouterInitializer(adminApi, conf.Token, logger))
+	return adminServer
+}
+
+func jobTypeConfig(conf config.JobTypeConfig) jobs.TypeConfig {
+	return jobs.TypeConfig{
+		Concurrency:      conf.Concurrency,
+		MaxAttempts:      conf.MaxAttempts,
+		RetryInterval:    time.Second * time.Duration(conf.RetryIntervalSec),
+		MaxRetryInterval: time.Second * time.Duration(conf.MaxRetryIntervalSec),
+		Lease:            time.Second * time.Duration(conf.LeaseSec),
+	}
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/idena-network/idena-indexer/config"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/migration/schema"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+	"text/tabwriter"
+)
+
+func migrateCommand() cli.Command {
+	return cli.Command{
+		Name:  "migrate",
+		Usage: "Manage postgres schema migrations",
+		Subcommands: []cli.Command{
+			{
+				Name:  "status",
+				Usage: "Show applied and pending migrations",
+				Action: func(context *cli.Context) error {
+					migrator, destroy := initMigrator(context)
+					defer destroy()
+					statuses, err := migrator.Status()
+					if err != nil {
+						return err
+					}
+					printMigrationStatuses(statuses)
+					return nil
+				},
+			},
+			{
+				Name:  "up",
+				Usage: "Apply pending migrations",
+				Action: func(context *cli.Context) error {
+					migrator, destroy := initMigrator(context)
+					defer destroy()
+					cnt, err := migrator.Up(false)
+					log.Info(fmt.Sprintf("Applied migrations: %v", cnt))
+					return err
+				},
+			},
+			{
+				Name:      "down-to",
+				Usage:     "Roll back migrations applied after the specified one",
+				ArgsUsage: "<version>",
+				Action: func(context *cli.Context) error {
+					version := context.Args().First()
+					if len(version) == 0 {
+						return errors.New("migration version is required")
+					}
+					migrator, destroy := initMigrator(context)
+					defer destroy()
+					cnt, err := migrator.DownTo(version)
+					log.Info(fmt.Sprintf("Rolled back migrations: %v", cnt))
+					return err
+				},
+			},
+		},
+	}
+}
+
+func initMigrator(context *cli.Context) (*schema.Migrator, func()) {
+	conf := config.LoadConfig(context.GlobalString("config"))
+	initLog(conf.Verbosity, conf.NodeVerbosity)
+	db, err := sql.Open("postgres", conf.Postgres.ConnStr)
+	if err != nil {
+		panic(err)
+	}
+	migrator := schema.NewMigrator(db, conf.Postgres.MigrationsDir, conf.Postgres.MigrationsBaseline, log.New("component", "migrator"))
+	return migrator, func() {
+		db.Close()
+	}
+}
+
+func printMigrationStatuses(statuses []schema.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Missing:
+			state = "applied, script missing"
+		case status.ChecksumMismatch:
+			state = "applied, checksum mismatch"
+		case status.Baseline:
+			state = "baseline"
+		case status.Applied:
+			state = "applied"
+		}
+		var appliedAt string
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", status.Version, state, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	upSuffix   = ".sql"
+	downSuffix = ".down.sql"
+)
+
+var datedVersionPattern = regexp.MustCompile(`^\d{8}-`)
+
+type Migration struct {
+	Version  string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func (m *Migration) HasDown() bool {
+	return len(strings.TrimSpace(m.Down)) > 0
+}
+
+// LoadMigrations reads migration scripts from dirPath. Each `<version>.sql` file is an up script and an optional
+// `<version>.down.sql` file is its rollback. Undated legacy scripts (e.g. fork-22.sql) go before dated ones.
+func LoadMigrations(dirPath string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	migrationsByVersion := make(map[string]*Migration)
+	get := func(version string) *Migration {
+		m, ok := migrationsByVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			migrationsByVersion[version] = m
+		}
+		return m
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), upSuffix) {
+			continue
+		}
+		bytes, err := ioutil.ReadFile(filepath.Join(dirPath, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(file.Name(), downSuffix) {
+			get(strings.TrimSuffix(file.Name(), downSuffix)).Down = string(bytes)
+			continue
+		}
+		m := get(strings.TrimSuffix(file.Name(), upSuffix))
+		m.Up = string(bytes)
+		m.Checksum = checksum(bytes)
+	}
+	res := make([]*Migration, 0, len(migrationsByVersion))
+	for _, m := range migrationsByVersion {
+		if len(m.Checksum) == 0 {
+			return nil, fmt.Errorf("migration %v has down script only", m.Version)
+		}
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return less(res[i].Version, res[j].Version)
+	})
+	return res, nil
+}
+
+func less(version1, version2 string) bool {
+	dated1, dated2 := datedVersionPattern.MatchString(version1), datedVersionPattern.MatchString(version2)
+	if dated1 != dated2 {
+		return dated2
+	}
+	return version1 < version2
+}
+
+func checksum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
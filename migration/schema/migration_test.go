@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LoadMigrations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20210122-b.sql":      "select 2;",
+		"20210113-a.sql":      "select 1;",
+		"20210113-a.down.sql": "select -1;",
+		"fork-22.sql":         "select 0;",
+		"readme.txt":          "not a migration",
+	}
+	for name, content := range files {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	migrations, err := LoadMigrations(dir)
+	require.Nil(t, err)
+	require.Len(t, migrations, 3)
+
+	require.Equal(t, "fork-22", migrations[0].Version)
+	require.False(t, migrations[0].HasDown())
+
+	require.Equal(t, "20210113-a", migrations[1].Version)
+	require.Equal(t, "select 1;", migrations[1].Up)
+	require.Equal(t, "select -1;", migrations[1].Down)
+	require.True(t, migrations[1].HasDown())
+
+	require.Equal(t, "20210122-b", migrations[2].Version)
+	require.Len(t, migrations[2].Checksum, 64)
+	require.NotEqual(t, migrations[1].Checksum, migrations[2].Checksum)
+}
+
+func Test_LoadMigrationsDownOnly(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "20210113-a.down.sql"), []byte("select 1;"), 0644))
+
+	_, err := LoadMigrations(dir)
+	require.NotNil(t, err)
+}
+
+func Test_LoadMigrationsRepoScripts(t *testing.T) {
+	migrations, err := LoadMigrations(filepath.Join("..", "..", "resources", "scripts", "migration"))
+	require.Nil(t, err)
+	require.NotEmpty(t, migrations)
+	require.Equal(t, "fork-22", migrations[0].Version)
+	require.Equal(t, "fork-23", migrations[1].Version)
+	require.Equal(t, "20210113-contracts-api-for-explorer", migrations[2].Version)
+}
@@ -0,0 +1,373 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// advisoryLockKey prevents several indexer instances from migrating the same database concurrently
+const advisoryLockKey = 7324119402
+
+const createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version     character varying(100) NOT NULL,
+    checksum    character(64)          NOT NULL,
+    applied_at  bigint                 NOT NULL,
+    duration_ms bigint                 NOT NULL,
+    baseline    boolean                NOT NULL,
+    CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+)`
+
+type ChecksumMismatchError struct {
+	Version  string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum of applied migration %v has changed, expected: %v, actual: %v", e.Version, e.Expected, e.Actual)
+}
+
+// ApplyError means that a migration script failed, retrying it won't help until the script or the db is fixed
+type ApplyError struct {
+	Version string
+	Err     error
+}
+
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("unable to apply migration %v: %v", e.Version, e.Err)
+}
+
+// BaselineError means that the migrations table cannot be initialized with the current baseline configuration
+type BaselineError struct {
+	Reason string
+}
+
+func (e *BaselineError) Error() string {
+	return e.Reason
+}
+
+// IsFatal returns true if the error cannot be fixed by retrying migrations without changing scripts or configuration
+func IsFatal(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *ChecksumMismatchError, *ApplyError, *BaselineError:
+		return true
+	}
+	return false
+}
+
+type Status struct {
+	Version          string
+	Applied          bool
+	Baseline         bool
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+	Missing          bool
+}
+
+type appliedMigration struct {
+	version   string
+	checksum  string
+	appliedAt int64
+	baseline  bool
+}
+
+type Migrator struct {
+	db       *sql.DB
+	dirPath  string
+	baseline string
+	logger   log.Logger
+}
+
+// NewMigrator creates a migrator for scripts located in dirPath. When the tracking table is created for an existing
+// schema, migrations up to and including baseline are recorded as already applied without running them. The baseline
+// is required in that case because the migrator cannot detect which scripts the schema has already received.
+func NewMigrator(db *sql.DB, dirPath string, baseline string, logger log.Logger) *Migrator {
+	return &Migrator{
+		db:       db,
+		dirPath:  dirPath,
+		baseline: baseline,
+		logger:   logger,
+	}
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	migrations, err := LoadMigrations(m.dirPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load migrations")
+	}
+	var exists bool
+	if err := m.db.QueryRow("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	appliedByVersion := make(map[string]*appliedMigration)
+	if exists {
+		if appliedByVersion, err = loadApplied(m.db); err != nil {
+			return nil, err
+		}
+	}
+	res := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		item := Status{
+			Version: migration.Version,
+		}
+		if applied, ok := appliedByVersion[migration.Version]; ok {
+			appliedAt := time.Unix(applied.appliedAt, 0).UTC()
+			item.Applied = true
+			item.Baseline = applied.baseline
+			item.AppliedAt = &appliedAt
+			item.ChecksumMismatch = applied.checksum != migration.Checksum
+			delete(appliedByVersion, migration.Version)
+		}
+		res = append(res, item)
+	}
+	for version, applied := range appliedByVersion {
+		appliedAt := time.Unix(applied.appliedAt, 0).UTC()
+		res = append(res, Status{
+			Version:   version,
+			Applied:   true,
+			Baseline:  applied.baseline,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	return res, nil
+}
+
+// Up applies pending migrations in order, each one in its own transaction. It fails with *ChecksumMismatchError
+// without applying anything if an already applied script has been modified. freshSchema must be true only if the
+// schema has just been created by init.sql, which always creates the latest schema, so all the scripts are recorded
+// as baseline.
+func (m *Migrator) Up(freshSchema bool) (int, error) {
+	migrations, err := LoadMigrations(m.dirPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to load migrations")
+	}
+	conn, unlock, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := m.initTable(conn, migrations, freshSchema); err != nil {
+		return 0, errors.Wrap(err, "unable to init migrations table")
+	}
+	appliedByVersion, err := loadApplied(conn)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to load applied migrations")
+	}
+	if err := verify(migrations, appliedByVersion, m.logger); err != nil {
+		return 0, err
+	}
+	var cnt int
+	for _, migration := range migrations {
+		if _, ok := appliedByVersion[migration.Version]; ok {
+			continue
+		}
+		m.logger.Info(fmt.Sprintf("Applying migration %v", migration.Version))
+		start := time.Now()
+		if err := apply(conn, migration, start); err != nil {
+			return cnt, &ApplyError{Version: migration.Version, Err: err}
+		}
+		cnt++
+		m.logger.Info(fmt.Sprintf("Applied migration %v", migration.Version), "d", time.Since(start))
+	}
+	return cnt, nil
+}
+
+// DownTo rolls back applied migrations which go after version in reverse order. All of them must have down scripts.
+func (m *Migrator) DownTo(version string) (int, error) {
+	migrations, err := LoadMigrations(m.dirPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to load migrations")
+	}
+	targetIdx := -1
+	for i, migration := range migrations {
+		if migration.Version == version {
+			targetIdx = i
+			break
+		}
+	}
+	if targetIdx < 0 {
+		return 0, errors.Errorf("unknown migration %v", version)
+	}
+	conn, unlock, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	appliedByVersion, err := loadApplied(conn)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to load applied migrations")
+	}
+	if _, ok := appliedByVersion[version]; !ok {
+		return 0, errors.Errorf("migration %v is not applied", version)
+	}
+	if err := verify(migrations, appliedByVersion, m.logger); err != nil {
+		return 0, err
+	}
+	var toRollBack []*Migration
+	for i := len(migrations) - 1; i > targetIdx; i-- {
+		migration := migrations[i]
+		if _, ok := appliedByVersion[migration.Version]; !ok {
+			continue
+		}
+		if !migration.HasDown() {
+			return 0, errors.Errorf("migration %v has no down script", migration.Version)
+		}
+		toRollBack = append(toRollBack, migration)
+	}
+	var cnt int
+	for _, migration := range toRollBack {
+		m.logger.Info(fmt.Sprintf("Rolling back migration %v", migration.Version))
+		start := time.Now()
+		if err := rollBack(conn, migration); err != nil {
+			return cnt, errors.Wrapf(err, "unable to roll back migration %v", migration.Version)
+		}
+		cnt++
+		m.logger.Info(fmt.Sprintf("Rolled back migration %v", migration.Version), "d", time.Since(start))
+	}
+	return cnt, nil
+}
+
+func (m *Migrator) lock() (*sql.Conn, func(), error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		conn.Close()
+		return nil, nil, errors.Wrap(err, "unable to acquire migrations lock")
+	}
+	unlock := func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockKey); err != nil {
+			m.logger.Warn(fmt.Sprintf("Unable to release migrations lock: %v", err))
+		}
+		conn.Close()
+	}
+	return conn, unlock, nil
+}
+
+func (m *Migrator) initTable(conn *sql.Conn, migrations []*Migration, freshSchema bool) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var exists bool
+	if err := tx.QueryRow("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	baselineIdx := len(migrations) - 1
+	if !freshSchema {
+		if len(m.baseline) == 0 {
+			return &BaselineError{Reason: "migrations baseline is required to start tracking migrations of the existing schema"}
+		}
+		baselineIdx = -1
+		for i, migration := range migrations {
+			if migration.Version == m.baseline {
+				baselineIdx = i
+				break
+			}
+		}
+		if baselineIdx < 0 {
+			return &BaselineError{Reason: fmt.Sprintf("unknown baseline migration %v", m.baseline)}
+		}
+	}
+	if _, err := tx.Exec(createTableQuery); err != nil {
+		return err
+	}
+	timestamp := time.Now().UTC().Unix()
+	for _, migration := range migrations[:baselineIdx+1] {
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, checksum, applied_at, duration_ms, baseline) VALUES ($1, $2, $3, 0, true)",
+			migration.Version, migration.Checksum, timestamp); err != nil {
+			return err
+		}
+	}
+	m.logger.Info(fmt.Sprintf("Created migrations table, baseline migrations: %v", baselineIdx+1))
+	return tx.Commit()
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadApplied(q queryer) (map[string]*appliedMigration, error) {
+	rows, err := q.QueryContext(context.Background(), "SELECT version, checksum, applied_at, baseline FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]*appliedMigration)
+	for rows.Next() {
+		item := &appliedMigration{}
+		if err := rows.Scan(&item.version, &item.checksum, &item.appliedAt, &item.baseline); err != nil {
+			return nil, err
+		}
+		res[item.version] = item
+	}
+	return res, rows.Err()
+}
+
+func verify(migrations []*Migration, appliedByVersion map[string]*appliedMigration, logger log.Logger) error {
+	known := make(map[string]struct{}, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = struct{}{}
+		applied, ok := appliedByVersion[migration.Version]
+		if !ok || applied.checksum == migration.Checksum {
+			continue
+		}
+		return &ChecksumMismatchError{
+			Version:  migration.Version,
+			Expected: applied.checksum,
+			Actual:   migration.Checksum,
+		}
+	}
+	for version := range appliedByVersion {
+		if _, ok := known[version]; !ok {
+			logger.Warn(fmt.Sprintf("Applied migration %v not found in scripts dir", version))
+		}
+	}
+	return nil
+}
+
+func apply(conn *sql.Conn, migration *Migration, start time.Time) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(migration.Up); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, checksum, applied_at, duration_ms, baseline) VALUES ($1, $2, $3, $4, false)",
+		migration.Version, migration.Checksum, start.UTC().Unix(), time.Since(start).Milliseconds()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func rollBack(conn *sql.Conn, migration *Migration) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(migration.Down); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,42 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	IndexedBlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "idena_indexer_indexed_blocks_total",
+		Help: "Number of indexed blocks",
+	})
+	Resets = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "idena_indexer_resets_total",
+		Help: "Number of indexer db resets to lower height",
+	})
+	RestoreRuns = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "idena_indexer_restore_runs_total",
+		Help: "Number of db data restore runs",
+	})
+	FlipContentLoadFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "idena_indexer_flip_content_load_failures_total",
+		Help: "Number of failed flip content load attempts",
+	})
+	ContractVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "idena_indexer_contract_verifications_total",
+		Help: "Number of completed contract verifications",
+	}, []string{"result"})
+	EpochStateCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "idena_indexer_epoch_state_cache_requests_total",
+		Help: "Number of epoch state requests by cache result",
+	}, []string{"result"})
+	EpochStateCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "idena_indexer_epoch_state_cache_bytes",
+		Help: "Approximate memory size of cached epoch states",
+	})
+	EpochStateLoadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "idena_indexer_epoch_state_load_duration_seconds",
+		Help:    "Time to load epoch states by source, either the snapshot file or the extracted db",
+		Buckets: DefaultSectionBuckets,
+	}, []string{"source"})
+)
@@ -0,0 +1,13 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+var DefaultSectionBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Handler serves metrics of the default prometheus registry
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
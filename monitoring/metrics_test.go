@@ -0,0 +1,24 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_MetricsPerformanceMonitor(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pm := NewMetricsPerformanceMonitor(registry)
+	pm.Start("Full")
+	pm.Start("Save")
+	pm.Complete("Save")
+	pm.Start("Flips")
+	pm.Complete("Flips")
+	require.Panics(t, func() {
+		pm.Complete("Convert")
+	})
+	cnt, err := testutil.GatherAndCount(registry, "idena_indexer_section_duration_seconds")
+	require.Nil(t, err)
+	require.Equal(t, 2, cnt)
+}
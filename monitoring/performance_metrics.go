@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+type metricsPerformanceMonitor struct {
+	sectionDuration *prometheus.HistogramVec
+	mutex           sync.Mutex
+	startTimes      []*eventType
+}
+
+// NewMetricsPerformanceMonitor creates a monitor which observes sections durations in the histogram
+// idena_indexer_section_duration_seconds labeled by section name instead of writing them to the log
+func NewMetricsPerformanceMonitor(registerer prometheus.Registerer) PerformanceMonitor {
+	sectionDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "idena_indexer_section_duration_seconds",
+		Help:    "Duration of performance monitor sections",
+		Buckets: DefaultSectionBuckets,
+	}, []string{"section"})
+	registerer.MustRegister(sectionDuration)
+	return &metricsPerformanceMonitor{
+		sectionDuration: sectionDuration,
+	}
+}
+
+func (pm *metricsPerformanceMonitor) Start(name string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.startTimes = append(pm.startTimes, &eventType{
+		name: name,
+		time: time.Now(),
+	})
+}
+
+func (pm *metricsPerformanceMonitor) Complete(name string) {
+	t := time.Now()
+	pm.mutex.Lock()
+	eventType := pm.startTimes[len(pm.startTimes)-1]
+	if eventType.name != name {
+		pm.mutex.Unlock()
+		panic(fmt.Sprintf("unexpected name to complete: %v, expected: %v", name, eventType.name))
+	}
+	pm.startTimes = pm.startTimes[:len(pm.startTimes)-1]
+	pm.mutex.Unlock()
+	pm.sectionDuration.WithLabelValues(name).Observe(t.Sub(eventType.time).Seconds())
+}
@@ -0,0 +1,73 @@
+package largetransfers
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-go/blockchain"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	initQuery = `CREATE TABLE IF NOT EXISTS large_transfers
+(
+    tx_hash        character(66)   NOT NULL,
+    block_height   bigint          NOT NULL,
+    "from"         character(42)   NOT NULL,
+    "to"           character(42),
+    amount         numeric(30, 18) NOT NULL,
+    sender_balance numeric(30, 18) NOT NULL,
+    CONSTRAINT large_transfers_pkey PRIMARY KEY (tx_hash)
+);
+CREATE INDEX IF NOT EXISTS large_transfers_block_height_idx on large_transfers (block_height);`
+	insertQuery = `INSERT INTO large_transfers (tx_hash, block_height, "from", "to", amount, sender_balance)
+VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)`
+	resetQuery = `DELETE FROM large_transfers WHERE block_height > $1`
+)
+
+type plugin struct {
+	minAmount decimal.Decimal
+}
+
+// New creates the plugin saving txs transferring at least minAmount coins along with balances of senders after the
+// blocks to the large_transfers table
+func New(minAmount decimal.Decimal) db.Plugin {
+	return &plugin{
+		minAmount: minAmount,
+	}
+}
+
+func (p *plugin) Name() string {
+	return "largeTransfers"
+}
+
+func (p *plugin) Init(tx *sql.Tx) error {
+	_, err := tx.Exec(initQuery)
+	return err
+}
+
+func (p *plugin) ProcessBlock(tx *sql.Tx, data *db.Data) error {
+	for _, transaction := range data.Block.Transactions {
+		if transaction.Amount.LessThan(p.minAmount) {
+			continue
+		}
+		senderBalance := blockchain.ConvertToFloat(data.Source.NewState.State.GetBalance(common.HexToAddress(transaction.From)))
+		if _, err := tx.Exec(insertQuery,
+			transaction.Hash,
+			data.Block.Height,
+			transaction.From,
+			transaction.To,
+			transaction.Amount,
+			senderBalance,
+		); err != nil {
+			return errors.Wrapf(err, "unable to save large transfer %v", transaction.Hash)
+		}
+	}
+	return nil
+}
+
+func (p *plugin) ResetTo(tx *sql.Tx, height uint64) error {
+	_, err := tx.Exec(resetQuery, height)
+	return err
+}
@@ -0,0 +1,64 @@
+package manifest
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"strconv"
+)
+
+var columnTypes = map[abi.ArgType]string{
+	abi.ArgTypeAddress: "character(42)",
+	abi.ArgTypeString:  "text",
+	abi.ArgTypeBytes:   "bytea",
+	abi.ArgTypeBool:    "boolean",
+	abi.ArgTypeUint64:  "numeric(20,0)",
+	abi.ArgTypeBigInt:  "numeric",
+	abi.ArgTypeDna:     "numeric",
+}
+
+func columnType(t abi.ArgType) string {
+	return columnTypes[t]
+}
+
+// parseFilter validates the api filter value and returns the sql condition comparing the column with the parameter
+// number paramNum
+func parseFilter(t abi.ArgType, column, value string, paramNum int) (string, interface{}, error) {
+	param := "$" + strconv.Itoa(paramNum)
+	switch t {
+	case abi.ArgTypeAddress:
+		if !common.IsHexAddress(value) {
+			return "", nil, errors.New("invalid address")
+		}
+		return "lower(" + column + ") = lower(" + param + ")", value, nil
+	case abi.ArgTypeString:
+		return column + " = " + param, value, nil
+	case abi.ArgTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, errors.New("invalid bool")
+		}
+		return column + " = " + param, b, nil
+	case abi.ArgTypeUint64, abi.ArgTypeBigInt, abi.ArgTypeDna:
+		d, err := decimal.NewFromString(value)
+		if err != nil {
+			return "", nil, errors.New("invalid number")
+		}
+		return column + " = " + param, d, nil
+	default:
+		return "", nil, errors.Errorf("filtering by %v args is not supported", t)
+	}
+}
+
+// selectExpr returns the expression reading the column as text or boolean
+func selectExpr(t abi.ArgType, column string) string {
+	switch t {
+	case abi.ArgTypeBytes:
+		return "'0x' || encode(" + column + ", 'hex')"
+	case abi.ArgTypeBool, abi.ArgTypeAddress, abi.ArgTypeString:
+		return column
+	default:
+		return column + "::text"
+	}
+}
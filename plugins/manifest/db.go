@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/core/types"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contractFilter is the filter name to select events of the contract address
+const contractFilter = "contract"
+
+// Postgres reads contract events saved by the manifests plugin
+type Postgres struct {
+	db        *sql.DB
+	manifests []*Manifest
+}
+
+func NewPostgres(connStr string, manifests []*Manifest) *Postgres {
+	dbAccessor, err := sql.Open("postgres", connStr)
+	if err != nil {
+		panic(err)
+	}
+	dbAccessor.SetMaxOpenConns(5)
+	dbAccessor.SetMaxIdleConns(5)
+	dbAccessor.SetConnMaxLifetime(5 * time.Minute)
+	return &Postgres{
+		db:        dbAccessor,
+		manifests: manifests,
+	}
+}
+
+// Manifests returns the loaded manifests
+func (p *Postgres) Manifests() []*Manifest {
+	return p.manifests
+}
+
+// Events returns events starting from the latest one, filters contain values of args or the contract address to be
+// equal to
+func (p *Postgres) Events(
+	manifestName, eventName string,
+	filters map[string]string,
+	count uint64,
+	continuationToken *string,
+) ([]*types.ManifestEvent, *string, error) {
+	var manifest *Manifest
+	for _, m := range p.manifests {
+		if m.Name == manifestName {
+			manifest = m
+			break
+		}
+	}
+	if manifest == nil {
+		return nil, nil, errors.Errorf("unknown manifest %v", manifestName)
+	}
+	event := manifest.eventIgnoreCase(eventName)
+	if event == nil {
+		return nil, nil, errors.Errorf("unknown event %v", eventName)
+	}
+	var startId *uint64
+	if continuationToken != nil {
+		id, err := strconv.ParseUint(*continuationToken, 10, 64)
+		if err != nil {
+			return nil, nil, errors.New("invalid continuation token")
+		}
+		startId = &id
+	}
+	query, params, err := eventsQuery(event, filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := p.db.Query(query, append([]interface{}{count + 1, startId}, params...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var res []*types.ManifestEvent
+	var ids []uint64
+	for rows.Next() {
+		item := &types.ManifestEvent{
+			Args: make(map[string]interface{}, len(event.Args)),
+		}
+		var id uint64
+		dest := []interface{}{&id, &item.BlockHeight, &item.TxHash, &item.Contract}
+		values := make([]interface{}, len(event.Args))
+		for i, arg := range event.Args {
+			if arg.Type == abi.ArgTypeBool {
+				values[i] = &sql.NullBool{}
+			} else {
+				values[i] = &sql.NullString{}
+			}
+		}
+		if err := rows.Scan(append(dest, values...)...); err != nil {
+			return nil, nil, err
+		}
+		for i, arg := range event.Args {
+			var value interface{}
+			switch v := values[i].(type) {
+			case *sql.NullBool:
+				if v.Valid {
+					value = v.Bool
+				}
+			case *sql.NullString:
+				if v.Valid {
+					value = v.String
+				}
+			}
+			item.Args[arg.Name] = value
+		}
+		res = append(res, item)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var nextContinuationToken *string
+	if uint64(len(res)) > count {
+		t := strconv.FormatUint(ids[count], 10)
+		nextContinuationToken = &t
+		res = res[:count]
+	}
+	return res, nextContinuationToken, nil
+}
+
+func eventsQuery(event *Event, filters map[string]string) (string, []interface{}, error) {
+	columns := []string{"id", "block_height", "tx_hash", "contract"}
+	for _, arg := range event.Args {
+		columns = append(columns, selectExpr(arg.Type, pq.QuoteIdentifier(arg.Name)))
+	}
+	conditions := []string{"($2::bigint IS NULL OR id <= $2)"}
+	var params []interface{}
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		argType := abi.ArgTypeAddress
+		if name != contractFilter {
+			arg := event.arg(name)
+			if arg == nil {
+				return "", nil, errors.Errorf("unknown arg %v", name)
+			}
+			argType = arg.Type
+		}
+		condition, param, err := parseFilter(argType, pq.QuoteIdentifier(name), filters[name], len(params)+3)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "wrong value %v=%v", name, filters[name])
+		}
+		conditions = append(conditions, condition)
+		params = append(params, param)
+	}
+	query := fmt.Sprintf("SELECT %v FROM %v WHERE %v ORDER BY id DESC LIMIT $1", strings.Join(columns, ", "),
+		pq.QuoteIdentifier(event.Table), strings.Join(conditions, " AND "))
+	return query, params, nil
+}
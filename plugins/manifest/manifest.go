@@ -0,0 +1,224 @@
+package manifest
+
+import (
+	"bytes"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Manifest declares events of a contract to be decoded into tables, it is read from a yaml or json file:
+//
+//	name: lottery
+//	codeHash: "0x..."
+//	events:
+//	  - name: win
+//	    table: lottery_wins
+//	    args:
+//	      - name: winner
+//	        type: address
+//	        indexed: true
+//	      - name: prize
+//	        type: dna
+//
+// Either contract or codeHash is to be set, codeHash matches all contracts deployed with the code.
+type Manifest struct {
+	Name     string   `yaml:"name" json:"name"`
+	Contract string   `yaml:"contract" json:"contract,omitempty"`
+	CodeHash string   `yaml:"codeHash" json:"codeHash,omitempty"`
+	Events   []*Event `yaml:"events" json:"events"`
+
+	contract *common.Address
+	codeHash *common.Hash
+}
+
+// Event maps args of the contract event with the name to columns of the table, args missing in the event data are
+// saved as nulls, extra ones are ignored
+type Event struct {
+	Name  string `yaml:"name" json:"name"`
+	Table string `yaml:"table" json:"table"`
+	Args  []*Arg `yaml:"args" json:"args"`
+}
+
+// Arg is saved to the column with the arg name, indexed args are filterable by the api without full table scans
+type Arg struct {
+	Name    string      `yaml:"name" json:"name"`
+	Type    abi.ArgType `yaml:"type" json:"type"`
+	Indexed bool        `yaml:"indexed" json:"indexed,omitempty"`
+}
+
+// identifierRegexp limits table and column names so that names of indexes built from them fit postgres max length
+var identifierRegexp = regexp.MustCompile("^[a-z][a-z0-9_]{0,27}$")
+
+// reservedColumns are added to each event table
+var reservedColumns = map[string]struct{}{
+	"id":           {},
+	"block_height": {},
+	"tx_hash":      {},
+	"event_index":  {},
+	"contract":     {},
+}
+
+// Load reads all *.yaml, *.yml and *.json manifests in the dir ordered by file names
+func Load(dir string) ([]*Manifest, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	var res []*Manifest
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := Parse(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid manifest %v", name)
+		}
+		res = append(res, manifest)
+	}
+	if err := validateUniqueness(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Parse reads the manifest in yaml or json, unknown fields are not allowed to catch typos
+func Parse(data []byte) (*Manifest, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	manifest := &Manifest{}
+	if err := decoder.Decode(manifest); err != nil {
+		return nil, err
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (m *Manifest) validate() error {
+	if !identifierRegexp.MatchString(m.Name) {
+		return errors.Errorf("invalid name %q", m.Name)
+	}
+	if (len(m.Contract) == 0) == (len(m.CodeHash) == 0) {
+		return errors.New("either contract or codeHash is required")
+	}
+	if len(m.Contract) > 0 {
+		if !common.IsHexAddress(m.Contract) {
+			return errors.Errorf("invalid contract %v", m.Contract)
+		}
+		contract := common.HexToAddress(m.Contract)
+		m.contract = &contract
+	} else {
+		codeHash := common.Hash{}
+		if err := codeHash.UnmarshalText([]byte(m.CodeHash)); err != nil {
+			return errors.Wrapf(err, "invalid codeHash %v", m.CodeHash)
+		}
+		m.codeHash = &codeHash
+	}
+	if len(m.Events) == 0 {
+		return errors.New("no events")
+	}
+	events := make(map[string]struct{}, len(m.Events))
+	for _, event := range m.Events {
+		if err := event.validate(); err != nil {
+			return errors.Wrapf(err, "invalid event %v", event.Name)
+		}
+		// event names are unique ignoring case since api request paths are lowercased
+		if _, ok := events[strings.ToLower(event.Name)]; ok {
+			return errors.Errorf("duplicated event %v", event.Name)
+		}
+		events[strings.ToLower(event.Name)] = struct{}{}
+	}
+	return nil
+}
+
+func (e *Event) validate() error {
+	if len(e.Name) == 0 {
+		return errors.New("empty name")
+	}
+	if !identifierRegexp.MatchString(e.Table) {
+		return errors.Errorf("invalid table %q", e.Table)
+	}
+	columns := make(map[string]struct{}, len(e.Args))
+	for _, arg := range e.Args {
+		if !identifierRegexp.MatchString(arg.Name) {
+			return errors.Errorf("invalid arg name %q", arg.Name)
+		}
+		if _, ok := reservedColumns[arg.Name]; ok {
+			return errors.Errorf("arg name %v is reserved", arg.Name)
+		}
+		if _, ok := columns[arg.Name]; ok {
+			return errors.Errorf("duplicated arg %v", arg.Name)
+		}
+		columns[arg.Name] = struct{}{}
+		if !arg.Type.Valid() {
+			return errors.Errorf("unknown type %q of arg %v", arg.Type, arg.Name)
+		}
+	}
+	return nil
+}
+
+func validateUniqueness(manifests []*Manifest) error {
+	names := make(map[string]struct{}, len(manifests))
+	tables := make(map[string]string)
+	for _, manifest := range manifests {
+		if _, ok := names[manifest.Name]; ok {
+			return errors.Errorf("duplicated manifest %v", manifest.Name)
+		}
+		names[manifest.Name] = struct{}{}
+		for _, event := range manifest.Events {
+			if owner, ok := tables[event.Table]; ok {
+				return errors.Errorf("table %v of manifest %v is already declared by manifest %v", event.Table,
+					manifest.Name, owner)
+			}
+			tables[event.Table] = manifest.Name
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) event(name string) *Event {
+	for _, event := range m.Events {
+		if event.Name == name {
+			return event
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) eventIgnoreCase(name string) *Event {
+	for _, event := range m.Events {
+		if strings.EqualFold(event.Name, name) {
+			return event
+		}
+	}
+	return nil
+}
+
+func (e *Event) arg(name string) *Arg {
+	for _, arg := range e.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
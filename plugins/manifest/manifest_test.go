@@ -0,0 +1,97 @@
+package manifest
+
+import (
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	manifest, err := Parse([]byte(`
+name: lottery
+contract: "0x0000000000000000000000000000000000000001"
+events:
+  - name: Win
+    table: lottery_wins
+    args:
+      - name: winner
+        type: address
+        indexed: true
+      - name: prize
+        type: dna
+`))
+	require.Nil(t, err)
+	require.Equal(t, "lottery", manifest.Name)
+	require.Equal(t, common.Address{19: 0x1}, *manifest.contract)
+	require.Nil(t, manifest.codeHash)
+	require.Len(t, manifest.Events, 1)
+	require.Equal(t, &Arg{Name: "winner", Type: abi.ArgTypeAddress, Indexed: true}, manifest.Events[0].Args[0])
+	require.NotNil(t, manifest.eventIgnoreCase("win"))
+	require.Nil(t, manifest.event("win"))
+
+	manifest, err = Parse([]byte(`{"name": "lottery", "codeHash": "0x0000000000000000000000000000000000000000000000000000000000000002",
+"events": [{"name": "win", "table": "lottery_wins", "args": [{"name": "prize", "type": "bigint"}]}]}`))
+	require.Nil(t, err)
+	require.Equal(t, common.Hash{31: 0x2}, *manifest.codeHash)
+
+	for _, invalid := range []string{
+		`{"name": "lottery", "events": [{"name": "win", "table": "lottery_wins"}]}`,
+		`{"name": "lottery", "contract": "0x1", "events": [{"name": "win", "table": "lottery_wins"}]}`,
+		`{"name": "Lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery_wins"}]}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": []}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery wins"}]}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery_wins"}, {"name": "Win", "table": "lottery_wins2"}]}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery_wins", "args": [{"name": "tx_hash", "type": "string"}]}]}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery_wins", "args": [{"name": "prize", "type": "float"}]}]}`,
+		`{"name": "lottery", "contract": "0x0000000000000000000000000000000000000001", "events": [{"name": "win", "table": "lottery_wins", "args": [{"name": "prize", "typ": "dna"}]}]}`,
+	} {
+		_, err := Parse([]byte(invalid))
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func Test_validateUniqueness(t *testing.T) {
+	manifest := func(name, table string) *Manifest {
+		return &Manifest{Name: name, Events: []*Event{{Name: "event", Table: table}}}
+	}
+	require.Nil(t, validateUniqueness([]*Manifest{manifest("m1", "t1"), manifest("m2", "t2")}))
+	require.NotNil(t, validateUniqueness([]*Manifest{manifest("m1", "t1"), manifest("m1", "t2")}))
+	require.NotNil(t, validateUniqueness([]*Manifest{manifest("m1", "t1"), manifest("m2", "t1")}))
+}
+
+func Test_eventsQuery(t *testing.T) {
+	event := &Event{Name: "win", Table: "lottery_wins", Args: []*Arg{
+		{Name: "winner", Type: abi.ArgTypeAddress},
+		{Name: "prize", Type: abi.ArgTypeDna},
+		{Name: "data", Type: abi.ArgTypeBytes},
+	}}
+	query, params, err := eventsQuery(event, map[string]string{
+		"winner":   "0x0000000000000000000000000000000000000001",
+		"contract": "0x0000000000000000000000000000000000000002",
+		"prize":    "1.5",
+	})
+	require.Nil(t, err)
+	require.Equal(t, `SELECT id, block_height, tx_hash, contract, "winner", "prize"::text, '0x' || encode("data", 'hex') `+
+		`FROM "lottery_wins" WHERE ($2::bigint IS NULL OR id <= $2) AND lower("contract") = lower($3) AND "prize" = $4 `+
+		`AND lower("winner") = lower($5) ORDER BY id DESC LIMIT $1`, query)
+	require.Len(t, params, 3)
+	require.True(t, decimal.RequireFromString("1.5").Equal(params[1].(decimal.Decimal)))
+
+	_, _, err = eventsQuery(event, map[string]string{"loser": "0x1"})
+	require.NotNil(t, err)
+	_, _, err = eventsQuery(event, map[string]string{"winner": "0x1"})
+	require.NotNil(t, err)
+	_, _, err = eventsQuery(event, map[string]string{"data": "0x01"})
+	require.NotNil(t, err)
+}
+
+func Test_insertQuery(t *testing.T) {
+	event := &Event{Name: "order", Table: "order", Args: []*Arg{
+		{Name: "user", Type: abi.ArgTypeAddress},
+		{Name: "select", Type: abi.ArgTypeDna},
+	}}
+	require.Equal(t, `INSERT INTO "order" (block_height, tx_hash, event_index, contract, "user", "select") `+
+		`VALUES ($1, $2, $3, $4, $5, $6)`, insertQuery(event))
+}
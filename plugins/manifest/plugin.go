@@ -0,0 +1,227 @@
+package manifest
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	initRegistryQuery = `CREATE TABLE IF NOT EXISTS manifest_tables
+(
+    table_name character varying(28) NOT NULL,
+    manifest   character varying(28) NOT NULL,
+    event      text                  NOT NULL,
+    CONSTRAINT manifest_tables_pkey PRIMARY KEY (table_name)
+)`
+	registeredTableQuery = `SELECT manifest, event FROM manifest_tables WHERE table_name = $1`
+	tableExistsQuery     = `SELECT to_regclass($1) IS NOT NULL`
+	registerTableQuery   = `INSERT INTO manifest_tables (table_name, manifest, event) VALUES ($1, $2, $3)`
+	columnTypeQuery      = `SELECT format_type(atttypid, atttypmod)
+FROM pg_attribute
+WHERE attrelid = to_regclass($1)
+  AND attname = $2
+  AND NOT attisdropped`
+	initTableQuery = `CREATE TABLE IF NOT EXISTS %[1]v
+(
+    id           bigserial     NOT NULL,
+    block_height bigint        NOT NULL,
+    tx_hash      character(66) NOT NULL,
+    event_index  integer       NOT NULL,
+    contract     character(42) NOT NULL,
+    CONSTRAINT %[2]v PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS %[3]v ON %[1]v (tx_hash, event_index);
+CREATE INDEX IF NOT EXISTS %[4]v ON %[1]v (block_height);
+CREATE INDEX IF NOT EXISTS %[5]v ON %[1]v (lower(contract), id DESC);`
+	addColumnQuery = `ALTER TABLE %v ADD COLUMN IF NOT EXISTS %v %v`
+	addIndexQuery  = `CREATE INDEX IF NOT EXISTS %v ON %v (%v, id DESC)`
+	resetQuery     = `DELETE FROM %v WHERE block_height > $1`
+)
+
+type plugin struct {
+	manifests     []*Manifest
+	insertQueries map[*Event]string
+	withCodeHash  bool
+	logger        log.Logger
+}
+
+// NewPlugin creates the plugin saving contract events declared by the manifests to their tables. Tables are created on
+// start and new args are added as columns, tables created by the indexer or other manifests are never touched. Events
+// are saved from the block the manifest is added at, changing types of args requires dropping the table.
+func NewPlugin(manifests []*Manifest, logger log.Logger) db.Plugin {
+	p := &plugin{
+		manifests:     manifests,
+		insertQueries: make(map[*Event]string),
+		logger:        logger,
+	}
+	for _, manifest := range manifests {
+		p.withCodeHash = p.withCodeHash || manifest.codeHash != nil
+		for _, event := range manifest.Events {
+			p.insertQueries[event] = insertQuery(event)
+		}
+	}
+	return p
+}
+
+func insertQuery(event *Event) string {
+	columns := []string{"block_height", "tx_hash", "event_index", "contract"}
+	for _, arg := range event.Args {
+		columns = append(columns, pq.QuoteIdentifier(arg.Name))
+	}
+	params := make([]string, len(columns))
+	for i := range params {
+		params[i] = "$" + strconv.Itoa(i+1)
+	}
+	return fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", pq.QuoteIdentifier(event.Table), strings.Join(columns, ", "),
+		strings.Join(params, ", "))
+}
+
+func (p *plugin) Name() string {
+	return "manifests"
+}
+
+func (p *plugin) Init(tx *sql.Tx) error {
+	if _, err := tx.Exec(initRegistryQuery); err != nil {
+		return err
+	}
+	for _, manifest := range p.manifests {
+		for _, event := range manifest.Events {
+			if err := initTable(tx, manifest, event); err != nil {
+				return errors.Wrapf(err, "unable to init table %v of manifest %v", event.Table, manifest.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func initTable(tx *sql.Tx, manifest *Manifest, event *Event) error {
+	table := pq.QuoteIdentifier(event.Table)
+	var registeredManifest, registeredEvent string
+	err := tx.QueryRow(registeredTableQuery, event.Table).Scan(&registeredManifest, &registeredEvent)
+	switch err {
+	case nil:
+		if registeredManifest != manifest.Name || registeredEvent != event.Name {
+			return errors.Errorf("table is used by event %v of manifest %v", registeredEvent, registeredManifest)
+		}
+	case sql.ErrNoRows:
+		var exists bool
+		if err := tx.QueryRow(tableExistsQuery, table).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("table is not created by manifests")
+		}
+		if _, err := tx.Exec(registerTableQuery, event.Table, manifest.Name, event.Name); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf(initTableQuery, table, pq.QuoteIdentifier(event.Table+"_pkey"),
+		pq.QuoteIdentifier(event.Table+"_event_idx"), pq.QuoteIdentifier(event.Table+"_block_height_idx"),
+		pq.QuoteIdentifier(event.Table+"_contract_idx"))); err != nil {
+		return err
+	}
+	for _, arg := range event.Args {
+		var existingType string
+		err := tx.QueryRow(columnTypeQuery, table, arg.Name).Scan(&existingType)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && existingType != columnType(arg.Type) {
+			return errors.Errorf("column %v has type %v instead of %v", arg.Name, existingType, columnType(arg.Type))
+		}
+		column := pq.QuoteIdentifier(arg.Name)
+		if _, err := tx.Exec(fmt.Sprintf(addColumnQuery, table, column, columnType(arg.Type))); err != nil {
+			return err
+		}
+		if !arg.Indexed {
+			continue
+		}
+		indexExpr := column
+		if arg.Type == abi.ArgTypeAddress {
+			indexExpr = "lower(" + column + ")"
+		}
+		index := pq.QuoteIdentifier(event.Table + "_" + arg.Name + "_idx")
+		if _, err := tx.Exec(fmt.Sprintf(addIndexQuery, index, table, indexExpr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *plugin) ProcessBlock(tx *sql.Tx, data *db.Data) error {
+	codeHashes := make(map[common.Address]*common.Hash)
+	codeHash := func(contract common.Address) *common.Hash {
+		if !p.withCodeHash {
+			return nil
+		}
+		hash, ok := codeHashes[contract]
+		if !ok {
+			hash = data.Source.NewState.State.GetCodeHash(contract)
+			codeHashes[contract] = hash
+		}
+		return hash
+	}
+	for _, receipt := range data.TxReceipts {
+		if !receipt.Success {
+			continue
+		}
+		for eventIndex, txEvent := range receipt.Events {
+			for _, manifest := range p.manifests {
+				if !manifest.matches(txEvent.Contract, codeHash) {
+					continue
+				}
+				event := manifest.event(txEvent.EventName)
+				if event == nil {
+					continue
+				}
+				args := []interface{}{data.Block.Height, receipt.TxHash.Hex(), eventIndex, txEvent.Contract.Hex()}
+				for i, arg := range event.Args {
+					var argData []byte
+					if i < len(txEvent.Data) {
+						argData = txEvent.Data[i]
+					}
+					value, err := arg.Type.Decode(argData)
+					if err != nil {
+						p.logger.Warn(fmt.Sprintf("Unable to decode arg %v of event %v, tx %v: %v", arg.Name,
+							event.Name, receipt.TxHash.Hex(), err))
+					}
+					args = append(args, value)
+				}
+				if _, err := tx.Exec(p.insertQueries[event], args...); err != nil {
+					return errors.Wrapf(err, "unable to save event %v of manifest %v, tx %v", event.Name,
+						manifest.Name, receipt.TxHash.Hex())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) matches(contract common.Address, codeHash func(contract common.Address) *common.Hash) bool {
+	if m.contract != nil {
+		return *m.contract == contract
+	}
+	hash := codeHash(contract)
+	return hash != nil && *hash == *m.codeHash
+}
+
+func (p *plugin) ResetTo(tx *sql.Tx, height uint64) error {
+	for _, manifest := range p.manifests {
+		for _, event := range manifest.Events {
+			if _, err := tx.Exec(fmt.Sprintf(resetQuery, pq.QuoteIdentifier(event.Table)), height); err != nil {
+				return errors.Wrapf(err, "unable to reset table %v of manifest %v", event.Table, manifest.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -3160,7 +3160,10 @@ BEGIN
                         (l_item ->> 'usedGas')::integer)
                 RETURNING id into l_tx_id;
 
-                INSERT INTO transaction_raws (tx_id, raw) VALUES (l_tx_id, decode((l_item ->> 'raw')::text, 'hex'));
+                -- raws are loaded separately when several blocks are saved at once
+                IF l_item ? 'raw' THEN
+                    INSERT INTO transaction_raws (tx_id, raw) VALUES (l_tx_id, decode((l_item ->> 'raw')::text, 'hex'));
+                END IF;
 
                 l_res = array_append(l_res, ((l_item ->> 'hash')::text, l_tx_id)::tp_tx_hash_id);
 
@@ -3462,6 +3465,10 @@ BEGIN
     call reset_changes_to(p_block_height);
     call reset_contracts_to(p_block_height);
     call reset_upgrade_voting_history_to(p_block_height);
+    call reset_webhooks_to(p_block_height);
+    call reset_reorg_history_to(p_block_height);
+    call reset_finality_to(p_block_height);
+    call reset_balance_checkpoints_to(p_block_height);
 
     select epoch, "timestamp" into l_epoch, l_timestamp from blocks where height = greatest(2, p_block_height);
 
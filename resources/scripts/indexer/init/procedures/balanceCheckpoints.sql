@@ -0,0 +1,34 @@
+-- save_balance_checkpoint saves balances of addresses which have been updated since the previous checkpoint, a balance
+-- at any height can be then found by the latest checkpoint and balance updates after it
+CREATE OR REPLACE PROCEDURE save_balance_checkpoint(p_block_height bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+DECLARE
+    l_prev_height bigint;
+BEGIN
+    SELECT coalesce(max(block_height), 0) INTO l_prev_height FROM balance_checkpoints;
+
+    INSERT INTO balance_checkpoints (address_id, block_height, balance, stake)
+    SELECT a.address_id, p_block_height, coalesce(b.balance, 0), coalesce(b.stake, 0)
+    FROM (SELECT address_id
+          FROM balance_updates
+          WHERE block_height > l_prev_height
+          UNION
+          -- committee reward balance updates are extended by later blocks
+          SELECT address_id
+          FROM latest_committee_reward_balance_updates
+          WHERE block_height > l_prev_height) a
+             LEFT JOIN balances b ON b.address_id = a.address_id
+    ON CONFLICT DO NOTHING;
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE reset_balance_checkpoints_to(p_block_height bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+BEGIN
+    DELETE FROM balance_checkpoints WHERE block_height > p_block_height;
+END
+$$;
@@ -47,16 +47,22 @@ CREATE OR REPLACE PROCEDURE update_contract_verification_state(p_contract_addres
                                                                p_state smallint,
                                                                p_timestamp bigint,
                                                                p_data bytea,
-                                                               p_error_message text)
+                                                               p_error_message text,
+                                                               p_compiler_version text,
+                                                               p_build_flags text,
+                                                               p_build_log text)
     LANGUAGE 'plpgsql'
 AS
 $$
 BEGIN
     UPDATE contract_verifications
-    SET state           = p_state,
-        state_timestamp = p_timestamp,
-        "data"          = p_data,
-        error_message   = p_error_message
+    SET state            = p_state,
+        state_timestamp  = p_timestamp,
+        "data"           = p_data,
+        error_message    = limited_text(p_error_message, 200),
+        compiler_version = limited_text(p_compiler_version, 100),
+        build_flags      = limited_text(p_build_flags, 500),
+        build_log        = p_build_log
     WHERE contract_address_id = (SELECT id FROM addresses WHERE lower(address) = lower(p_contract_address));
 END
-$$;
\ No newline at end of file
+$$;
@@ -0,0 +1,31 @@
+-- finalized_height returns the max height of blocks which are not expected to be reset, rows of blocks up to this
+-- height are final
+CREATE OR REPLACE FUNCTION finalized_height()
+    RETURNS bigint
+    LANGUAGE 'plpgsql'
+    STABLE
+AS
+$$
+BEGIN
+    RETURN (SELECT finalized_height FROM finality);
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE save_finalized_height(p_block_height bigint,
+                                                  p_confirmations bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+BEGIN
+    UPDATE finality SET finalized_height = greatest(p_block_height - p_confirmations, 0);
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE reset_finality_to(p_block_height bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+BEGIN
+    UPDATE finality SET finalized_height = least(finalized_height, p_block_height);
+END
+$$;
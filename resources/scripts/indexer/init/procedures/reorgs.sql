@@ -0,0 +1,69 @@
+CREATE OR REPLACE FUNCTION save_reorg(p_reset_height bigint,
+                                      p_new_tip_hash text,
+                                      p_timestamp bigint)
+    RETURNS bigint
+    LANGUAGE 'plpgsql'
+AS
+$$
+DECLARE
+    l_old_tip_height          bigint;
+    l_old_tip_hash            text;
+    l_removed_txs             bigint;
+    l_removed_balance_updates bigint;
+    l_removed_rewards         bigint;
+    l_id                      bigint;
+BEGIN
+    select height, hash into l_old_tip_height, l_old_tip_hash from blocks order by height desc limit 1;
+    l_old_tip_height = coalesce(l_old_tip_height, p_reset_height);
+
+    select count(*) into l_removed_txs from transactions where block_height > p_reset_height;
+    select count(*) into l_removed_balance_updates from balance_updates where block_height > p_reset_height;
+    select (select count(*) from mining_rewards where block_height > p_reset_height) +
+           (select count(*)
+            from validation_rewards vr
+                     join address_states s on s.id = vr.ei_address_state_id
+            where s.block_height > p_reset_height)
+    into l_removed_rewards;
+
+    insert into reorg_history ("timestamp", reset_height, old_tip_height, old_tip_hash, new_tip_hash, depth,
+                               removed_txs, removed_balance_updates, removed_rewards)
+    values (p_timestamp, p_reset_height, l_old_tip_height, l_old_tip_hash, p_new_tip_hash,
+            greatest(l_old_tip_height - p_reset_height, 0), l_removed_txs, l_removed_balance_updates,
+            l_removed_rewards)
+    returning id into l_id;
+
+    insert into reorg_history_txs (reorg_id, hash)
+    select l_id, hash
+    from transactions
+    where block_height > p_reset_height;
+
+    return l_id;
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE save_reincluded_txs(p_block_height bigint,
+                                                p_hashes text[])
+    LANGUAGE 'plpgsql'
+AS
+$$
+BEGIN
+    if p_hashes is null then
+        return;
+    end if;
+    update reorg_history_txs
+    set reincluded_block_height = p_block_height
+    where reincluded_block_height is null
+      and lower(hash) = any (select lower(unnest(p_hashes)));
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE reset_reorg_history_to(p_block_height bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+BEGIN
+    update reorg_history_txs
+    set reincluded_block_height = null
+    where reincluded_block_height > p_block_height;
+END
+$$;
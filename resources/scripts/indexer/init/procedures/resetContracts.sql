@@ -54,6 +54,16 @@ BEGIN
     WHERE c.tx_id >= l_tx_id
       AND c.contract_address_id = t.contract_address_id;
 
+    DELETE
+    FROM contract_abis t USING contracts c
+    WHERE c.tx_id >= l_tx_id
+      AND c.contract_address_id = t.contract_address_id;
+
+    DELETE
+    FROM contract_codes t USING contracts c
+    WHERE c.tx_id >= l_tx_id
+      AND c.contract_address_id = t.contract_address_id;
+
     DELETE FROM contracts WHERE tx_id >= l_tx_id;
     DELETE FROM tx_receipts WHERE tx_id >= l_tx_id;
     DELETE FROM tx_events WHERE tx_id >= l_tx_id;
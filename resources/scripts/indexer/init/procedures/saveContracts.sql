@@ -89,6 +89,12 @@ BEGIN
 
             INSERT INTO contracts (tx_id, contract_address_id, "type", stake, code)
             VALUES (l_tx_id, l_contract_address_id, CONTRACT_TYPE_CONTRACT, 0, l_contract_code);
+
+            if l_item ->> 'codeHash' is not null then
+                INSERT INTO contract_codes (contract_address_id, code_hash, size, module, parse_error)
+                VALUES (l_contract_address_id, (l_item ->> 'codeHash')::text, coalesce((l_item ->> 'codeSize')::integer, 0),
+                        l_item -> 'module', limited_text((l_item ->> 'parseError')::text, 200));
+            end if;
         end loop;
 END
 $$;
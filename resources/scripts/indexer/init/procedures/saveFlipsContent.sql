@@ -15,7 +15,11 @@ BEGIN
             loop
                 l_fail := p_fails[i];
                 if l_fail.attempts_limit_reached then
-                    delete from flips_queue where lower(cid) = lower(l_fail.cid);
+                    -- failed flips are kept with the max timestamp to be requeued by admins
+                    update flips_queue
+                    set attempts              = attempts + 1,
+                        next_attempt_timestamp=9223372036854775807
+                    where lower(cid) = lower(l_fail.cid);
                 else
                     update flips_queue
                     set attempts              = attempts + 1,
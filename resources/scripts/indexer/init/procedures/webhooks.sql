@@ -0,0 +1,62 @@
+CREATE OR REPLACE PROCEDURE save_webhook_events(p_block_height bigint,
+                                                p_items jsonb[])
+    LANGUAGE 'plpgsql'
+AS
+$$
+DECLARE
+    l_item jsonb;
+BEGIN
+    for i in 1..cardinality(p_items)
+        loop
+            l_item = p_items[i];
+            INSERT INTO webhook_events (block_height, "type", addresses, tx_type, contract, payload)
+            VALUES (p_block_height,
+                    (l_item ->> 'type')::text,
+                    (SELECT array_agg(lower(value)) FROM jsonb_array_elements_text(l_item -> 'addresses')),
+                    (l_item ->> 'txType')::text,
+                    lower((l_item ->> 'contract')::text),
+                    l_item -> 'payload');
+        end loop;
+END
+$$;
+
+CREATE OR REPLACE PROCEDURE reset_webhooks_to(p_block_height bigint)
+    LANGUAGE 'plpgsql'
+AS
+$$
+DECLARE
+    DELIVERY_STATE_DELIVERED CONSTANT smallint = 1;
+    EVENT_TYPE_REVERTED      CONSTANT text     = 'reverted';
+    l_record                          record;
+    l_event_id                        bigint;
+BEGIN
+    -- webhooks that received or might have received events of the reverted blocks get a compensating event
+    for l_record in SELECT d.webhook_id, array_agg(e.id ORDER BY e.id) event_ids
+                    FROM webhook_deliveries d
+                             JOIN webhook_events e ON e.id = d.event_id
+                    WHERE e.block_height > p_block_height
+                      AND e.type <> EVENT_TYPE_REVERTED
+                      AND (d.state = DELIVERY_STATE_DELIVERED OR d.attempts > 0)
+                    GROUP BY d.webhook_id
+        loop
+            INSERT INTO webhook_events (block_height, "type", payload)
+            VALUES (p_block_height, EVENT_TYPE_REVERTED,
+                    jsonb_build_object('height', p_block_height, 'eventIds', to_jsonb(l_record.event_ids)))
+            RETURNING id INTO l_event_id;
+            INSERT INTO webhook_deliveries (webhook_id, event_id, state, next_attempt_at)
+            VALUES (l_record.webhook_id, l_event_id, 0, 0);
+        end loop;
+
+    -- foreign keys are not checked during reset so deliveries are deleted explicitly
+    DELETE
+    FROM webhook_deliveries
+    WHERE event_id IN (SELECT id
+                       FROM webhook_events
+                       WHERE block_height > p_block_height
+                         AND "type" <> EVENT_TYPE_REVERTED);
+    DELETE
+    FROM webhook_events
+    WHERE block_height > p_block_height
+      AND "type" <> EVENT_TYPE_REVERTED;
+END
+$$;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS admin_actions
+(
+    id           bigserial             NOT NULL,
+    "timestamp"  bigint                NOT NULL,
+    action       character varying(50) NOT NULL,
+    params       jsonb,
+    remote_addr  character varying(100),
+    completed_at bigint,
+    error        character varying(200),
+    CONSTRAINT admin_actions_pkey PRIMARY KEY (id)
+);
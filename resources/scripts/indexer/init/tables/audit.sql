@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS audit_runs
+(
+    id            bigserial NOT NULL,
+    "timestamp"   bigint    NOT NULL,
+    block_height  bigint    NOT NULL,
+    discrepancies bigint    NOT NULL,
+    incomplete    boolean   NOT NULL,
+    repair        boolean   NOT NULL,
+    CONSTRAINT audit_runs_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_discrepancies
+(
+    run_id      bigint                NOT NULL,
+    "check"     character varying(30) NOT NULL,
+    severity    character varying(10) NOT NULL,
+    address     character(42),
+    contract    character(42),
+    state_value text                  NOT NULL,
+    db_value    text                  NOT NULL,
+    CONSTRAINT audit_discrepancies_run_id_fkey FOREIGN KEY (run_id)
+        REFERENCES audit_runs (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS audit_discrepancies_run_id_idx ON audit_discrepancies (run_id);
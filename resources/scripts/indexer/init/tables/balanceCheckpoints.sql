@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS balance_checkpoints
+(
+    address_id   bigint          NOT NULL,
+    block_height bigint          NOT NULL,
+    balance      numeric(30, 18) NOT NULL,
+    stake        numeric(30, 18) NOT NULL,
+    CONSTRAINT balance_checkpoints_pkey PRIMARY KEY (address_id, block_height)
+);
+CREATE INDEX IF NOT EXISTS balance_checkpoints_block_height_idx on balance_checkpoints (block_height);
+
+CREATE INDEX IF NOT EXISTS balance_updates_address_id_block_height_idx on balance_updates (address_id, block_height);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS contract_abis
+(
+    contract_address_id bigint NOT NULL,
+    "timestamp"         bigint NOT NULL,
+    abi                 jsonb  NOT NULL,
+    CONSTRAINT contract_abis_pkey PRIMARY KEY (contract_address_id)
+);
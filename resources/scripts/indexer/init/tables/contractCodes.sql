@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS contract_codes
+(
+    contract_address_id bigint        NOT NULL,
+    code_hash           character(66) NOT NULL,
+    size                integer       NOT NULL,
+    module              jsonb,
+    parse_error         character varying(200),
+    CONSTRAINT contract_codes_pkey PRIMARY KEY (contract_address_id)
+);
+CREATE INDEX IF NOT EXISTS contract_codes_code_hash_idx ON contract_codes (code_hash);
@@ -23,7 +23,10 @@ CREATE TABLE IF NOT EXISTS contract_verifications
     state_timestamp     bigint   NOT NULL,
     "data"              bytea,
     file_name           character varying(50),
-    error_message       character varying(200)
+    error_message       character varying(200),
+    compiler_version    character varying(100),
+    build_flags         character varying(500),
+    build_log           text
 );
 CREATE UNIQUE INDEX IF NOT EXISTS contract_verifications_pkey ON contract_verifications (contract_address_id);
 CREATE INDEX IF NOT EXISTS contract_verifications_pending ON contract_verifications (contract_address_id) WHERE state = 0;
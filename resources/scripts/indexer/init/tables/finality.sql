@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS finality
+(
+    finalized_height bigint NOT NULL
+);
+INSERT INTO finality (finalized_height)
+SELECT 0
+WHERE NOT exists(SELECT 1 FROM finality);
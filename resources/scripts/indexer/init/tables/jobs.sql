@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS job_types
+(
+    "type" character varying(30) NOT NULL,
+    paused boolean               NOT NULL DEFAULT false,
+    CONSTRAINT job_types_pkey PRIMARY KEY ("type")
+);
+
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id              bigserial             NOT NULL,
+    "type"          character varying(30) NOT NULL,
+    payload         jsonb                 NOT NULL,
+    attempts        integer               NOT NULL DEFAULT 0,
+    next_attempt_at bigint                NOT NULL,
+    last_error      character varying(200),
+    created_at      bigint                NOT NULL,
+    CONSTRAINT jobs_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS jobs_type_next_attempt_at_idx ON jobs ("type", next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS job_attempts
+(
+    job_id      bigint  NOT NULL,
+    attempt     integer NOT NULL,
+    started_at  bigint  NOT NULL,
+    duration_ms bigint  NOT NULL,
+    error       character varying(200),
+    CONSTRAINT job_attempts_pkey PRIMARY KEY (job_id, attempt)
+);
+
+CREATE TABLE IF NOT EXISTS job_dead_letters
+(
+    id         bigserial             NOT NULL,
+    job_id     bigint                NOT NULL,
+    "type"     character varying(30) NOT NULL,
+    payload    jsonb                 NOT NULL,
+    attempts   integer               NOT NULL,
+    last_error character varying(200),
+    created_at bigint                NOT NULL,
+    CONSTRAINT job_dead_letters_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS job_dead_letters_type_idx ON job_dead_letters ("type", id desc);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS reorg_history
+(
+    id                      bigserial             NOT NULL,
+    "timestamp"             bigint                NOT NULL,
+    reset_height            bigint                NOT NULL,
+    old_tip_height          bigint                NOT NULL,
+    old_tip_hash            character varying(66),
+    new_tip_hash            character varying(66) NOT NULL,
+    depth                   bigint                NOT NULL,
+    removed_txs             bigint                NOT NULL,
+    removed_balance_updates bigint                NOT NULL,
+    removed_rewards         bigint                NOT NULL,
+    CONSTRAINT reorg_history_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS reorg_history_txs
+(
+    reorg_id                bigint                NOT NULL,
+    hash                    character varying(66) NOT NULL,
+    reincluded_block_height bigint,
+    CONSTRAINT reorg_history_txs_pkey PRIMARY KEY (reorg_id, hash),
+    CONSTRAINT reorg_history_txs_reorg_id_fkey FOREIGN KEY (reorg_id)
+        REFERENCES reorg_history (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS reorg_history_txs_hash_idx ON reorg_history_txs (lower(hash)) WHERE reincluded_block_height IS NULL;
+CREATE INDEX IF NOT EXISTS reorg_history_txs_reincluded_block_height_idx ON reorg_history_txs (reincluded_block_height) WHERE reincluded_block_height IS NOT NULL;
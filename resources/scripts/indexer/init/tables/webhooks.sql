@@ -0,0 +1,76 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          bigserial              NOT NULL,
+    url         character varying(500) NOT NULL,
+    secret      character varying(64)  NOT NULL,
+    event_types character varying(30)[],
+    addresses   character varying(42)[],
+    tx_types    character varying(30)[],
+    contracts   character varying(42)[],
+    finalized   boolean                NOT NULL DEFAULT false,
+    created_at  bigint                 NOT NULL,
+    CONSTRAINT webhooks_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events
+(
+    id           bigserial             NOT NULL,
+    block_height bigint                NOT NULL,
+    "type"       character varying(30) NOT NULL,
+    addresses    character varying(42)[],
+    tx_type      character varying(30),
+    contract     character varying(42),
+    payload      jsonb                 NOT NULL,
+    CONSTRAINT webhook_events_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS webhook_events_block_height_idx ON webhook_events (block_height);
+
+CREATE TABLE IF NOT EXISTS webhook_fan_out_state
+(
+    last_event_id bigint NOT NULL
+);
+INSERT INTO webhook_fan_out_state (last_event_id)
+SELECT 0
+WHERE NOT exists(SELECT 1 FROM webhook_fan_out_state);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              bigserial NOT NULL,
+    webhook_id      bigint    NOT NULL,
+    event_id        bigint    NOT NULL,
+    state           smallint  NOT NULL,
+    attempts        integer   NOT NULL DEFAULT 0,
+    next_attempt_at bigint    NOT NULL,
+    delivered_at    bigint,
+    last_error      character varying(200),
+    CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT webhook_deliveries_webhook_id_fkey FOREIGN KEY (webhook_id)
+        REFERENCES webhooks (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT webhook_deliveries_event_id_fkey FOREIGN KEY (event_id)
+        REFERENCES webhook_events (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE state = 0;
+CREATE INDEX IF NOT EXISTS webhook_deliveries_event_id_idx ON webhook_deliveries (event_id);
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters
+(
+    id           bigserial             NOT NULL,
+    webhook_id   bigint                NOT NULL,
+    event_id     bigint                NOT NULL,
+    block_height bigint                NOT NULL,
+    "type"       character varying(30) NOT NULL,
+    payload      jsonb                 NOT NULL,
+    attempts     integer               NOT NULL,
+    last_error   character varying(200),
+    created_at   bigint                NOT NULL,
+    CONSTRAINT webhook_dead_letters_pkey PRIMARY KEY (id),
+    CONSTRAINT webhook_dead_letters_webhook_id_fkey FOREIGN KEY (webhook_id)
+        REFERENCES webhooks (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS webhook_dead_letters_webhook_id_idx ON webhook_dead_letters (webhook_id, id desc);
@@ -0,0 +1 @@
+call save_balance_checkpoint($1)
@@ -0,0 +1 @@
+call save_finalized_height($1, $2)
@@ -0,0 +1 @@
+call save_reincluded_txs($1, $2)
@@ -0,0 +1 @@
+select save_reorg($1, $2, $3)
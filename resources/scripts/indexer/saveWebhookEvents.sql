@@ -0,0 +1 @@
+call save_webhook_events($1, $2);
\ No newline at end of file
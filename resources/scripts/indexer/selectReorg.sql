@@ -0,0 +1,12 @@
+select h.id,
+       h."timestamp",
+       h.reset_height,
+       h.old_tip_height,
+       coalesce(h.old_tip_hash, ''),
+       h.new_tip_hash,
+       h.depth,
+       h.removed_txs,
+       h.removed_balance_updates,
+       h.removed_rewards
+from reorg_history h
+where h.id = $1
@@ -0,0 +1,7 @@
+ALTER TABLE contract_verifications
+    ADD COLUMN compiler_version character varying(100);
+ALTER TABLE contract_verifications
+    ADD COLUMN build_flags character varying(500);
+ALTER TABLE contract_verifications
+    ADD COLUMN build_log text;
+DROP PROCEDURE IF EXISTS update_contract_verification_state(text, smallint, bigint, bytea, text);
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-go/common/eventbus"
+	"github.com/idena-network/idena-go/core/appstate"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/core/audit"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	db2 "github.com/tendermint/tm-db"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func Test_audit(t *testing.T) {
+	_, dbAccessor := testCommon.InitDefaultPostgres("..")
+	defer dbAccessor.Destroy()
+	auditDb := audit.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+
+	addr1, addr2 := tests.GetRandAddr(), tests.GetRandAddr()
+	require.Nil(t, dbAccessor.Save(&db.Data{
+		Epoch: 1,
+		Block: db.Block{
+			Height: 1,
+			Hash:   "1",
+			Time:   20,
+		},
+		Addresses: []db.Address{
+			{Address: addr1.Hex(), StateChanges: []db.AddressStateChange{{NewState: uint8(state.Verified)}}},
+			{Address: addr2.Hex()},
+		},
+		ChangedBalances: []db.Balance{
+			{Address: addr1.Hex(), Balance: decimal.New(5, 0)},
+			{Address: addr2.Hex(), Balance: decimal.New(2, 0)},
+		},
+	}))
+
+	appState, _ := appstate.NewAppState(db2.NewMemDB(), eventbus.New())
+	appState.State.SetBalance(addr1, new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18)))
+	appState.State.SetState(addr1, state.Verified)
+	appState.State.SetBalance(addr2, new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)))
+	appState.State.SetState(addr2, state.Newbie)
+	require.Nil(t, appState.CommitAt(1))
+
+	logger := log.New("component", "audit")
+
+	// audit is not due
+	auditor := audit.NewAuditor(auditDb, dbAccessor, nil, audit.Config{IntervalBlocks: 10, Repair: true}, logger)
+	require.False(t, auditor.Audit(1, appState))
+	runs, _, err := auditDb.Runs(10, nil)
+	require.Nil(t, err)
+	require.Empty(t, runs)
+
+	// discrepancies are saved but not repaired
+	auditor = audit.NewAuditor(auditDb, dbAccessor, nil, audit.Config{IntervalBlocks: 1}, logger)
+	require.False(t, auditor.Audit(1, appState))
+
+	// repair is requested for inconsistent balances
+	auditor = audit.NewAuditor(auditDb, dbAccessor, nil, audit.Config{IntervalBlocks: 1, Repair: true}, logger)
+	require.True(t, auditor.Audit(1, appState))
+
+	runs, continuationToken, err := auditDb.Runs(10, nil)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Len(t, runs, 2)
+	require.Equal(t, uint64(1), runs[0].BlockHeight)
+	require.Equal(t, uint64(2), runs[0].Discrepancies)
+	require.False(t, runs[0].Incomplete)
+	require.True(t, runs[0].Repair)
+	require.False(t, runs[1].Repair)
+
+	discrepancies, err := auditDb.Discrepancies(runs[0].Id)
+	require.Nil(t, err)
+	require.Len(t, discrepancies, 2)
+
+	require.Equal(t, audit.CheckBalance, discrepancies[0].Check)
+	require.Equal(t, audit.SeverityCritical, discrepancies[0].Severity)
+	require.Equal(t, strings.ToLower(addr2.Hex()), discrepancies[0].Address)
+	require.Equal(t, "3", discrepancies[0].StateValue)
+	require.Equal(t, "2", discrepancies[0].DbValue)
+
+	require.Equal(t, audit.CheckIdentityState, discrepancies[1].Check)
+	require.Equal(t, audit.SeverityWarning, discrepancies[1].Severity)
+	require.Equal(t, strings.ToLower(addr2.Hex()), discrepancies[1].Address)
+	require.Equal(t, "Newbie", discrepancies[1].StateValue)
+	require.Equal(t, "Undefined", discrepancies[1].DbValue)
+}
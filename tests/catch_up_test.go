@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"database/sql"
+	"fmt"
+	types2 "github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/core/state"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-indexer/indexer"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	catchUpFixtureIdentities  = 50
+	catchUpFixtureTxsPerBlock = 10
+	catchUpFixtureFirstTime   = 1600000000
+)
+
+type catchUpFixture struct {
+	keys []*ecdsa.PrivateKey
+}
+
+func newCatchUpFixture() *catchUpFixture {
+	res := &catchUpFixture{}
+	for i := 0; i < catchUpFixtureIdentities; i++ {
+		key, _ := crypto.GenerateKey()
+		res.keys = append(res.keys, key)
+	}
+	return res
+}
+
+// index applies blocks with committee rewards and transactions and waits for all of them to be saved
+func (f *catchUpFixture) index(t testing.TB, catchUp indexer.CatchUpConfig, blocks int, beforeBlocks func()) *sql.DB {
+	ctx := testCommon.InitIndexer2(testCommon.Options{
+		ClearDb:           true,
+		Schema:            testCommon.PostgresSchema,
+		ScriptsPathPrefix: "..",
+		CatchUp:           catchUp,
+	})
+	t.Cleanup(func() {
+		ctx.Listener.Destroy()
+	})
+	appState := ctx.Listener.NodeCtx().AppState
+	statsCollector := ctx.Listener.StatsCollector()
+	addrs := make([]common.Address, 0, len(f.keys))
+	for _, key := range f.keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		addrs = append(addrs, addr)
+		appState.State.SetState(addr, state.Verified)
+		appState.State.SetBalance(addr, dna(1000))
+	}
+	appState.Precommit()
+	require.Nil(t, appState.CommitAt(1))
+	require.Nil(t, appState.Initialize(1))
+
+	if beforeBlocks != nil {
+		beforeBlocks()
+	}
+	lastHeight := uint64(blocks + 1)
+	for height := uint64(2); height <= lastHeight; height++ {
+		statsCollector.EnableCollecting()
+		for i := 0; i < catchUpFixtureTxsPerBlock; i++ {
+			addCommitteeReward(statsCollector, addrs[(int(height)+i)%len(addrs)], dna(1), dna(1), appState)
+		}
+		block := buildBlock(height)
+		block.Header.ProposedHeader.Time = catchUpFixtureFirstTime + int64(height)*20
+		for i := 0; i < catchUpFixtureTxsPerBlock; i++ {
+			recipient := addrs[(int(height)+i+1)%len(addrs)]
+			tx, _ := types2.SignTx(&types2.Transaction{
+				Type:         types2.SendTx,
+				To:           &recipient,
+				Amount:       big.NewInt(int64(height)),
+				AccountNonce: uint32(height),
+			}, f.keys[(int(height)+i)%len(f.keys)])
+			block.Body.Transactions = append(block.Body.Transactions, tx)
+		}
+		require.Nil(t, applyBlock(ctx.EventBus, block, appState))
+		statsCollector.CompleteCollecting()
+	}
+	require.Eventually(t, func() bool {
+		return ctx.Indexer.LastIndexedHeight() == lastHeight
+	}, time.Minute, time.Millisecond*10)
+	return ctx.DbConnector
+}
+
+func getCatchUpFixtureResult(t testing.TB, db *sql.DB) []string {
+	rows, err := db.Query(`select c.block_height, c.minted, c.total_balance, c.total_stake,
+       (select count(*) from transactions t where t.block_height = c.block_height),
+       (select count(*)
+        from transactions t
+                 join transaction_raws r on r.tx_id = t.id
+        where t.block_height = c.block_height),
+       coalesce((select s.vrf_score::text from block_proposer_vrf_scores s where s.block_height = c.block_height), '')
+from coins c
+order by c.block_height`)
+	require.Nil(t, err)
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var height, txs, txRaws uint64
+		var minted, totalBalance, totalStake sql.NullString
+		var vrfScore string
+		require.Nil(t, rows.Scan(&height, &minted, &totalBalance, &totalStake, &txs, &txRaws, &vrfScore))
+		res = append(res, fmt.Sprintf("%v %v %v %v %v %v %v", height, minted.String, totalBalance.String, totalStake.String, txs, txRaws, vrfScore))
+	}
+	rows, err = db.Query(`select a.address, b.balance, b.stake from balances b join addresses a on a.id = b.address_id order by a.address`)
+	require.Nil(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var address string
+		var balance, stake sql.NullString
+		require.Nil(t, rows.Scan(&address, &balance, &stake))
+		res = append(res, fmt.Sprintf("%v %v %v", address, balance.String, stake.String))
+	}
+	return res
+}
+
+func Test_catchUpIndexing(t *testing.T) {
+	fixture := newCatchUpFixture()
+	const blocks = 100
+
+	db := fixture.index(t, indexer.CatchUpConfig{}, blocks, nil)
+	expected := getCatchUpFixtureResult(t, db)
+	require.Greater(t, len(expected), blocks)
+
+	db = fixture.index(t, indexer.CatchUpConfig{
+		Workers:           4,
+		MaxBlocksInFlight: 16,
+		MinBlockAge:       time.Hour,
+	}, blocks, nil)
+	require.Equal(t, expected, getCatchUpFixtureResult(t, db))
+
+	db = fixture.index(t, indexer.CatchUpConfig{
+		Workers:           4,
+		MaxBlocksInFlight: 16,
+		MinBlockAge:       time.Hour,
+		SaveBatchSize:     8,
+	}, blocks, nil)
+	require.Equal(t, expected, getCatchUpFixtureResult(t, db))
+}
+
+func benchmarkIndexing(b *testing.B, catchUp indexer.CatchUpConfig) {
+	fixture := newCatchUpFixture()
+	b.ReportAllocs()
+	b.StopTimer()
+	// the fixture indexes b.N blocks, setting up the indexer is excluded from the measured time
+	fixture.index(b, catchUp, b.N, b.StartTimer)
+	b.StopTimer()
+}
+
+// Run with -benchtime Nx to compare throughput over N blocks with BenchmarkCatchUpIndexing
+func BenchmarkIndexing(b *testing.B) {
+	benchmarkIndexing(b, indexer.CatchUpConfig{})
+}
+
+func BenchmarkCatchUpIndexing(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		for _, saveBatchSize := range []int{1, 16} {
+			b.Run(fmt.Sprintf("workers=%v,saveBatchSize=%v", workers, saveBatchSize), func(b *testing.B) {
+				benchmarkIndexing(b, indexer.CatchUpConfig{
+					Workers:           workers,
+					MaxBlocksInFlight: 32,
+					MinBlockAge:       time.Hour,
+					SaveBatchSize:     saveBatchSize,
+				})
+			})
+		}
+	}
+}
@@ -28,7 +28,10 @@ const (
 	PostgresSchema  = "auto_test_schema"
 )
 
-var defaultScriptsPath = filepath.Join("resources", "scripts", "indexer")
+var (
+	defaultScriptsPath    = filepath.Join("resources", "scripts", "indexer")
+	defaultMigrationsPath = filepath.Join("resources", "scripts", "migration")
+)
 
 func initLog() {
 	handler := log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stdout, log.TerminalFormat(false)))
@@ -86,10 +89,12 @@ func InitIndexer(
 		5,
 		indexerEventBus,
 		"",
+		0,
 		dbAccessor,
 		indexer.NewOracleVotingToProlongDetector(),
+		nil,
 		false,
-		false,
+		indexer.CatchUpConfig{},
 	)
 	testIndexer.Start()
 	return dbConnector, testIndexer, listener, dbAccessor, nodeEventBus
@@ -108,6 +113,8 @@ type Options struct {
 	NodeConfig                        *config2.Config
 	OracleVotingToProlongDetector     indexer.OracleVotingToProlongDetector
 	TokenContractHolder               stats.TokenContractHolder
+	CatchUp                           indexer.CatchUpConfig
+	Plugins                           []db.Plugin
 }
 
 type IndexerCtx struct {
@@ -140,7 +147,7 @@ func InitIndexer2(opt Options) *IndexerCtx {
 
 	initLog()
 	pm := monitoring.NewEmptyPerformanceMonitor()
-	dbConnector, dbAccessor := InitPostgres(opt.ClearDb, opt.ChangesHistoryBlocksCount, opt.Schema, opt.ScriptsPathPrefix, pm)
+	dbConnector, dbAccessor := InitPostgres(opt.ClearDb, opt.ChangesHistoryBlocksCount, opt.Schema, opt.ScriptsPathPrefix, pm, opt.Plugins...)
 	memPoolIndexer := mempool.NewIndexer(dbAccessor, log.New("component", "mpi"))
 	memDb := db2.NewMemDB()
 	appState := opt.AppState
@@ -188,10 +195,12 @@ func InitIndexer2(opt Options) *IndexerCtx {
 		*opt.UpgradeVotingShortHistoryMinShift,
 		indexerEventBus,
 		"",
+		0,
 		dbAccessor,
 		opt.OracleVotingToProlongDetector,
+		nil,
 		false,
-		false,
+		opt.CatchUp,
 	)
 	testIndexer.Start()
 	return &IndexerCtx{
@@ -204,7 +213,8 @@ func InitPostgres(
 	changesHistoryBlocksCount int,
 	schema string,
 	scriptsPathPrefix string,
-	pm monitoring.PerformanceMonitor) (*sql.DB, db.Accessor) {
+	pm monitoring.PerformanceMonitor,
+	plugins ...db.Plugin) (*sql.DB, db.Accessor) {
 	dbConnector, err := sql.Open("postgres", PostgresConnStr)
 	if err != nil {
 		panic(err)
@@ -226,12 +236,18 @@ func InitPostgres(
 	dbAccessor := db.NewPostgresAccessor(
 		PostgresConnStr+"&search_path="+schema,
 		filepath.Join(scriptsPathPrefix, defaultScriptsPath),
+		filepath.Join(scriptsPathPrefix, defaultMigrationsPath),
+		"",
 		&TestWordsLoader{},
 		pm,
 		changesHistoryBlocksCount,
 		false,
+		false,
+		0,
+		0,
 		"",
 		"",
+		plugins,
 	)
 	return dbConnector, dbAccessor
 }
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/monitoring"
+	"github.com/idena-network/idena-indexer/tests/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+const changesHistoryBlocksCount = 100
+
+// backends lists db.Accessor implementations which must behave the same way on the same blocks,
+// each call returns an accessor with an empty storage
+var backends = map[string]func(t *testing.T) db.Accessor{
+	"postgres": func(t *testing.T) db.Accessor {
+		_, accessor := common.InitPostgres(true, changesHistoryBlocksCount, common.PostgresSchema, filepath.Join("..", ".."),
+			monitoring.NewEmptyPerformanceMonitor())
+		t.Cleanup(accessor.Destroy)
+		return accessor
+	},
+}
+
+func forEachBackend(t *testing.T, test func(t *testing.T, accessor db.Accessor)) {
+	for name, newAccessor := range backends {
+		t.Run(name, func(t *testing.T) {
+			test(t, newAccessor(t))
+		})
+	}
+}
+
+type blocksFixture struct {
+	addresses []string
+}
+
+func newBlocksFixture() *blocksFixture {
+	res := &blocksFixture{}
+	for i := 0; i < 5; i++ {
+		res.addresses = append(res.addresses, tests.GetRandAddr().Hex())
+	}
+	return res
+}
+
+// data returns block data with a transaction between fixture addresses and balance updates of both of them
+func (f *blocksFixture) data(height uint64) *db.Data {
+	from := f.addresses[int(height)%len(f.addresses)]
+	to := f.addresses[(int(height)+1)%len(f.addresses)]
+	txHash := fmt.Sprintf("0x%064x", height)
+	data := &db.Data{
+		Epoch: 1,
+		Block: db.Block{
+			Height: height,
+			Hash:   strconv.Itoa(int(height)),
+			Time:   int64(height) * 20,
+			Transactions: []db.Transaction{
+				{
+					Hash:   txHash,
+					From:   from,
+					To:     to,
+					Amount: decimal.New(int64(height), 0),
+					Raw:    fmt.Sprintf("%08x", height),
+				},
+			},
+			ProposerVrfScore: float64(height) / 1000,
+		},
+		Coins: db.Coins{
+			TotalBalance: decimal.New(int64(height), 0),
+		},
+	}
+	if height == 1 {
+		for _, address := range f.addresses {
+			data.Addresses = append(data.Addresses, db.Address{Address: address})
+		}
+	}
+	data.ChangedBalances = []db.Balance{
+		{Address: from, Balance: decimal.New(int64(height), 0)},
+		{Address: to, Balance: decimal.New(int64(height)*2, 0)},
+	}
+	return data
+}
+
+func sortedBalances(t *testing.T, accessor db.Accessor) []string {
+	balances, err := accessor.Balances()
+	require.Nil(t, err)
+	var res []string
+	for _, balance := range balances {
+		res = append(res, balance.Address+" "+balance.Balance.String())
+	}
+	sort.Strings(res)
+	return res
+}
+
+func Test_accessorSave(t *testing.T) {
+	fixture := newBlocksFixture()
+	forEachBackend(t, func(t *testing.T, accessor db.Accessor) {
+		for height := uint64(1); height <= 10; height++ {
+			require.Nil(t, accessor.Save(fixture.data(height)))
+		}
+		lastHeight, err := accessor.GetLastHeight()
+		require.Nil(t, err)
+		require.Equal(t, uint64(10), lastHeight)
+		require.Len(t, sortedBalances(t, accessor), len(fixture.addresses))
+
+		gapCnt, err := accessor.BalanceUpdateGapCnt()
+		require.Nil(t, err)
+		require.Zero(t, gapCnt)
+	})
+}
+
+func Test_accessorSaveBatch(t *testing.T) {
+	fixture := newBlocksFixture()
+	var expected []string
+	forEachBackend(t, func(t *testing.T, accessor db.Accessor) {
+		for height := uint64(1); height <= 10; height++ {
+			require.Nil(t, accessor.Save(fixture.data(height)))
+		}
+		expected = sortedBalances(t, accessor)
+	})
+	forEachBackend(t, func(t *testing.T, accessor db.Accessor) {
+		require.Nil(t, accessor.Save(fixture.data(1)))
+		var batch []*db.Data
+		for height := uint64(2); height <= 10; height++ {
+			batch = append(batch, fixture.data(height))
+		}
+		require.Nil(t, accessor.SaveBatch(batch, monitoring.NewEmptyPerformanceMonitor()))
+
+		lastHeight, err := accessor.GetLastHeight()
+		require.Nil(t, err)
+		require.Equal(t, uint64(10), lastHeight)
+		require.Equal(t, expected, sortedBalances(t, accessor))
+	})
+}
+
+func Test_accessorSaveBatchIsAtomic(t *testing.T) {
+	fixture := newBlocksFixture()
+	forEachBackend(t, func(t *testing.T, accessor db.Accessor) {
+		require.Nil(t, accessor.Save(fixture.data(1)))
+		invalid := fixture.data(3)
+		invalid.Block.Height = 2
+		require.NotNil(t, accessor.SaveBatch([]*db.Data{fixture.data(2), invalid}, monitoring.NewEmptyPerformanceMonitor()))
+
+		lastHeight, err := accessor.GetLastHeight()
+		require.Nil(t, err)
+		require.Equal(t, uint64(1), lastHeight)
+	})
+}
+
+func Test_accessorResetTo(t *testing.T) {
+	fixture := newBlocksFixture()
+	forEachBackend(t, func(t *testing.T, accessor db.Accessor) {
+		for height := uint64(1); height <= 10; height++ {
+			require.Nil(t, accessor.Save(fixture.data(height)))
+		}
+		require.Nil(t, accessor.ResetTo(5))
+
+		lastHeight, err := accessor.GetLastHeight()
+		require.Nil(t, err)
+		require.Equal(t, uint64(5), lastHeight)
+
+		// blocks above the reset height can be saved again
+		for height := uint64(6); height <= 10; height++ {
+			require.Nil(t, accessor.Save(fixture.data(height)))
+		}
+		lastHeight, err = accessor.GetLastHeight()
+		require.Nil(t, err)
+		require.Equal(t, uint64(10), lastHeight)
+	})
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/contract/abi"
+	"github.com/idena-network/idena-indexer/core/explorer"
+	types2 "github.com/idena-network/idena-indexer/core/types"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func Test_contractAbi(t *testing.T) {
+	ctx := testCommon.InitIndexer2(testCommon.Options{
+		ClearDb:           true,
+		Schema:            testCommon.PostgresSchema,
+		ScriptsPathPrefix: "..",
+	})
+	listener, bus := ctx.Listener, ctx.EventBus
+	defer listener.Destroy()
+
+	appState := listener.NodeCtx().AppState
+
+	appState.Precommit()
+	require.Nil(t, appState.CommitAt(1))
+	require.Nil(t, appState.Initialize(1))
+
+	statsCollector := listener.StatsCollector()
+	contractAddress := tests.GetRandAddr()
+	to := tests.GetRandAddr()
+
+	// Block 2: deploy
+	statsCollector.EnableCollecting()
+	block := buildBlock(2)
+	payload, err := attachments.CreateDeployContractAttachment(common.Hash{}, []byte{0x1}, nil).ToBytes()
+	require.Nil(t, err)
+	tx := &types.Transaction{AccountNonce: 1, Type: types.DeployContractTx, Payload: payload}
+	statsCollector.BeginApplyingTx(tx, appState)
+	statsCollector.AddWasmContract(contractAddress, []byte{0x1})
+	statsCollector.AddTxReceipt(&types.TxReceipt{Success: true, TxHash: tx.Hash(), GasCost: big.NewInt(1),
+		ContractAddress: contractAddress, Method: "deploy"}, appState)
+	statsCollector.CompleteApplyingTx(appState)
+	block.Body.Transactions = append(block.Body.Transactions, tx)
+	require.Nil(t, applyBlock(bus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	// Block 3: call
+	statsCollector.EnableCollecting()
+	block = buildBlock(3)
+	payload, err = attachments.CreateCallContractAttachment("transfer", to.Bytes(), []byte{0x1, 0x0}).ToBytes()
+	require.Nil(t, err)
+	tx = &types.Transaction{AccountNonce: 2, Type: types.CallContractTx, To: &contractAddress, Payload: payload}
+	statsCollector.BeginApplyingTx(tx, appState)
+	statsCollector.AddTxReceipt(&types.TxReceipt{Success: true, TxHash: tx.Hash(), GasCost: big.NewInt(1),
+		ContractAddress: contractAddress, Method: "transfer", ActionResult: []byte{0x1},
+		Events: []*types.TxEvent{{EventName: "transfer", Data: [][]byte{contractAddress.Bytes(), to.Bytes(), {0x1, 0x0}}}},
+	}, appState)
+	statsCollector.CompleteApplyingTx(appState)
+	block.Body.Transactions = append(block.Body.Transactions, tx)
+	require.Nil(t, applyBlock(bus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	connStr := testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema
+	explorerDb := explorer.NewPostgres(connStr)
+	registry := abi.NewRegistry(abi.NewPostgres(connStr), false)
+
+	address, call, err := explorerDb.TransactionContractCall(tx.Hash().Hex())
+	require.Nil(t, err)
+	require.Equal(t, contractAddress, *address)
+	decoded, err := registry.Decode(*address, call)
+	require.Nil(t, err)
+	require.Nil(t, decoded)
+
+	usrErr, err := registry.Upload(tests.GetRandAddr(), []byte(`{"methods": [{"name": "transfer"}]}`))
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+	usrErr, err = registry.Upload(contractAddress, []byte(`{"methods": [{"name": "transfer", "args": [{"name": "to", "type": "float"}]}]}`))
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+	usrErr, err = abi.NewRegistry(abi.NewPostgres(connStr), true).Upload(contractAddress, []byte(`{"methods": [{"name": "transfer"}]}`))
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+
+	usrErr, err = registry.Upload(contractAddress, []byte(`{
+  "methods": [{"name": "transfer", "args": [{"name": "to", "type": "address"}, {"name": "amount", "type": "bigint"}], "returns": "bool"}],
+  "events": [{"name": "transfer", "args": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "amount", "type": "bigint"}]}]
+}`))
+	require.Nil(t, err)
+	require.Nil(t, usrErr)
+
+	// An existing ABI is never replaced
+	usrErr, err = registry.Upload(contractAddress, []byte(`{"methods": [{"name": "transfer"}]}`))
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+
+	// A new registry reads the saved ABI from the db
+	decoded, err = abi.NewRegistry(abi.NewPostgres(connStr), false).Decode(*address, call)
+	require.Nil(t, err)
+	require.Equal(t, &types2.DecodedContractCall{
+		Method: "transfer",
+		Args: []*types2.DecodedValue{
+			{Name: "to", Type: "address", Value: to.Hex()},
+			{Name: "amount", Type: "bigint", Value: "256"},
+		},
+		Result: &types2.DecodedValue{Type: "bool", Value: true},
+		Events: []*types2.DecodedEvent{{
+			Contract: contractAddress.Hex(),
+			Name:     "transfer",
+			Args: []*types2.DecodedValue{
+				{Name: "from", Type: "address", Value: contractAddress.Hex()},
+				{Name: "to", Type: "address", Value: to.Hex()},
+				{Name: "amount", Type: "bigint", Value: "256"},
+			},
+		}},
+	}, decoded)
+
+	usrErr, err = registry.Delete(contractAddress)
+	require.Nil(t, err)
+	require.Nil(t, usrErr)
+	usrErr, err = registry.Delete(contractAddress)
+	require.Nil(t, err)
+	require.NotNil(t, usrErr)
+	contractAbi, err := registry.Get(contractAddress)
+	require.Nil(t, err)
+	require.Nil(t, contractAbi)
+	usrErr, err = registry.Upload(contractAddress, []byte(`{"methods": [{"name": "transfer"}]}`))
+	require.Nil(t, err)
+	require.Nil(t, usrErr)
+}
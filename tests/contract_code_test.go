@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"github.com/idena-network/idena-go/blockchain/attachments"
+	"github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/contract/wasm"
+	"github.com/idena-network/idena-indexer/core/explorer"
+	types2 "github.com/idena-network/idena-indexer/core/types"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func Test_contractCode(t *testing.T) {
+	ctx := testCommon.InitIndexer2(testCommon.Options{
+		ClearDb:           true,
+		Schema:            testCommon.PostgresSchema,
+		ScriptsPathPrefix: "..",
+	})
+	db, listener, bus := ctx.DbConnector, ctx.Listener, ctx.EventBus
+	defer listener.Destroy()
+
+	appState := listener.NodeCtx().AppState
+
+	appState.Precommit()
+	require.Nil(t, appState.CommitAt(1))
+	require.Nil(t, appState.Initialize(1))
+
+	statsCollector := listener.StatsCollector()
+
+	// header, export section with the "deploy" function, code section with 1 empty body
+	code := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x07, 0x0a, 0x01, 0x06, 'd', 'e', 'p', 'l', 'o', 'y', 0x00, 0x00,
+		0x0a, 0x04, 0x01, 0x02, 0x00, 0x0b}
+	contract1, contract2, contract3 := tests.GetRandAddr(), tests.GetRandAddr(), tests.GetRandAddr()
+
+	deploy := func(height uint64, nonce uint32, contracts map[common.Address][]byte) {
+		statsCollector.EnableCollecting()
+		block := buildBlock(height)
+		payload, err := attachments.CreateDeployContractAttachment(common.Hash{}, []byte{0x1}, nil).ToBytes()
+		require.Nil(t, err)
+		tx := &types.Transaction{AccountNonce: nonce, Type: types.DeployContractTx, Payload: payload}
+		statsCollector.BeginApplyingTx(tx, appState)
+		for address, code := range contracts {
+			statsCollector.AddWasmContract(address, code)
+		}
+		statsCollector.AddTxReceipt(&types.TxReceipt{Success: true, TxHash: tx.Hash(), GasCost: big.NewInt(1),
+			Method: "deploy"}, appState)
+		statsCollector.CompleteApplyingTx(appState)
+		block.Body.Transactions = append(block.Body.Transactions, tx)
+		require.Nil(t, applyBlock(bus, block, appState))
+		statsCollector.CompleteCollecting()
+	}
+	deploy(2, 1, map[common.Address][]byte{contract1: code, contract2: {0x1, 0x2}})
+	deploy(3, 2, map[common.Address][]byte{contract3: code})
+
+	_, err := db.Exec(`INSERT INTO contract_verifications (contract_address_id, state, state_timestamp, file_name)
+VALUES ((SELECT id FROM addresses WHERE address = $1), 1, 100, 'src.zip')`, contract1.Hex())
+	require.Nil(t, err)
+
+	explorerDb := explorer.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+
+	contractCode, err := explorerDb.ContractCode(contract1.Hex())
+	require.Nil(t, err)
+	codeHash := crypto.Hash(code)
+	require.Equal(t, common.Hash(codeHash).Hex(), contractCode.CodeHash)
+	require.Equal(t, uint64(len(code)), contractCode.Size)
+	require.Equal(t, &types2.WasmModule{
+		Exports:   []string{"deploy"},
+		Imports:   []*types2.WasmImport{},
+		Functions: 1,
+		CodeSize:  4,
+	}, contractCode.Module)
+	require.Empty(t, contractCode.ParseError)
+	require.Equal(t, uint64(1), contractCode.Clones)
+	require.Equal(t, "Verified", contractCode.Verification.State)
+	require.Equal(t, "src.zip", contractCode.Verification.FileName)
+
+	contractCode, err = explorerDb.ContractCode(contract2.Hex())
+	require.Nil(t, err)
+	require.Nil(t, contractCode.Module)
+	require.NotEmpty(t, contractCode.ParseError)
+	require.Zero(t, contractCode.Clones)
+	require.Nil(t, contractCode.Verification)
+
+	contractCode, err = explorerDb.ContractCode(tests.GetRandAddr().Hex())
+	require.Nil(t, err)
+	require.Nil(t, contractCode)
+
+	clones, continuationToken, err := explorerDb.ContractClones(contract3.Hex(), 10, nil)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Len(t, clones, 1)
+	require.Equal(t, contract1.Hex(), clones[0].Address)
+
+	// Backfill of contracts deployed before the introspection
+	wasmDb := wasm.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+	addresses, err := wasmDb.GetUnqueuedContracts(wasm.JobTypeIntrospection)
+	require.Nil(t, err)
+	require.Empty(t, addresses)
+
+	_, err = db.Exec(`DELETE FROM contract_codes WHERE contract_address_id = (SELECT id FROM addresses WHERE address = $1)`,
+		contract3.Hex())
+	require.Nil(t, err)
+	addresses, err = wasmDb.GetUnqueuedContracts(wasm.JobTypeIntrospection)
+	require.Nil(t, err)
+	require.Equal(t, []common.Address{contract3}, addresses)
+	contractCode3, err := wasmDb.Code(contract3)
+	require.Nil(t, err)
+	require.Equal(t, code, contractCode3)
+	require.Nil(t, wasmDb.SaveInfo(contract3, wasm.Introspect(contractCode3)))
+	contractCode, err = explorerDb.ContractCode(contract3.Hex())
+	require.Nil(t, err)
+	require.Equal(t, []string{"deploy"}, contractCode.Module.Exports)
+	require.Equal(t, uint64(1), contractCode.Clones)
+
+	// Reset
+	require.Nil(t, ctx.DbAccessor.ResetTo(2))
+	contractCode, err = explorerDb.ContractCode(contract3.Hex())
+	require.Nil(t, err)
+	require.Nil(t, contractCode)
+	clones, _, err = explorerDb.ContractClones(contract1.Hex(), 10, nil)
+	require.Nil(t, err)
+	require.Empty(t, clones)
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/core/explorer"
+	"github.com/idena-network/idena-indexer/db"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func Test_explorer(t *testing.T) {
+	_, dbAccessor := testCommon.InitDefaultPostgres("..")
+	defer dbAccessor.Destroy()
+	explorerDb := explorer.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+	from, to, other := tests.GetRandAddr().Hex(), tests.GetRandAddr().Hex(), tests.GetRandAddr().Hex()
+
+	txHash := func(i int) string {
+		return fmt.Sprintf("0x%064x", 1000+i)
+	}
+	blockData := func(height uint64, txs ...db.Transaction) *db.Data {
+		data := &db.Data{
+			Epoch: 1,
+			Block: db.Block{
+				Height:       height,
+				Hash:         fmt.Sprintf("0x%064x", height),
+				Time:         int64(height) * 20,
+				Transactions: txs,
+			},
+		}
+		if height == 1 {
+			data.Addresses = []db.Address{{Address: from}, {Address: to}, {Address: other}}
+		}
+		return data
+	}
+
+	require.Nil(t, dbAccessor.Save(blockData(1)))
+	require.Nil(t, dbAccessor.Save(blockData(2,
+		db.Transaction{Hash: txHash(1), From: from, To: to, Amount: decimal.New(1, 0), Raw: "01"},
+		db.Transaction{Hash: txHash(2), From: to, To: from, Amount: decimal.New(2, 0), Raw: "01"},
+	)))
+	require.Nil(t, dbAccessor.Save(blockData(3,
+		db.Transaction{Hash: txHash(3), From: other, To: to, Amount: decimal.New(3, 0), Raw: "01"},
+		db.Transaction{Hash: txHash(4), Type: 12, From: from, Raw: "01"},
+	)))
+
+	block, err := explorerDb.Block(2)
+	require.Nil(t, err)
+	require.Equal(t, uint64(2), block.Height)
+	require.Equal(t, fmt.Sprintf("0x%064x", 2), block.Hash)
+	require.Equal(t, uint32(2), block.TxCount)
+	require.Equal(t, int64(40), block.Timestamp.Unix())
+
+	block, err = explorerDb.BlockByHash(fmt.Sprintf("0x%064X", 3))
+	require.Nil(t, err)
+	require.Equal(t, uint64(3), block.Height)
+
+	block, err = explorerDb.Block(4)
+	require.Nil(t, err)
+	require.Nil(t, block)
+
+	blockTxs, continuationToken, err := explorerDb.BlockTxs(2, 1, nil)
+	require.Nil(t, err)
+	require.Len(t, blockTxs, 1)
+	require.Equal(t, txHash(1), blockTxs[0].Hash)
+	require.NotNil(t, continuationToken)
+	blockTxs, continuationToken, err = explorerDb.BlockTxs(2, 1, continuationToken)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Equal(t, txHash(2), blockTxs[0].Hash)
+
+	tx, err := explorerDb.Transaction(txHash(2))
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), tx.Epoch)
+	require.Equal(t, uint64(2), tx.BlockHeight)
+	require.Equal(t, fmt.Sprintf("0x%064x", 2), tx.BlockHash)
+	require.Equal(t, "SendTx", tx.Type)
+	require.Equal(t, to, tx.From)
+	require.Equal(t, from, tx.To)
+	require.Equal(t, "2", tx.Amount.String())
+
+	tx, err = explorerDb.Transaction(txHash(5))
+	require.Nil(t, err)
+	require.Nil(t, tx)
+
+	addressTxs, continuationToken, err := explorerDb.AddressTxs(from, nil, 2, nil)
+	require.Nil(t, err)
+	require.Len(t, addressTxs, 2)
+	require.Equal(t, txHash(4), addressTxs[0].Hash)
+	require.Equal(t, "BurnTx", addressTxs[0].Type)
+	require.Equal(t, txHash(2), addressTxs[1].Hash)
+	require.NotNil(t, continuationToken)
+	addressTxs, continuationToken, err = explorerDb.AddressTxs(from, nil, 2, continuationToken)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Len(t, addressTxs, 1)
+	require.Equal(t, txHash(1), addressTxs[0].Hash)
+
+	addressTxs, continuationToken, err = explorerDb.AddressTxs(from, []uint16{0}, 10, nil)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Len(t, addressTxs, 2)
+	require.Equal(t, txHash(2), addressTxs[0].Hash)
+	require.Equal(t, txHash(1), addressTxs[1].Hash)
+
+	addressTxs, _, err = explorerDb.AddressTxs(tests.GetRandAddr().Hex(), nil, 10, nil)
+	require.Nil(t, err)
+	require.Empty(t, addressTxs)
+}
+
+func Test_explorerBalanceAt(t *testing.T) {
+	dbConnector, dbAccessor := testCommon.InitDefaultPostgres("..")
+	defer dbAccessor.Destroy()
+	explorerDb := explorer.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+	addr := tests.GetRandAddr()
+
+	blockData := func(height uint64, balanceOld, stakeOld, balanceNew, stakeNew int64) *db.Data {
+		data := &db.Data{
+			Epoch: 1,
+			Block: db.Block{
+				Height: height,
+				Hash:   fmt.Sprintf("0x%064x", height),
+				Time:   int64(height) * 20,
+			},
+		}
+		if height == 1 {
+			data.Addresses = []db.Address{{Address: addr.Hex()}}
+		}
+		if balanceOld != balanceNew || stakeOld != stakeNew {
+			data.BalanceUpdates = []*db.BalanceUpdate{{
+				Address:    addr,
+				BalanceOld: big.NewInt(balanceOld),
+				StakeOld:   big.NewInt(stakeOld),
+				BalanceNew: big.NewInt(balanceNew),
+				StakeNew:   big.NewInt(stakeNew),
+				Reason:     db.ProposerRewardReason,
+			}}
+			data.ChangedBalances = []db.Balance{{
+				Address: addr.Hex(),
+				Balance: decimal.New(balanceNew, 0),
+				Stake:   decimal.New(stakeNew, 0),
+			}}
+		}
+		return data
+	}
+
+	require.Nil(t, dbAccessor.Save(blockData(1, 0, 0, 0, 0)))
+	require.Nil(t, dbAccessor.Save(blockData(2, 0, 0, 10, 1)))
+	_, err := dbConnector.Exec("call save_balance_checkpoint(2)")
+	require.Nil(t, err)
+	require.Nil(t, dbAccessor.Save(blockData(3, 0, 0, 0, 0)))
+	require.Nil(t, dbAccessor.Save(blockData(4, 10, 1, 15, 2)))
+
+	check := func(height uint64, expectedBalance, expectedStake int64) {
+		balance, err := explorerDb.BalanceAt(addr.Hex(), height)
+		require.Nil(t, err)
+		require.NotNil(t, balance)
+		require.Equal(t, explorer.BalanceSourceDb, balance.Source)
+		require.Equal(t, decimal.New(expectedBalance, 0).String(), balance.Balance.String(), "height %v", height)
+		require.Equal(t, decimal.New(expectedStake, 0).String(), balance.Stake.String(), "height %v", height)
+	}
+	check(1, 0, 0)
+	check(2, 10, 1)
+	check(3, 10, 1)
+	check(4, 15, 2)
+
+	balance, err := explorerDb.BalanceAt(addr.Hex(), 0)
+	require.Nil(t, err)
+	require.Nil(t, balance)
+
+	balance, err = explorerDb.BalanceAt(tests.GetRandAddr().Hex(), 3)
+	require.Nil(t, err)
+	require.True(t, balance.Balance.IsZero())
+
+	// the checkpoint is removed with the block
+	require.Nil(t, dbAccessor.ResetTo(1))
+	var checkpoints int
+	require.Nil(t, dbConnector.QueryRow("SELECT count(*) FROM balance_checkpoints").Scan(&checkpoints))
+	require.Zero(t, checkpoints)
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	types2 "github.com/idena-network/idena-go/blockchain/types"
+	"github.com/idena-network/idena-go/common"
+	"github.com/idena-network/idena-go/crypto"
+	"github.com/idena-network/idena-indexer/db"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/plugins/largetransfers"
+	"github.com/idena-network/idena-indexer/plugins/manifest"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_largeTransfersPlugin(t *testing.T) {
+	ctx := testCommon.InitIndexer2(testCommon.Options{
+		ClearDb:           true,
+		Schema:            testCommon.PostgresSchema,
+		ScriptsPathPrefix: "..",
+		Plugins:           []db.Plugin{largetransfers.New(decimal.New(10, 0))},
+	})
+	defer ctx.Listener.Destroy()
+	appState := ctx.Listener.NodeCtx().AppState
+	statsCollector := ctx.Listener.StatsCollector()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.Address{0x1}
+	appState.State.SetBalance(sender, dna(1000))
+	appState.Precommit()
+	require.Nil(t, appState.CommitAt(1))
+	require.Nil(t, appState.Initialize(1))
+
+	sendTx := func(amount int, nonce uint32) *types2.Transaction {
+		tx, _ := types2.SignTx(&types2.Transaction{
+			Type:         types2.SendTx,
+			To:           &recipient,
+			Amount:       dna(amount),
+			AccountNonce: nonce,
+		}, key)
+		return tx
+	}
+
+	statsCollector.EnableCollecting()
+	block := buildBlock(2)
+	largeTx, smallTx := sendTx(50, 1), sendTx(5, 2)
+	block.Body.Transactions = append(block.Body.Transactions, largeTx, smallTx)
+	appState.State.SetBalance(sender, dna(945))
+	require.Nil(t, applyBlock(ctx.EventBus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	statsCollector.EnableCollecting()
+	block = buildBlock(3)
+	block.Body.Transactions = append(block.Body.Transactions, sendTx(10, 3))
+	appState.State.SetBalance(sender, dna(935))
+	require.Nil(t, applyBlock(ctx.EventBus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	require.Eventually(t, func() bool {
+		return ctx.Indexer.LastIndexedHeight() == 3
+	}, time.Minute, time.Millisecond*10)
+
+	type largeTransfer struct {
+		txHash        string
+		height        uint64
+		to            string
+		amount        string
+		senderBalance string
+	}
+	readLargeTransfers := func() []largeTransfer {
+		rows, err := ctx.DbConnector.Query(`SELECT tx_hash, block_height, "to", amount, sender_balance FROM large_transfers ORDER BY block_height`)
+		require.Nil(t, err)
+		defer rows.Close()
+		var res []largeTransfer
+		for rows.Next() {
+			var item largeTransfer
+			var amount, senderBalance decimal.Decimal
+			require.Nil(t, rows.Scan(&item.txHash, &item.height, &item.to, &amount, &senderBalance))
+			item.amount, item.senderBalance = amount.String(), senderBalance.String()
+			res = append(res, item)
+		}
+		return res
+	}
+
+	transfers := readLargeTransfers()
+	require.Len(t, transfers, 2)
+	require.Equal(t, largeTransfer{largeTx.Hash().Hex(), 2, recipient.Hex(), "50", "945"}, transfers[0])
+	require.Equal(t, uint64(3), transfers[1].height)
+	require.Equal(t, "10", transfers[1].amount)
+	require.Equal(t, "935", transfers[1].senderBalance)
+
+	_, err := ctx.DbAccessor.ResetToWithHistory(2, "0x3", time.Now())
+	require.Nil(t, err)
+	transfers = readLargeTransfers()
+	require.Len(t, transfers, 1)
+	require.Equal(t, largeTx.Hash().Hex(), transfers[0].txHash)
+}
+
+func Test_manifestsPlugin(t *testing.T) {
+	contract := common.Address{0x5}
+	lottery, err := manifest.Parse([]byte(`
+name: lottery
+contract: "` + contract.Hex() + `"
+events:
+  - name: Win
+    table: lottery_wins
+    args:
+      - name: winner
+        type: address
+        indexed: true
+      - name: prize
+        type: dna
+      - name: round
+        type: uint64
+`))
+	require.Nil(t, err)
+	ctx := testCommon.InitIndexer2(testCommon.Options{
+		ClearDb:           true,
+		Schema:            testCommon.PostgresSchema,
+		ScriptsPathPrefix: "..",
+		Plugins:           []db.Plugin{manifest.NewPlugin([]*manifest.Manifest{lottery}, log.New())},
+	})
+	defer ctx.Listener.Destroy()
+	appState := ctx.Listener.NodeCtx().AppState
+	statsCollector := ctx.Listener.StatsCollector()
+
+	appState.Precommit()
+	require.Nil(t, appState.CommitAt(1))
+	require.Nil(t, appState.Initialize(1))
+
+	winner1, winner2 := common.Address{0x1}, common.Address{0x2}
+	applyTx := func(nonce uint32, success bool, events ...*types2.TxEvent) *types2.Transaction {
+		tx := &types2.Transaction{AccountNonce: nonce, Type: types2.CallContractTx, To: &contract}
+		statsCollector.BeginApplyingTx(tx, appState)
+		statsCollector.AddTxReceipt(&types2.TxReceipt{Success: success, TxHash: tx.Hash(), ContractAddress: contract,
+			Events: events}, appState)
+		statsCollector.CompleteApplyingTx(appState)
+		return tx
+	}
+	win := func(contract common.Address, winner common.Address, prize int, round uint64) *types2.TxEvent {
+		return &types2.TxEvent{
+			Contract:  contract,
+			EventName: "Win",
+			Data:      [][]byte{winner.Bytes(), dna(prize).Bytes(), common.ToBytes(round)},
+		}
+	}
+
+	statsCollector.EnableCollecting()
+	block := buildBlock(2)
+	tx1 := applyTx(1, true,
+		&types2.TxEvent{Contract: contract, EventName: "Start"},
+		win(contract, winner1, 10, 1),
+		win(common.Address{0x6}, winner1, 20, 1),
+	)
+	tx2 := applyTx(2, false, win(contract, winner2, 30, 1))
+	block.Body.Transactions = append(block.Body.Transactions, tx1, tx2)
+	require.Nil(t, applyBlock(ctx.EventBus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	statsCollector.EnableCollecting()
+	block = buildBlock(3)
+	tx3 := applyTx(3, true, &types2.TxEvent{
+		Contract:  contract,
+		EventName: "Win",
+		Data:      [][]byte{winner2.Bytes(), make([]byte, 40)},
+	})
+	block.Body.Transactions = append(block.Body.Transactions, tx3)
+	require.Nil(t, applyBlock(ctx.EventBus, block, appState))
+	statsCollector.CompleteCollecting()
+
+	require.Eventually(t, func() bool {
+		return ctx.Indexer.LastIndexedHeight() == 3
+	}, time.Minute, time.Millisecond*10)
+
+	reader := manifest.NewPostgres(testCommon.PostgresConnStr+"&search_path="+testCommon.PostgresSchema,
+		[]*manifest.Manifest{lottery})
+	wins, nextContinuationToken, err := reader.Events("lottery", "win", nil, 1, nil)
+	require.Nil(t, err)
+	require.Len(t, wins, 1)
+	require.NotNil(t, nextContinuationToken)
+	require.Equal(t, uint64(3), wins[0].BlockHeight)
+	require.Equal(t, tx3.Hash().Hex(), wins[0].TxHash)
+	require.Equal(t, map[string]interface{}{"winner": winner2.Hex(), "prize": "0", "round": nil}, wins[0].Args)
+
+	wins, nextContinuationToken, err = reader.Events("lottery", "win", nil, 1, nextContinuationToken)
+	require.Nil(t, err)
+	require.Len(t, wins, 1)
+	require.Nil(t, nextContinuationToken)
+	require.Equal(t, uint64(2), wins[0].BlockHeight)
+	require.Equal(t, tx1.Hash().Hex(), wins[0].TxHash)
+	require.Equal(t, contract.Hex(), wins[0].Contract)
+	require.Equal(t, map[string]interface{}{"winner": winner1.Hex(), "prize": "10", "round": "1"}, wins[0].Args)
+
+	wins, _, err = reader.Events("lottery", "win", map[string]string{"winner": strings.ToLower(winner1.Hex())}, 10, nil)
+	require.Nil(t, err)
+	require.Len(t, wins, 1)
+	require.Equal(t, tx1.Hash().Hex(), wins[0].TxHash)
+
+	_, err = ctx.DbAccessor.ResetToWithHistory(2, "0x3", time.Now())
+	require.Nil(t, err)
+	wins, _, err = reader.Events("lottery", "win", nil, 10, nil)
+	require.Nil(t, err)
+	require.Len(t, wins, 1)
+	require.Equal(t, tx1.Hash().Hex(), wins[0].TxHash)
+}
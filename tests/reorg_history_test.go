@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/idena-network/idena-go/tests"
+	"github.com/idena-network/idena-indexer/core/reorg"
+	"github.com/idena-network/idena-indexer/db"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_reorgHistory(t *testing.T) {
+	_, dbAccessor := testCommon.InitDefaultPostgres("..")
+	defer dbAccessor.Destroy()
+	reorgDb := reorg.NewPostgres(testCommon.PostgresConnStr + "&search_path=" + testCommon.PostgresSchema)
+	from, to := tests.GetRandAddr().Hex(), tests.GetRandAddr().Hex()
+
+	blockData := func(height uint64, txHashes ...string) *db.Data {
+		data := &db.Data{
+			Epoch: 1,
+			Block: db.Block{
+				Height: height,
+				Hash:   fmt.Sprintf("0x%064x", height),
+				Time:   int64(height) * 20,
+			},
+		}
+		if height == 1 {
+			data.Addresses = []db.Address{{Address: from}, {Address: to}}
+		}
+		for _, hash := range txHashes {
+			data.Block.Transactions = append(data.Block.Transactions, db.Transaction{
+				Hash:   hash,
+				From:   from,
+				To:     to,
+				Amount: decimal.New(1, 0),
+				Raw:    "01",
+			})
+		}
+		return data
+	}
+	txHash := func(i int) string {
+		return fmt.Sprintf("0x%064x", 1000+i)
+	}
+
+	require.Nil(t, dbAccessor.Save(blockData(1)))
+	for height := uint64(2); height <= 5; height++ {
+		require.Nil(t, dbAccessor.Save(blockData(height, txHash(int(height)))))
+	}
+
+	reorgRecord, err := dbAccessor.ResetToWithHistory(3, "0x4", time.Unix(100, 0))
+	require.Nil(t, err)
+	require.Equal(t, uint64(3), reorgRecord.ResetHeight)
+	require.Equal(t, uint64(5), reorgRecord.OldTipHeight)
+	require.Equal(t, fmt.Sprintf("0x%064x", 5), reorgRecord.OldTipHash)
+	require.Equal(t, "0x4", reorgRecord.NewTipHash)
+	require.Equal(t, uint64(2), reorgRecord.Depth)
+	require.Equal(t, uint64(2), reorgRecord.RemovedTxs)
+	require.Equal(t, int64(100), reorgRecord.Timestamp)
+
+	// the tx of the block 5 is included by the new block 4, the tx of the old block 4 is dropped
+	require.Nil(t, dbAccessor.Save(blockData(4, txHash(5))))
+
+	reorgs, continuationToken, err := reorgDb.Reorgs(10, nil)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Len(t, reorgs, 1)
+	require.Equal(t, reorgRecord.Id, reorgs[0].Id)
+	require.Equal(t, uint64(2), reorgs[0].Depth)
+
+	txs, err := reorgDb.ReorgTxs(reorgRecord.Id)
+	require.Nil(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, txHash(4), txs[0].Hash)
+	require.Nil(t, txs[0].ReincludedBlockHeight)
+	require.Equal(t, txHash(5), txs[1].Hash)
+	require.NotNil(t, txs[1].ReincludedBlockHeight)
+	require.Equal(t, uint64(4), *txs[1].ReincludedBlockHeight)
+
+	// the tx is not reincluded anymore after its block is reset
+	_, err = dbAccessor.ResetToWithHistory(3, "0x4", time.Unix(200, 0))
+	require.Nil(t, err)
+	txs, err = reorgDb.ReorgTxs(reorgRecord.Id)
+	require.Nil(t, err)
+	require.Nil(t, txs[1].ReincludedBlockHeight)
+
+	reorgs, continuationToken, err = reorgDb.Reorgs(1, nil)
+	require.Nil(t, err)
+	require.Len(t, reorgs, 1)
+	require.NotNil(t, continuationToken)
+	reorgs, continuationToken, err = reorgDb.Reorgs(1, continuationToken)
+	require.Nil(t, err)
+	require.Nil(t, continuationToken)
+	require.Equal(t, reorgRecord.Id, reorgs[0].Id)
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"database/sql"
+	"github.com/idena-network/idena-indexer/log"
+	"github.com/idena-network/idena-indexer/migration/schema"
+	"github.com/idena-network/idena-indexer/monitoring"
+	testCommon "github.com/idena-network/idena-indexer/tests/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func initMigrationsTest(t *testing.T) (*sql.DB, string) {
+	testCommon.InitPostgres(true, 0, testCommon.PostgresSchema, "..", monitoring.NewEmptyPerformanceMonitor())
+	db, err := sql.Open("postgres", testCommon.PostgresConnStr+"&search_path="+testCommon.PostgresSchema)
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db, t.TempDir()
+}
+
+func writeMigration(t *testing.T, dir, name, content string) {
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var res bool
+	require.Nil(t, db.QueryRow("SELECT to_regclass($1) IS NOT NULL", table).Scan(&res))
+	return res
+}
+
+func Test_schemaMigrationsFreshSchemaBaseline(t *testing.T) {
+	db, _ := initMigrationsTest(t)
+
+	migrations, err := schema.LoadMigrations(filepath.Join("..", "resources", "scripts", "migration"))
+	require.Nil(t, err)
+	var cnt, baselineCnt int
+	require.Nil(t, db.QueryRow("SELECT count(*), count(*) FILTER (WHERE baseline) FROM schema_migrations").Scan(&cnt, &baselineCnt))
+	require.Equal(t, len(migrations), cnt)
+	require.Equal(t, len(migrations), baselineCnt)
+}
+
+func Test_schemaMigrationsUpAndDownTo(t *testing.T) {
+	db, dir := initMigrationsTest(t)
+	writeMigration(t, dir, "20990101-a.sql", "CREATE TABLE test_migration_a (id bigint);")
+	writeMigration(t, dir, "20990101-a.down.sql", "DROP TABLE test_migration_a;")
+	writeMigration(t, dir, "20990102-b.sql", "CREATE TABLE test_migration_b (id bigint);")
+	writeMigration(t, dir, "20990102-b.down.sql", "DROP TABLE test_migration_b;")
+	migrator := schema.NewMigrator(db, dir, "", log.New("component", "migrator"))
+
+	cnt, err := migrator.Up(false)
+	require.Nil(t, err)
+	require.Equal(t, 2, cnt)
+	require.True(t, tableExists(t, db, "test_migration_a"))
+	require.True(t, tableExists(t, db, "test_migration_b"))
+
+	cnt, err = migrator.Up(false)
+	require.Nil(t, err)
+	require.Zero(t, cnt)
+
+	statuses, err := migrator.Status()
+	require.Nil(t, err)
+	applied := make(map[string]schema.Status)
+	for _, status := range statuses {
+		applied[status.Version] = status
+	}
+	require.True(t, applied["20990101-a"].Applied)
+	require.False(t, applied["20990101-a"].Baseline)
+	require.True(t, applied["20990102-b"].Applied)
+
+	cnt, err = migrator.DownTo("20990101-a")
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	require.True(t, tableExists(t, db, "test_migration_a"))
+	require.False(t, tableExists(t, db, "test_migration_b"))
+
+	cnt, err = migrator.Up(false)
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	require.True(t, tableExists(t, db, "test_migration_b"))
+}
+
+func Test_schemaMigrationsChecksumDrift(t *testing.T) {
+	db, dir := initMigrationsTest(t)
+	writeMigration(t, dir, "20990101-a.sql", "CREATE TABLE test_migration_a (id bigint);")
+	migrator := schema.NewMigrator(db, dir, "", log.New("component", "migrator"))
+	_, err := migrator.Up(false)
+	require.Nil(t, err)
+
+	writeMigration(t, dir, "20990101-a.sql", "CREATE TABLE test_migration_a (id bigint, name text);")
+	writeMigration(t, dir, "20990102-b.sql", "CREATE TABLE test_migration_b (id bigint);")
+	cnt, err := migrator.Up(false)
+	require.Zero(t, cnt)
+	require.IsType(t, &schema.ChecksumMismatchError{}, err)
+	require.True(t, schema.IsFatal(err))
+	require.False(t, tableExists(t, db, "test_migration_b"))
+}
+
+func Test_schemaMigrationsFailedScript(t *testing.T) {
+	db, dir := initMigrationsTest(t)
+	writeMigration(t, dir, "20990101-a.sql", "CREATE TABLE test_migration_a (id bigint); SELECT * FROM unknown_table;")
+	migrator := schema.NewMigrator(db, dir, "", log.New("component", "migrator"))
+
+	cnt, err := migrator.Up(false)
+	require.Zero(t, cnt)
+	require.IsType(t, &schema.ApplyError{}, err)
+	require.True(t, schema.IsFatal(err))
+	require.False(t, tableExists(t, db, "test_migration_a"))
+	var applied bool
+	require.Nil(t, db.QueryRow("SELECT exists(SELECT 1 FROM schema_migrations WHERE version = '20990101-a')").Scan(&applied))
+	require.False(t, applied)
+}
+
+func Test_schemaMigrationsExistingSchemaBaseline(t *testing.T) {
+	db, dir := initMigrationsTest(t)
+	_, err := db.Exec("DROP TABLE schema_migrations")
+	require.Nil(t, err)
+	writeMigration(t, dir, "20990101-a.sql", "CREATE TABLE test_migration_a (id bigint);")
+	writeMigration(t, dir, "20990102-b.sql", "CREATE TABLE test_migration_b (id bigint);")
+
+	// Existing schema without explicit baseline
+	migrator := schema.NewMigrator(db, dir, "", log.New("component", "migrator"))
+	_, err = migrator.Up(false)
+	require.IsType(t, &schema.BaselineError{}, errors.Cause(err))
+	require.True(t, schema.IsFatal(err))
+	require.False(t, tableExists(t, db, "schema_migrations"))
+
+	// Existing schema with explicit baseline
+	migrator = schema.NewMigrator(db, dir, "20990101-a", log.New("component", "migrator"))
+	cnt, err := migrator.Up(false)
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	require.False(t, tableExists(t, db, "test_migration_a"))
+	require.True(t, tableExists(t, db, "test_migration_b"))
+
+	statuses, err := migrator.Status()
+	require.Nil(t, err)
+	require.Len(t, statuses, 2)
+	require.True(t, statuses[0].Baseline)
+	require.True(t, statuses[1].Applied)
+	require.False(t, statuses[1].Baseline)
+}